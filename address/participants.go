@@ -0,0 +1,163 @@
+package address
+
+import (
+	"encoding/json"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"strings"
+)
+
+// Participant Address involved in a transaction and its role
+type Participant struct {
+	Address string
+	Role    string
+}
+
+// ExtractParticipants Return addresses involved in the transaction except the sender.
+// Addresses of validators the transaction refers to by public key are not known from the transaction itself
+func ExtractParticipants(txType uint64, data *anypb.Any, tags map[string]string, rawTx string) ([]Participant, error) {
+	list, err := dataParticipants(txType, data, tags)
+	if err != nil {
+		return list, err
+	}
+	signers, err := MultisigSigners(rawTx)
+	if err != nil {
+		return list, err
+	}
+	for _, signer := range signers {
+		list = append(list, Participant{Address: helpers.RemovePrefix(signer), Role: models.TxRoleMultisigSigner})
+	}
+	return list, nil
+}
+
+// dataParticipants Return addresses of the transaction data and tags
+func dataParticipants(txType uint64, data *anypb.Any, tags map[string]string) ([]Participant, error) {
+	var list []Participant
+	add := func(address, role string) {
+		if address != "" {
+			list = append(list, Participant{Address: helpers.RemovePrefix(address), Role: role})
+		}
+	}
+
+	switch transaction.Type(txType) {
+	case transaction.TypeSend:
+		txData := new(api_pb.SendData)
+		if err := data.UnmarshalTo(txData); err != nil {
+			return list, err
+		}
+		add(txData.To, models.TxRoleRecipient)
+	case transaction.TypeMultisend:
+		txData := new(api_pb.MultiSendData)
+		if err := data.UnmarshalTo(txData); err != nil {
+			return list, err
+		}
+		for _, receiver := range txData.List {
+			add(receiver.To, models.TxRoleRecipient)
+		}
+	case transaction.TypeCreateMultisig:
+		txData := new(api_pb.CreateMultisigData)
+		if err := data.UnmarshalTo(txData); err != nil {
+			return list, err
+		}
+		for _, adr := range txData.Addresses {
+			add(adr, models.TxRoleMultisigOwner)
+		}
+	case transaction.TypeEditMultisig:
+		txData := new(api_pb.EditMultisigData)
+		if err := data.UnmarshalTo(txData); err != nil {
+			return list, err
+		}
+		for _, adr := range txData.Addresses {
+			add(adr, models.TxRoleMultisigOwner)
+		}
+	case transaction.TypeRedeemCheck:
+		txData := new(api_pb.RedeemCheckData)
+		if err := data.UnmarshalTo(txData); err != nil {
+			return list, err
+		}
+		decoded, err := transaction.DecodeCheckBase64(txData.RawCheck)
+		if err != nil {
+			return list, err
+		}
+		sender, err := decoded.Sender()
+		if err != nil {
+			return list, err
+		}
+		add(sender, models.TxRoleCheckIssuer)
+	case transaction.TypeEditCoinOwner:
+		txData := new(api_pb.EditCoinOwnerData)
+		if err := data.UnmarshalTo(txData); err != nil {
+			return list, err
+		}
+		add(txData.NewOwner, models.TxRoleCoinOwner)
+	case transaction.TypeDeclareCandidacy:
+		txData := new(api_pb.DeclareCandidacyData)
+		if err := data.UnmarshalTo(txData); err != nil {
+			return list, err
+		}
+		add(txData.Address, models.TxRoleValidator)
+	case transaction.TypeEditCandidate:
+		txData := new(api_pb.EditCandidateData)
+		if err := data.UnmarshalTo(txData); err != nil {
+			return list, err
+		}
+		add(txData.OwnerAddress, models.TxRoleValidator)
+		add(txData.ControlAddress, models.TxRoleValidator)
+		add(txData.RewardAddress, models.TxRoleValidator)
+	case transaction.TypeBuySwapPool,
+		transaction.TypeSellSwapPool,
+		transaction.TypeSellAllSwapPool:
+		sellers, err := orderSellers(tags)
+		if err != nil {
+			return list, err
+		}
+		for _, seller := range sellers {
+			add(seller, models.TxRoleOrderSeller)
+		}
+	}
+	return list, nil
+}
+
+// orderSellers Return sellers of limit orders filled by a swap
+func orderSellers(tags map[string]string) ([]string, error) {
+	jsonString := strings.Replace(tags["tx.pools"], `\`, "", -1)
+	if jsonString == "" {
+		return nil, nil
+	}
+	var tagPools []models.BuySwapPoolTag
+	if err := json.Unmarshal([]byte(jsonString), &tagPools); err != nil {
+		return nil, err
+	}
+	var sellers []string
+	for _, p := range tagPools {
+		for _, i := range p.Details.Orders {
+			sellers = append(sellers, i.Seller)
+		}
+		for _, i := range p.Sellers {
+			sellers = append(sellers, i.Seller)
+		}
+	}
+	return sellers, nil
+}
+
+// MultisigSigners Return addresses which signed the transaction when it is sent from a multisig address
+func MultisigSigners(rawTx string) ([]string, error) {
+	decoded, err := transaction.Decode("0x" + strings.TrimPrefix(rawTx, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	if decoded.GetTransaction().SignatureType != transaction.SignatureTypeMulti {
+		return nil, nil
+	}
+	signers, err := decoded.Signers()
+	if err != nil {
+		return nil, err
+	}
+	for i, signer := range signers {
+		signers[i] = strings.TrimPrefix(signer, "Mx")
+	}
+	return signers, nil
+}
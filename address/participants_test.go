@@ -0,0 +1,115 @@
+package address
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"reflect"
+	"testing"
+)
+
+func TestDataParticipants(t *testing.T) {
+	const (
+		first  = "7633980c000139dd3bd24a3f54e06474fa941e16"
+		second = "ee81347211c72524338f9680072af90744333146"
+	)
+	swapTags := map[string]string{
+		"tx.pools": `[{"pool_id":1,"details":{"orders":[{"id":5,"seller":"Mx` + first + `"}]},"sellers":[{"seller":"Mx` + second + `","value":"1"}]}]`,
+	}
+
+	tests := []struct {
+		name    string
+		txType  transaction.Type
+		data    proto.Message
+		tags    map[string]string
+		want    []Participant
+		wantErr bool
+	}{
+		{
+			name:   "send",
+			txType: transaction.TypeSend,
+			data:   &api_pb.SendData{To: "Mx" + first, Value: "1"},
+			want:   []Participant{{first, models.TxRoleRecipient}},
+		},
+		{
+			name:   "multisend",
+			txType: transaction.TypeMultisend,
+			data:   &api_pb.MultiSendData{List: []*api_pb.SendData{{To: "Mx" + first}, {To: "Mx" + second}}},
+			want:   []Participant{{first, models.TxRoleRecipient}, {second, models.TxRoleRecipient}},
+		},
+		{
+			name:   "create multisig",
+			txType: transaction.TypeCreateMultisig,
+			data:   &api_pb.CreateMultisigData{Addresses: []string{"Mx" + first, "Mx" + second}},
+			want:   []Participant{{first, models.TxRoleMultisigOwner}, {second, models.TxRoleMultisigOwner}},
+		},
+		{
+			name:   "edit coin owner",
+			txType: transaction.TypeEditCoinOwner,
+			data:   &api_pb.EditCoinOwnerData{NewOwner: "Mx" + second},
+			want:   []Participant{{second, models.TxRoleCoinOwner}},
+		},
+		{
+			name:   "declare candidacy",
+			txType: transaction.TypeDeclareCandidacy,
+			data:   &api_pb.DeclareCandidacyData{Address: "Mx" + first},
+			want:   []Participant{{first, models.TxRoleValidator}},
+		},
+		{
+			name:   "edit candidate without control address",
+			txType: transaction.TypeEditCandidate,
+			data:   &api_pb.EditCandidateData{OwnerAddress: "Mx" + first, RewardAddress: "Mx" + second},
+			want:   []Participant{{first, models.TxRoleValidator}, {second, models.TxRoleValidator}},
+		},
+		{
+			name:   "swap with orders",
+			txType: transaction.TypeSellSwapPool,
+			data:   &api_pb.SellSwapPoolData{},
+			tags:   swapTags,
+			want:   []Participant{{first, models.TxRoleOrderSeller}, {second, models.TxRoleOrderSeller}},
+		},
+		{
+			name:   "swap without orders",
+			txType: transaction.TypeBuySwapPool,
+			data:   &api_pb.BuySwapPoolData{},
+		},
+		{
+			name:    "broken swap tags",
+			txType:  transaction.TypeSellAllSwapPool,
+			data:    &api_pb.SellAllSwapPoolData{},
+			tags:    map[string]string{"tx.pools": "["},
+			wantErr: true,
+		},
+		{
+			name:   "delegate",
+			txType: transaction.TypeDelegate,
+			data:   &api_pb.DelegateData{},
+		},
+		{
+			name:    "data of another type",
+			txType:  transaction.TypeSend,
+			data:    &api_pb.DelegateData{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := anypb.New(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := dataParticipants(uint64(tt.txType), data, tt.tags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dataParticipants() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dataParticipants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
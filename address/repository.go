@@ -1,25 +1,42 @@
 package address
 
 import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/cache"
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
 	"github.com/go-pg/pg/v10"
-	"sync"
+	"sort"
 )
 
 type Repository struct {
 	db       *pg.DB
-	cache    *sync.Map
-	invCache *sync.Map
+	cache    *cache.Cache
+	invCache *cache.Cache
 }
 
-func NewRepository(db *pg.DB) *Repository {
+func NewRepository(db *pg.DB, cacheConfig cache.Config) *Repository {
 	return &Repository{
 		db:       db,
-		cache:    new(sync.Map), //TODO: добавить реализацию очистки
-		invCache: new(sync.Map), //TODO: добавить реализацию очистки
+		cache:    cache.New("address", cacheConfig),
+		invCache: cache.New("address_by_id", cacheConfig),
 	}
 }
 
+// WarmUp Load addresses with the latest activity into the cache
+func (r *Repository) WarmUp(limit int) (int, error) {
+	var list []*models.Address
+	err := r.db.Model(&list).
+		Column("address.id", "address.address").
+		Join("JOIN address_stats AS s ON s.address_id = address.id").
+		OrderExpr("s.last_block_id DESC").
+		Limit(limit).
+		Select()
+	if err != nil {
+		return 0, err
+	}
+	r.addToCache(list)
+	return len(list), nil
+}
+
 //Find address id
 func (r *Repository) FindId(address string) (uint, error) {
 	//First look in the cache
@@ -58,6 +75,122 @@ func (r *Repository) FindIdOrCreate(address string) (uint, error) {
 	return adr.ID, err
 }
 
+// FindIds Resolve ids of addresses in one query, addresses which don't exist are skipped
+func (r *Repository) FindIds(addresses []string) (map[string]uint, error) {
+	result, missing := r.loadIds(addresses)
+	if len(missing) == 0 {
+		return result, nil
+	}
+	var list []*models.Address
+	err := r.db.Model(&list).Column("id", "address").Where("address in (?)", pg.In(missing)).Select()
+	if err != nil {
+		return nil, err
+	}
+	r.addToCache(list)
+	for _, a := range list {
+		result[a.Address] = a.ID
+	}
+	return result, nil
+}
+
+// FindIdsOrCreate Resolve ids of addresses in one round-trip, missing addresses are created
+func (r *Repository) FindIdsOrCreate(addresses []string) (map[string]uint, error) {
+	result, missing := r.loadIds(addresses)
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	// sorted input keeps the same lock order between concurrent workers
+	sort.Strings(missing)
+	var list []*models.Address
+	_, err := r.db.Query(&list, `
+WITH input (address) AS (SELECT unnest(?::text[])),
+     inserted AS (
+         INSERT INTO addresses (address)
+             SELECT address FROM input ORDER BY address
+         ON CONFLICT DO NOTHING
+         RETURNING id, address)
+SELECT id, address FROM inserted
+UNION ALL
+SELECT a.id, a.address FROM addresses a JOIN input i ON i.address = a.address;`, pg.Array(missing))
+	if err != nil {
+		return nil, err
+	}
+	r.addToCache(list)
+	for _, a := range list {
+		result[a.Address] = a.ID
+	}
+
+	// rows inserted by a concurrent transaction are invisible to the statement above
+	var conflicted []string
+	for _, a := range missing {
+		if _, ok := result[a]; !ok {
+			conflicted = append(conflicted, a)
+		}
+	}
+	if len(conflicted) == 0 {
+		return result, nil
+	}
+	found, err := r.FindIds(conflicted)
+	if err != nil {
+		return nil, err
+	}
+	for a, id := range found {
+		result[a] = id
+	}
+	return result, nil
+}
+
+// FindAddressesByIds Resolve addresses of ids in one query
+func (r *Repository) FindAddressesByIds(ids []uint) (map[uint]string, error) {
+	result := make(map[uint]string, len(ids))
+	seen := make(map[uint]struct{})
+	var missing []uint
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		if a, ok := r.invCache.Load(id); ok {
+			result[id] = a.(string)
+			continue
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	var list []*models.Address
+	err := r.db.Model(&list).Column("id", "address").Where("id in (?)", pg.In(missing)).Select()
+	if err != nil {
+		return nil, err
+	}
+	r.addToCache(list)
+	for _, a := range list {
+		result[a.ID] = a.Address
+	}
+	return result, nil
+}
+
+// loadIds Return cached ids and unique addresses which are not in the cache
+func (r *Repository) loadIds(addresses []string) (map[string]uint, []string) {
+	result := make(map[string]uint, len(addresses))
+	seen := make(map[string]struct{})
+	var missing []string
+	for _, a := range addresses {
+		if _, ok := seen[a]; ok {
+			continue
+		}
+		seen[a] = struct{}{}
+		if id, ok := r.cache.Load(a); ok {
+			result[a] = id.(uint)
+			continue
+		}
+		missing = append(missing, a)
+	}
+	return result, missing
+}
+
 func (r *Repository) FindById(id uint) (string, error) {
 	//First look in the cache
 	address, ok := r.invCache.Load(id)
@@ -124,11 +257,8 @@ func (r *Repository) SaveFromMapIfNotExists(addresses map[string]struct{}) error
 
 func (r *Repository) addToCache(addresses []*models.Address) {
 	for _, a := range addresses {
-		_, exist := r.cache.Load(a)
-		if !exist {
-			r.cache.Store(a.Address, a.ID)
-			r.invCache.Store(a.ID, a.Address)
-		}
+		r.cache.Store(a.Address, a.ID)
+		r.invCache.Store(a.ID, a.Address)
 	}
 }
 
@@ -1,16 +1,12 @@
 package address
 
 import (
-	"encoding/json"
 	"github.com/MinterTeam/minter-explorer-extender/v2/env"
-	"github.com/MinterTeam/minter-explorer-extender/v2/models"
 	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
-	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
 	"github.com/MinterTeam/node-grpc-gateway/api_pb"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/types/known/anypb"
 	"math"
-	"strings"
 	"sync"
 )
 
@@ -45,93 +41,20 @@ func (s *Service) SaveAddressesWorker(jobs <-chan []string) {
 	}
 }
 
+// ExtractAddressesFromTransactions Return senders and all participants of transactions
 func (s *Service) ExtractAddressesFromTransactions(transactions []*api_pb.TransactionResponse) ([]string, error, map[string]struct{}) {
 	var mapAddresses = make(map[string]struct{})
 	for _, tx := range transactions {
 		mapAddresses[helpers.RemovePrefix(tx.From)] = struct{}{}
-		switch transaction.Type(tx.Type) {
-		case transaction.TypeSend:
-			txData := new(api_pb.SendData)
-			if err := tx.Data.UnmarshalTo(txData); err != nil {
-				s.logger.Error(err)
-				continue
-			}
-			mapAddresses[helpers.RemovePrefix(txData.To)] = struct{}{}
-		case transaction.TypeMultisend:
-			txData := new(api_pb.MultiSendData)
-			if err := tx.Data.UnmarshalTo(txData); err != nil {
-				s.logger.Error(err)
-				continue
-			}
-			for _, receiver := range txData.List {
-				mapAddresses[helpers.RemovePrefix(receiver.To)] = struct{}{}
-			}
-		case transaction.TypeCreateMultisig:
-			txData := new(api_pb.CreateMultisigData)
-			if err := tx.Data.UnmarshalTo(txData); err != nil {
-				s.logger.Error(err)
-				continue
-			}
-			for _, adr := range txData.Addresses {
-				mapAddresses[helpers.RemovePrefix(adr)] = struct{}{}
-			}
-		case transaction.TypeEditMultisig:
-			txData := new(api_pb.EditMultisigData)
-			if err := tx.Data.UnmarshalTo(txData); err != nil {
-				s.logger.Error(err)
-				continue
-			}
-			for _, adr := range txData.Addresses {
-				mapAddresses[helpers.RemovePrefix(adr)] = struct{}{}
-			}
-		case transaction.TypeRedeemCheck:
-			txData := new(api_pb.RedeemCheckData)
-			if err := tx.Data.UnmarshalTo(txData); err != nil {
-				s.logger.Error(err)
-				continue
-			}
-
-			data, err := transaction.DecodeCheckBase64(txData.RawCheck)
-			if err != nil {
-				s.logger.WithFields(logrus.Fields{
-					"RawCheck": txData.RawCheck,
-					"Tx":       tx.Hash,
-				}).Error(err)
-				continue
-			}
-			sender, err := data.Sender()
-			if err != nil {
-				s.logger.Error(err)
-				continue
-			}
-			mapAddresses[helpers.RemovePrefix(sender)] = struct{}{}
-
-		case transaction.TypeBuySwapPool,
-			transaction.TypeSellSwapPool,
-			transaction.TypeSellAllSwapPool:
-			tags := tx.GetTags()
-			jsonString := strings.Replace(tags["tx.pools"], `\`, "", -1)
-			if jsonString == "" {
-				continue
-			}
-			var tagPools []models.BuySwapPoolTag
-			err := json.Unmarshal([]byte(jsonString), &tagPools)
-			if err != nil {
-				s.logger.WithFields(logrus.Fields{
-					"json":  jsonString,
-					"tx":    tx.Hash,
-					"block": tx.Height,
-				}).Error(err)
-				continue
-			}
-			for _, p := range tagPools {
-				for _, i := range p.Details.Orders {
-					mapAddresses[helpers.RemovePrefix(i.Seller)] = struct{}{}
-				}
-				for _, i := range p.Sellers {
-					mapAddresses[helpers.RemovePrefix(i.Seller)] = struct{}{}
-				}
-			}
+		participants, err := ExtractParticipants(tx.Type, tx.Data, tx.GetTags(), tx.RawTx)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"tx":    tx.Hash,
+				"block": tx.Height,
+			}).Error(err)
+		}
+		for _, p := range participants {
+			mapAddresses[p.Address] = struct{}{}
 		}
 	}
 	addresses := addressesMapToSlice(mapAddresses)
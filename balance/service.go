@@ -1,6 +1,7 @@
 package balance
 
 import (
+	"fmt"
 	"github.com/MinterTeam/minter-explorer-extender/v2/address"
 	"github.com/MinterTeam/minter-explorer-extender/v2/broadcast"
 	"github.com/MinterTeam/minter-explorer-extender/v2/coin"
@@ -17,33 +18,17 @@ import (
 
 func (s *Service) BalanceManager() {
 	for {
-		block := <-s.updateFromResponsesChannel
-
-		//chasingMode, ok := s.chasingMode.Load().(bool)
-		//if !ok{
-		//	s.logger.Error("chasing mode setup error")
-		//	return
-		//}
-		//if chasingMode {
-		//	return
-		//}
-
-		_, err, addressesMap := s.addressService.ExtractAddressesFromTransactions(block.Transactions)
-		if err != nil {
-			s.logger.WithFields(logrus.Fields{
-				"block": block.Height,
-			}).Error(err)
-		}
-		listEvent, _ := s.addressService.ExtractAddressesEventsResponse(block.Height, block.Events)
-		for _, i := range listEvent {
-			addressesMap[i] = struct{}{}
+		var addressesMap map[string]struct{}
+		select {
+		case block := <-s.updateFromResponsesChannel:
+			addressesMap = s.extractAddresses(block)
+		case list := <-s.updateAddressesChannel:
+			addressesMap = make(map[string]struct{}, len(list))
+			for _, adr := range list {
+				addressesMap[helpers.RemovePrefix(adr)] = struct{}{}
+			}
 		}
 
-		//TODO: move to env
-		addressesMap["ffffffffffffffffffffffffffffffffffffffff"] = struct{}{}
-		addressesMap["0000000000000000000000000000000000000000"] = struct{}{}
-		addressesMap["00cedde786b34d733d1dc96559253081572df2c6"] = struct{}{}
-
 		var addressesData []string
 		for k := range addressesMap {
 			addressesData = append(addressesData, k)
@@ -74,10 +59,44 @@ func (s *Service) BalanceManager() {
 	}
 }
 
+// extractAddresses Return addresses of transactions and events of the block which balances have to be updated
+func (s *Service) extractAddresses(block *api_pb.BlockResponse) map[string]struct{} {
+	//chasingMode, ok := s.chasingMode.Load().(bool)
+	//if !ok{
+	//	s.logger.Error("chasing mode setup error")
+	//	return
+	//}
+	//if chasingMode {
+	//	return
+	//}
+
+	_, err, addressesMap := s.addressService.ExtractAddressesFromTransactions(block.Transactions)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"block": block.Height,
+		}).Error(err)
+	}
+	listEvent, _ := s.addressService.ExtractAddressesEventsResponse(block.Height, block.Events)
+	for _, i := range listEvent {
+		addressesMap[i] = struct{}{}
+	}
+
+	//TODO: move to env
+	addressesMap["ffffffffffffffffffffffffffffffffffffffff"] = struct{}{}
+	addressesMap["0000000000000000000000000000000000000000"] = struct{}{}
+	addressesMap["00cedde786b34d733d1dc96559253081572df2c6"] = struct{}{}
+	return addressesMap
+}
+
 func (s *Service) UpdateChannel() chan *api_pb.BlockResponse {
 	return s.updateFromResponsesChannel
 }
 
+// UpdateAddressesChannel Addresses which balances have been changed without a transaction or an event of the block
+func (s *Service) UpdateAddressesChannel() chan []string {
+	return s.updateAddressesChannel
+}
+
 func (s *Service) SetChasingMode(val bool) {
 	s.chasingMode.Store(val)
 }
@@ -100,10 +119,15 @@ func (s *Service) updateAddresses(list []string) error {
 		return err
 	}
 
+	addressIds, err := s.addressService.Storage.FindIds(list)
+	if err != nil {
+		return err
+	}
+
 	for adr, item := range response.Addresses {
-		addressId, err := s.addressService.Storage.FindId(helpers.RemovePrefix(adr))
-		if err != nil {
-			return err
+		addressId, ok := addressIds[helpers.RemovePrefix(adr)]
+		if !ok {
+			return fmt.Errorf("address %s not found", adr)
 		}
 		for _, val := range item.Balance {
 			_, err := s.coinRepository.GetById(uint(val.Coin.Id))
@@ -127,11 +151,11 @@ func (s *Service) updateAddresses(list []string) error {
 	if len(balances) > 0 {
 		var ids []uint
 		for _, a := range list {
-			addressId, err := s.addressService.Storage.FindId(a)
-			if err != nil {
+			addressId, ok := addressIds[a]
+			if !ok {
 				s.logger.WithFields(logrus.Fields{
 					"address": a,
-				}).Error(err)
+				}).Error("address not found")
 			}
 			ids = append(ids, addressId)
 		}
@@ -172,6 +196,7 @@ func NewService(env *env.ExtenderEnvironment, repository *Repository, nodeApi *g
 		coinRepository:             coinRepository,
 		broadcastService:           broadcastService,
 		updateFromResponsesChannel: make(chan *api_pb.BlockResponse),
+		updateAddressesChannel:     make(chan []string, 100),
 		logger:                     logger,
 		chasingMode:                chasingMode,
 	}
@@ -188,4 +213,5 @@ type Service struct {
 	logger                     *logrus.Entry
 	chasingMode                atomic.Value
 	updateFromResponsesChannel chan *api_pb.BlockResponse
+	updateAddressesChannel     chan []string
 }
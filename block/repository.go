@@ -1,22 +1,33 @@
 package block
 
 import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/bulk"
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
 	"github.com/go-pg/pg/v10"
 	"github.com/go-pg/pg/v10/orm"
 )
 
 type Repository struct {
-	db *pg.DB
+	db         orm.DB
+	bulkWriter *bulk.Writer
 }
 
-func NewRepository(db *pg.DB) *Repository {
+func NewRepository(db *pg.DB, bulkWriter *bulk.Writer) *Repository {
 	orm.RegisterTable((*models.BlockValidator)(nil))
 	return &Repository{
-		db: db,
+		db:         db,
+		bulkWriter: bulkWriter,
 	}
 }
 
+// WithTx Return repository which runs queries in the transaction
+func (r *Repository) WithTx(tx *pg.Tx) *Repository {
+	repository := *r
+	repository.db = tx
+	return &repository
+}
+
 func (r *Repository) Save(block *models.Block) error {
 	_, err := r.db.Model(block).Insert()
 	if err != nil {
@@ -44,64 +55,67 @@ func (r *Repository) GetById(id uint64) (*models.Block, error) {
 }
 
 func (r *Repository) LinkWithValidators(links []*models.BlockValidator) error {
+	if r.bulkWriter.IsEnabled(bulk.TableBlockValidator) {
+		return r.bulkWriter.SaveBlockValidators(r.db, links)
+	}
 	_, err := r.db.Model(&links).Insert()
 	return err
 }
 
 func (r *Repository) DeleteLastBlockData() error {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return err
-	}
-	// Rollback tx on error.
-	defer tx.Rollback()
-	_, err = tx.Query(nil, `delete from orders where created_at_block >= (select id from blocks order by id desc limit 1);`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from transaction_outputs where transaction_id IN (select distinct id from transactions where block_id = (select id from blocks order by id desc limit 1));`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from transaction_validator where transaction_id IN (select distinct id from transactions where block_id = (select id from blocks order by id desc limit 1));`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from index_transaction_by_address where transaction_id in (select distinct id from transactions where block_id = (select id from blocks order by id desc limit 1));`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from invalid_transactions  where block_id = (select id from blocks order by id desc limit 1);`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from transactions where block_id = (select id from blocks order by id desc limit 1);`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from slashes where block_id = (select id from blocks order by id desc limit 1);`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from address_liquidity_pools where liquidity_pool_id in (select id from liquidity_pools where token_id in (select id from coins where created_at_block_id >= (select id from blocks order by id desc limit 1)));`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from liquidity_pools where token_id in (select id from coins where created_at_block_id >= (select id from blocks order by id desc limit 1));`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from coins where created_at_block_id >= (select id from blocks order by id desc limit 1);`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from block_validator where block_id = (select id from blocks order by id desc limit 1);`)
-	if err != nil {
-		return err
-	}
-	_, err = tx.Query(nil, `delete from blocks where id = (select id from blocks order by id desc limit 1);`)
-	if err != nil {
-		return err
-	}
-	return tx.Commit()
+	return database.RunInTransaction(r.db, func(tx *pg.Tx) error {
+		_, err := tx.Query(nil, `delete from orders where created_at_block >= (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from transaction_outputs where block_id = (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from transaction_validator where transaction_id IN (select distinct id from transactions where block_id = (select id from blocks order by id desc limit 1));`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from index_transaction_by_address where block_id = (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from invalid_transactions  where block_id = (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from transactions where block_id = (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from slashes where block_id = (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from address_liquidity_pools where liquidity_pool_id in (select id from liquidity_pools where token_id in (select id from coins where created_at_block_id >= (select id from blocks order by id desc limit 1)));`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from liquidity_pools where token_id in (select id from coins where created_at_block_id >= (select id from blocks order by id desc limit 1));`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from coins where created_at_block_id >= (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from events where block_id = (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from block_validator where block_id = (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Query(nil, `delete from blocks where id = (select id from blocks order by id desc limit 1);`)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/MinterTeam/minter-explorer-extender/v2/validator"
 	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
 	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/go-pg/pg/v10"
 	"time"
 )
 
@@ -24,6 +25,14 @@ func NewBlockService(blockRepository *Repository, validatorRepository *validator
 	}
 }
 
+// WithTx Return service which saves the block in the transaction.
+// The block cache of the returned service is not shared, set it on the parent after commit
+func (s *Service) WithTx(tx *pg.Tx) *Service {
+	service := *s
+	service.blockRepository = s.blockRepository.WithTx(tx)
+	return &service
+}
+
 func (s *Service) SetBlockCache(b *models.Block) {
 	s.blockCache = b
 }
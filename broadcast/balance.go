@@ -14,10 +14,13 @@ type BalanceResource struct {
 	BipAmount string             `json:"bip_amount"`
 }
 
+// Transform Balance with its value in base coin, passed as the first param
 func (BalanceResource) Transform(model resource.ItemInterface, params ...resource.ParamInterface) resource.Interface {
 	balance := model.(models.Balance)
-	//bipAmount := services.Swap.EstimateInBip(balance.Coin, helpers.StringToBigInt(balance.Value))
 	bipAmount := big.NewInt(0)
+	if len(params) > 0 {
+		bipAmount = params[0].(*big.Int)
+	}
 
 	return BalanceResource{
 		Coin:      new(coins.IdResource).Transform(*balance.Coin, coins.Params{IsTypeRequired: true}),
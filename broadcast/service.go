@@ -10,6 +10,7 @@ import (
 	"github.com/MinterTeam/minter-explorer-extender/v2/coin"
 	"github.com/MinterTeam/minter-explorer-extender/v2/env"
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-extender/v2/price"
 	"github.com/MinterTeam/minter-go-sdk/v2/api/grpc_client"
 	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
 	"github.com/MinterTeam/node-grpc-gateway/api_pb"
@@ -41,6 +42,7 @@ type Service struct {
 	nodeClient          *grpc_client.Client
 	addressRepository   *address.Repository
 	coinRepository      *coin.Repository
+	priceService        *price.Service
 	logger              *logrus.Entry
 	stakeChannel        chan *api_pb.TransactionResponse
 	chasingMode         atomic.Value
@@ -48,10 +50,14 @@ type Service struct {
 	transactionsChannel chan []*models.Transaction
 	balanceChannel      chan []*models.Balance
 	commissionsChannel  chan *api_pb.UpdateCommissionsEvent
+	poolCandlesChannel  chan []models.PoolCandle
+	coinCandlesChannel  chan []models.CoinCandle
+	validatorAlerts     chan models.ValidatorAlert
+	waitListChannel     chan []models.WaitListUpdate
 }
 
 func NewService(env *env.ExtenderEnvironment, addressRepository *address.Repository, coinRepository *coin.Repository,
-	nodeClient *grpc_client.Client, logger *logrus.Entry) *Service {
+	priceService *price.Service, nodeClient *grpc_client.Client, logger *logrus.Entry) *Service {
 
 	//wsClient := gocent.New(gocent.Config{
 	//	Addr: env.WsLink,
@@ -73,11 +79,16 @@ func NewService(env *env.ExtenderEnvironment, addressRepository *address.Reposit
 		nodeClient:          nodeClient,
 		addressRepository:   addressRepository,
 		coinRepository:      coinRepository,
+		priceService:        priceService,
 		commissionsChannel:  make(chan *api_pb.UpdateCommissionsEvent),
 		stakeChannel:        make(chan *api_pb.TransactionResponse),
 		balanceChannel:      make(chan []*models.Balance),
 		transactionsChannel: make(chan []*models.Transaction),
 		blockChannel:        make(chan models.Block),
+		poolCandlesChannel:  make(chan []models.PoolCandle),
+		coinCandlesChannel:  make(chan []models.CoinCandle),
+		validatorAlerts:     make(chan models.ValidatorAlert),
+		waitListChannel:     make(chan []models.WaitListUpdate),
 		logger:              logger,
 		chasingMode:         chasingMode,
 	}
@@ -97,6 +108,14 @@ func (s *Service) Manager() {
 			go s.PublishStake(tx)
 		case c := <-s.commissionsChannel:
 			s.PublishCommissions(c)
+		case c := <-s.poolCandlesChannel:
+			go s.PublishPoolCandles(c)
+		case c := <-s.coinCandlesChannel:
+			go s.PublishCoinCandles(c)
+		case a := <-s.validatorAlerts:
+			go s.PublishValidatorAlert(a)
+		case w := <-s.waitListChannel:
+			go s.PublishWaitList(w)
 		}
 	}
 }
@@ -121,6 +140,22 @@ func (s *Service) BlockChannel() chan models.Block {
 	return s.blockChannel
 }
 
+func (s *Service) PoolCandlesChannel() chan []models.PoolCandle {
+	return s.poolCandlesChannel
+}
+
+func (s *Service) CoinCandlesChannel() chan []models.CoinCandle {
+	return s.coinCandlesChannel
+}
+
+func (s *Service) ValidatorAlertsChannel() chan models.ValidatorAlert {
+	return s.validatorAlerts
+}
+
+func (s *Service) WaitListChannel() chan []models.WaitListUpdate {
+	return s.waitListChannel
+}
+
 func (s *Service) SetChasingMode(val bool) {
 	s.chasingMode.Store(val)
 }
@@ -138,12 +173,20 @@ func (s *Service) PublishTransactions(transactions []*models.Transaction) {
 	channel := `transactions`
 	channelCut := `transactions_100`
 	count := 0
+	ids := make([]uint, len(transactions))
+	for i, tx := range transactions {
+		ids[i] = uint(tx.FromAddressID)
+	}
+	addresses, err := s.addressRepository.FindAddressesByIds(ids)
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
 	for _, tx := range transactions {
 		mTransaction := *tx
-		adr, err := s.addressRepository.FindById(uint(tx.FromAddressID))
-
-		if err != nil {
-			s.logger.Error(err)
+		adr, ok := addresses[uint(tx.FromAddressID)]
+		if !ok {
+			s.logger.WithField("address_id", tx.FromAddressID).Error("address not found")
 			continue
 		}
 
@@ -183,32 +226,37 @@ func (s *Service) PublishBalances(balances []*models.Balance) {
 
 	var mapBalances = make(map[uint][]interface{})
 
+	ids := make([]uint, len(balances))
+	for i, item := range balances {
+		ids[i] = item.AddressID
+	}
+	addresses, err := s.addressRepository.FindAddressesByIds(ids)
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+
 	for _, item := range balances {
 		c, err := s.coinRepository.GetById(item.CoinID)
 		if err != nil {
 			s.logger.Error(err)
 			continue
 		}
-		adr, err := s.addressRepository.FindById(item.AddressID)
-		if err != nil {
-			s.logger.Error(err)
+		adr, ok := addresses[item.AddressID]
+		if !ok {
+			s.logger.WithField("address_id", item.AddressID).Error("address not found")
 			continue
 		}
 
 		mBalance := *item
 		mBalance.Address = &models.Address{Address: adr}
 		mBalance.Coin = c
-		res := new(BalanceResource).Transform(mBalance)
+		res := new(BalanceResource).Transform(mBalance, s.priceService.EstimateInBip(uint64(item.CoinID), item.Value))
 		mapBalances[item.AddressID] = append(mapBalances[item.AddressID], res)
 	}
 
 	for addressId, items := range mapBalances {
-		adr, err := s.addressRepository.FindById(addressId)
-		if err != nil {
-			s.logger.Error(err)
-			continue
-		}
-		channel := "Mx" + adr
+		channel := "Mx" + addresses[addressId]
 		msg, err := json.Marshal(items)
 		if err != nil {
 			log.Printf(`Error parse json: %s`, err)
@@ -247,6 +295,86 @@ func (s *Service) PublishCommissions(data *api_pb.UpdateCommissionsEvent) {
 	s.publish(channel, msg)
 }
 
+func (s *Service) PublishPoolCandles(candles []models.PoolCandle) {
+	chasingMode, ok := s.chasingMode.Load().(bool)
+	if !ok {
+		s.logger.Error("chasing mode setup error")
+		return
+	}
+	if chasingMode {
+		return
+	}
+	for _, c := range candles {
+		msg, err := json.Marshal(c)
+		if err != nil {
+			s.logger.Error(err)
+			continue
+		}
+		s.publish(fmt.Sprintf("candles/pool/%d/%s", c.LiquidityPoolId, c.Period), msg)
+	}
+}
+
+func (s *Service) PublishCoinCandles(candles []models.CoinCandle) {
+	chasingMode, ok := s.chasingMode.Load().(bool)
+	if !ok {
+		s.logger.Error("chasing mode setup error")
+		return
+	}
+	if chasingMode {
+		return
+	}
+	for _, c := range candles {
+		msg, err := json.Marshal(c)
+		if err != nil {
+			s.logger.Error(err)
+			continue
+		}
+		s.publish(fmt.Sprintf("candles/coin/%d/%s", c.CoinId, c.Period), msg)
+	}
+}
+
+func (s *Service) PublishValidatorAlert(alert models.ValidatorAlert) {
+	chasingMode, ok := s.chasingMode.Load().(bool)
+	if !ok {
+		s.logger.Error("chasing mode setup error")
+		return
+	}
+	if chasingMode {
+		return
+	}
+	msg, err := json.Marshal(alert)
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+	s.publish("validators/alerts", msg)
+	s.publish(fmt.Sprintf("validators/alerts/%s", alert.PublicKey), msg)
+}
+
+// PublishWaitList Publish wait list changes to the channel of each address
+func (s *Service) PublishWaitList(updates []models.WaitListUpdate) {
+	chasingMode, ok := s.chasingMode.Load().(bool)
+	if !ok {
+		s.logger.Error("chasing mode setup error")
+		return
+	}
+	if chasingMode {
+		return
+	}
+	byAddress := make(map[string][]models.WaitListUpdate)
+	for _, u := range updates {
+		byAddress[u.Address] = append(byAddress[u.Address], u)
+	}
+	for adr, list := range byAddress {
+		msg, err := json.Marshal(list)
+		if err != nil {
+			s.logger.Error(err)
+			continue
+		}
+		s.publish(fmt.Sprintf("waitlist/%s", adr), msg)
+	}
+}
+
 func (s *Service) PublishStake(tx *api_pb.TransactionResponse) {
 	chasingMode, ok := s.chasingMode.Load().(bool)
 	if !ok {
@@ -0,0 +1,279 @@
+package bulk
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
+	"github.com/MinterTeam/minter-explorer-extender/v2/env"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+	"github.com/sirupsen/logrus"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEncodeValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"nil", nil, `\N`},
+		{"empty string", "", `\N`},
+		{"string", "BIP", "BIP"},
+		{"string with special chars", "a\tb\nc\\d\re", `a\tb\nc\\d\re`},
+		{"empty json", json.RawMessage{}, `\N`},
+		{"json", json.RawMessage(`{"to":"Mx0","value":"1"}`), `{"to":"Mx0","value":"1"}`},
+		{"json with newline", json.RawMessage("{\"payload\":\"a\\nb\"}\n"), `{"payload":"a\\nb"}\n`},
+		{"empty bytes", []byte{}, `\N`},
+		{"bytes", []byte{0xf8, 0x01}, `\\xf801`},
+		{"tags", map[string]string{"tx.type": "01"}, `{"tx.type":"01"}`},
+		{"time", time.Date(2021, 5, 1, 12, 0, 0, 500, time.UTC), "2021-05-01T12:00:00.0000005Z"},
+		{"true", true, "t"},
+		{"false", false, "f"},
+		{"uint", uint(7), "7"},
+		{"uint8", uint8(255), "255"},
+		{"uint64", uint64(18446744073709551615), "18446744073709551615"},
+		{"int", -1, "-1"},
+		{"int64", int64(-9000000000), "-9000000000"},
+		{"other", 1.5, "1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodeValue(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("encodeValue(%#v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		tables  []string
+		enabled []string
+		wantErr bool
+	}{
+		{"none", nil, nil, false},
+		{"all", Tables, Tables, false},
+		{"spaces", []string{" transactions", "events "}, []string{TableTransactions, TableEvents}, false},
+		{"unknown", []string{TableTransactions, "balances"}, nil, true},
+		{"empty name", []string{""}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewWriter(tt.tables)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewWriter(%q) error = %v, wantErr %v", tt.tables, err, tt.wantErr)
+			}
+			for _, table := range tt.enabled {
+				if !w.IsEnabled(table) {
+					t.Errorf("table %s is not enabled", table)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkWrites Compare INSERT and COPY of every supported table, each op writes 1000 rows.
+// Needs a migrated database set by DB_* variables, each op is rolled back
+func BenchmarkWrites(b *testing.B) {
+	if os.Getenv("DB_NAME") == "" {
+		b.Skip("DB_NAME is not set")
+	}
+	envData := env.New()
+	db := database.Connect(envData)
+	defer db.Close()
+
+	const rows = 1000
+
+	var blockId uint64
+	_, err := db.QueryOne(pg.Scan(&blockId), `SELECT coalesce(max(id), 0) + 1 FROM blocks;`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	partitions := database.NewPartitionManager(db, envData.PartitionSizeBlocks, 1, logrus.NewEntry(logrus.New()))
+	err = partitions.EnsurePartitions(blockId)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	runs := []struct {
+		table  string
+		method string
+		write  func(tx *pg.Tx, f *fixture) error
+	}{
+		{TableTransactions, "insert", func(tx *pg.Tx, f *fixture) error {
+			list := f.newTransactions(rows)
+			_, err := tx.Model(&list).Insert()
+			return err
+		}},
+		{TableTransactions, "copy", func(tx *pg.Tx, f *fixture) error {
+			return saveTransactions(tx, f.newTransactions(rows))
+		}},
+		{TableTransactionOutputs, "insert", func(tx *pg.Tx, f *fixture) error {
+			list := f.newOutputs()
+			_, err := tx.Model(&list).Insert()
+			return err
+		}},
+		{TableTransactionOutputs, "copy", func(tx *pg.Tx, f *fixture) error {
+			return saveTransactionOutputs(tx, f.newOutputs())
+		}},
+		{TableTransactionValidator, "insert", func(tx *pg.Tx, f *fixture) error {
+			list := f.newTransactionValidators()
+			_, err := tx.Model(&list).Insert()
+			return err
+		}},
+		{TableTransactionValidator, "copy", func(tx *pg.Tx, f *fixture) error {
+			return saveTransactionValidators(tx, f.newTransactionValidators())
+		}},
+		{TableBlockValidator, "insert", func(tx *pg.Tx, f *fixture) error {
+			list := f.newBlockValidators(rows)
+			_, err := tx.Model(&list).Insert()
+			return err
+		}},
+		{TableBlockValidator, "copy", func(tx *pg.Tx, f *fixture) error {
+			return saveBlockValidators(tx, f.newBlockValidators(rows))
+		}},
+		{TableEvents, "insert", func(tx *pg.Tx, f *fixture) error {
+			list := f.newEvents(rows)
+			_, err := tx.Model(&list).Insert()
+			return err
+		}},
+		{TableEvents, "copy", func(tx *pg.Tx, f *fixture) error {
+			return saveEvents(tx, f.newEvents(rows))
+		}},
+	}
+
+	for _, r := range runs {
+		withTransactions := r.table == TableTransactionOutputs || r.table == TableTransactionValidator
+		b.Run(r.table+"/"+r.method, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				tx, err := db.Begin()
+				if err != nil {
+					b.Fatal(err)
+				}
+				f, err := newFixture(tx, blockId, rows, withTransactions)
+				if err != nil {
+					_ = tx.Rollback()
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				err = r.write(tx, f)
+				b.StopTimer()
+				_ = tx.Rollback()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// fixture Rows the benchmarked tables refer to, created in the benchmark transaction
+type fixture struct {
+	blockId        uint64
+	addressId      uint64
+	validatorId    uint64
+	transactionIds []uint64
+}
+
+func newFixture(tx *pg.Tx, blockId uint64, rows int, withTransactions bool) (*fixture, error) {
+	f := &fixture{blockId: blockId}
+	_, err := tx.QueryOne(pg.Scan(&f.addressId), `
+		INSERT INTO addresses (address) VALUES (repeat('0', 40))
+		ON CONFLICT (address) DO UPDATE SET address = excluded.address
+		RETURNING id;`)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.QueryOne(pg.Scan(&f.validatorId), `
+		INSERT INTO validators (public_key) VALUES (repeat('0', 64))
+		ON CONFLICT (public_key) DO UPDATE SET public_key = excluded.public_key
+		RETURNING id;`)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`INSERT INTO coins (id, symbol, crr) VALUES (0, 'BIP', 0) ON CONFLICT (id) DO NOTHING;`)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO blocks (id, size, proposer_validator_id, block_time, created_at, block_reward, hash)
+		SELECT id, 0, ?, 0, now(), 0, lpad(to_hex(id), 64, '0') FROM generate_series(?::bigint, ?::bigint) id;`,
+		f.validatorId, blockId, blockId+uint64(rows)-1)
+	if err != nil {
+		return nil, err
+	}
+	if withTransactions {
+		list := f.newTransactions(rows)
+		_, err = tx.Model(&list).Insert()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range list {
+			f.transactionIds = append(f.transactionIds, t.ID)
+		}
+	}
+	return f, nil
+}
+
+func (f *fixture) newTransactions(rows int) []*models.Transaction {
+	list := make([]*models.Transaction, rows)
+	for i := range list {
+		list[i] = &models.Transaction{
+			FromAddressID: f.addressId,
+			Nonce:         uint64(i + 1),
+			GasPrice:      1,
+			Gas:           10,
+			Commission:    "10000000000000000",
+			BlockID:       f.blockId,
+			GasCoinID:     0,
+			CreatedAt:     time.Now(),
+			Type:          1,
+			Hash:          fmt.Sprintf("%064x", i),
+			Data:          json.RawMessage(`{"coin":{"id":0,"symbol":"BIP"},"to":"Mx0","value":"1"}`),
+			Tags:          map[string]string{"tx.type": "01"},
+			RawTx:         []byte{0xf8, 0x01},
+		}
+	}
+	return list
+}
+
+func (f *fixture) newOutputs() []*models.TransactionOutput {
+	list := make([]*models.TransactionOutput, len(f.transactionIds))
+	for i, id := range f.transactionIds {
+		list[i] = &models.TransactionOutput{TransactionID: id, BlockID: f.blockId, ToAddressID: f.addressId, CoinID: 0, Value: "1000000000000000000"}
+	}
+	return list
+}
+
+func (f *fixture) newTransactionValidators() []*models.TransactionValidator {
+	list := make([]*models.TransactionValidator, len(f.transactionIds))
+	for i, id := range f.transactionIds {
+		list[i] = &models.TransactionValidator{TransactionID: id, ValidatorID: f.validatorId}
+	}
+	return list
+}
+
+func (f *fixture) newBlockValidators(rows int) []*models.BlockValidator {
+	list := make([]*models.BlockValidator, rows)
+	for i := range list {
+		list[i] = &models.BlockValidator{BlockID: f.blockId + uint64(i), ValidatorID: f.validatorId, Signed: i%2 == 0}
+	}
+	return list
+}
+
+func (f *fixture) newEvents(rows int) []models.Event {
+	list := make([]models.Event, rows)
+	for i := range list {
+		list[i] = models.Event{BlockId: f.blockId, Type: "minter/RewardEvent", Data: json.RawMessage(`{"role":"Validator","amount":"1"}`)}
+	}
+	return list
+}
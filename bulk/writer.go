@@ -0,0 +1,248 @@
+package bulk
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TableTransactions         = "transactions"
+	TableTransactionOutputs   = "transaction_outputs"
+	TableTransactionValidator = "transaction_validator"
+	TableBlockValidator       = "block_validator"
+	TableEvents               = "events"
+)
+
+// Tables Tables which can be written with COPY
+var Tables = []string{
+	TableTransactions,
+	TableTransactionOutputs,
+	TableTransactionValidator,
+	TableBlockValidator,
+	TableEvents,
+}
+
+var transactionColumns = []string{
+	"from_address_id", "nonce", "gas_price", "gas", "commission", "block_id", "gas_coin_id", "created_at",
+	"type", "hash", "service_data", "data", "tags", "payload", "raw_tx",
+}
+
+// Writer Write rows of high-volume tables with COPY instead of multi-row INSERT.
+// Rows are written to the connection passed by the repository, so it works inside a transaction as well
+type Writer struct {
+	tables map[string]struct{}
+}
+
+// NewWriter Return an error if a table can't be written with COPY
+func NewWriter(tables []string) (*Writer, error) {
+	supported := make(map[string]struct{}, len(Tables))
+	for _, t := range Tables {
+		supported[t] = struct{}{}
+	}
+	w := &Writer{
+		tables: make(map[string]struct{}),
+	}
+	for _, t := range tables {
+		t = strings.TrimSpace(t)
+		if _, ok := supported[t]; !ok {
+			return nil, fmt.Errorf("bulk copy is not supported for table %q, expected one of %s", t, strings.Join(Tables, ", "))
+		}
+		w.tables[t] = struct{}{}
+	}
+	return w, nil
+}
+
+// IsEnabled Check if COPY is selected for the table
+func (w *Writer) IsEnabled(table string) bool {
+	if w == nil {
+		return false
+	}
+	_, ok := w.tables[table]
+	return ok
+}
+
+// SaveTransactions Copy transactions through a staging table and set ids assigned by the database
+func (w *Writer) SaveTransactions(db orm.DB, list []*models.Transaction) error {
+	return database.RunInTransaction(db, func(tx *pg.Tx) error {
+		return saveTransactions(tx, list)
+	})
+}
+
+func (w *Writer) SaveTransactionOutputs(db orm.DB, list []*models.TransactionOutput) error {
+	return saveTransactionOutputs(db, list)
+}
+
+func (w *Writer) SaveTransactionValidators(db orm.DB, list []*models.TransactionValidator) error {
+	return saveTransactionValidators(db, list)
+}
+
+func (w *Writer) SaveBlockValidators(db orm.DB, list []*models.BlockValidator) error {
+	return saveBlockValidators(db, list)
+}
+
+func (w *Writer) SaveEvents(db orm.DB, list []models.Event) error {
+	return saveEvents(db, list)
+}
+
+// saveTransactions COPY doesn't return generated ids, so rows are copied into a temporary table first
+// and moved with INSERT ... RETURNING. Must be called inside a transaction
+func saveTransactions(tx *pg.Tx, list []*models.Transaction) error {
+	if len(list) == 0 {
+		return nil
+	}
+	columns := strings.Join(transactionColumns, ", ")
+
+	_, err := tx.Exec(fmt.Sprintf(`CREATE TEMP TABLE transactions_staging ON COMMIT DROP AS SELECT %s FROM transactions WITH NO DATA;`, columns))
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]interface{}, len(list))
+	for i, t := range list {
+		rows[i] = []interface{}{
+			t.FromAddressID, t.Nonce, t.GasPrice, t.Gas, t.Commission, t.BlockID, t.GasCoinID, t.CreatedAt,
+			t.Type, t.Hash, t.ServiceData, t.Data, t.Tags, t.Payload, t.RawTx,
+		}
+	}
+	err = copyRows(tx, "transactions_staging", transactionColumns, rows)
+	if err != nil {
+		return err
+	}
+
+	var ids []struct {
+		Id   uint64
+		Hash string
+	}
+	_, err = tx.Query(&ids, fmt.Sprintf(`INSERT INTO transactions (%s) SELECT %s FROM transactions_staging RETURNING id, hash;`, columns, columns))
+	if err != nil {
+		return err
+	}
+	idByHash := make(map[string]uint64, len(ids))
+	for _, row := range ids {
+		idByHash[row.Hash] = row.Id
+	}
+	for _, t := range list {
+		t.ID = idByHash[t.Hash]
+	}
+
+	_, err = tx.Exec(`DROP TABLE transactions_staging;`)
+	return err
+}
+
+func saveTransactionOutputs(db orm.DB, list []*models.TransactionOutput) error {
+	rows := make([][]interface{}, len(list))
+	for i, o := range list {
+		rows[i] = []interface{}{o.TransactionID, o.BlockID, o.ToAddressID, o.CoinID, o.Value}
+	}
+	return copyRows(db, TableTransactionOutputs, []string{"transaction_id", "block_id", "to_address_id", "coin_id", "value"}, rows)
+}
+
+func saveTransactionValidators(db orm.DB, list []*models.TransactionValidator) error {
+	rows := make([][]interface{}, len(list))
+	for i, l := range list {
+		rows[i] = []interface{}{l.TransactionID, l.ValidatorID}
+	}
+	return copyRows(db, TableTransactionValidator, []string{"transaction_id", "validator_id"}, rows)
+}
+
+func saveBlockValidators(db orm.DB, list []*models.BlockValidator) error {
+	rows := make([][]interface{}, len(list))
+	for i, l := range list {
+		rows[i] = []interface{}{l.BlockID, l.ValidatorID, l.Signed}
+	}
+	return copyRows(db, TableBlockValidator, []string{"block_id", "validator_id", "signed"}, rows)
+}
+
+func saveEvents(db orm.DB, list []models.Event) error {
+	rows := make([][]interface{}, len(list))
+	for i, e := range list {
+		rows[i] = []interface{}{e.BlockId, e.Type, e.Data}
+	}
+	return copyRows(db, TableEvents, []string{"block_id", "type", "data"}, rows)
+}
+
+// copyRows Send rows with COPY FROM STDIN in text format
+func copyRows(db orm.DB, table string, columns []string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	for _, row := range rows {
+		for i, v := range row {
+			if i > 0 {
+				buf.WriteByte('\t')
+			}
+			value, err := encodeValue(v)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(value)
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := db.CopyFrom(buf, fmt.Sprintf(`COPY %s (%s) FROM STDIN`, table, strings.Join(columns, ", ")))
+	return err
+}
+
+// encodeValue Encode value for COPY text format.
+// Empty strings and slices are written as NULL the same way go-pg does for zero values on insert
+func encodeValue(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return `\N`, nil
+	case string:
+		if value == "" {
+			return `\N`, nil
+		}
+		return escape(value), nil
+	case json.RawMessage:
+		if len(value) == 0 {
+			return `\N`, nil
+		}
+		return escape(string(value)), nil
+	case []byte:
+		if len(value) == 0 {
+			return `\N`, nil
+		}
+		return `\\x` + hex.EncodeToString(value), nil
+	case map[string]string:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return escape(string(data)), nil
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+	case bool:
+		if value {
+			return "t", nil
+		}
+		return "f", nil
+	case uint:
+		return strconv.FormatUint(uint64(value), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(value), 10), nil
+	case uint64:
+		return strconv.FormatUint(value, 10), nil
+	case int:
+		return strconv.Itoa(value), nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	}
+	return escape(fmt.Sprint(v)), nil
+}
+
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+func escape(value string) string {
+	return copyEscaper.Replace(value)
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"container/list"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"sync"
+	"time"
+)
+
+var (
+	hits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "extender_cache_hits_total",
+		Help: "Number of cache lookups which found a value",
+	}, []string{"cache"})
+	misses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "extender_cache_misses_total",
+		Help: "Number of cache lookups which didn't find a value or found an expired one",
+	}, []string{"cache"})
+	evictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "extender_cache_evictions_total",
+		Help: "Number of values evicted because the cache is full",
+	}, []string{"cache"})
+)
+
+// Config Size is the max number of values, zero means unlimited. Values older than TTL are dropped, zero TTL means they never expire
+type Config struct {
+	Size int
+	TTL  time.Duration
+}
+
+type entry struct {
+	key       interface{}
+	value     interface{}
+	expiresAt time.Time
+}
+
+// Cache Bounded LRU cache safe for concurrent use, has the same Load/Store/Delete methods as sync.Map
+type Cache struct {
+	config    Config
+	mx        sync.Mutex
+	items     map[interface{}]*list.Element
+	order     *list.List
+	hits      prometheus.Counter
+	misses    prometheus.Counter
+	evictions prometheus.Counter
+}
+
+func New(name string, config Config) *Cache {
+	return &Cache{
+		config:    config,
+		items:     make(map[interface{}]*list.Element),
+		order:     list.New(),
+		hits:      hits.WithLabelValues(name),
+		misses:    misses.WithLabelValues(name),
+		evictions: evictions.WithLabelValues(name),
+	}
+}
+
+func (c *Cache) Load(key interface{}) (interface{}, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses.Inc()
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if c.config.TTL > 0 && time.Now().After(e.expiresAt) {
+		c.remove(el)
+		c.misses.Inc()
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	c.hits.Inc()
+	return e.value, true
+}
+
+func (c *Cache) Store(key, value interface{}) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	expiresAt := time.Now().Add(c.config.TTL)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.config.Size > 0 && c.order.Len() > c.config.Size {
+		c.remove(c.order.Back())
+		c.evictions.Inc()
+	}
+}
+
+func (c *Cache) Delete(key interface{}) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// DeleteFunc Invalidate all values for which fn returns true
+func (c *Cache) DeleteFunc(fn func(key, value interface{}) bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		e := el.Value.(*entry)
+		if fn(e.key, e.value) {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+func (c *Cache) Len() int {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEviction(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		store   []int
+		load    []int
+		then    []int
+		present []int
+		absent  []int
+	}{
+		{
+			name:    "unlimited",
+			size:    0,
+			store:   []int{1, 2, 3, 4},
+			present: []int{1, 2, 3, 4},
+		},
+		{
+			name:    "oldest evicted",
+			size:    2,
+			store:   []int{1, 2, 3},
+			present: []int{2, 3},
+			absent:  []int{1},
+		},
+		{
+			name:    "loaded value is kept",
+			size:    2,
+			store:   []int{1, 2},
+			load:    []int{1},
+			then:    []int{3},
+			present: []int{1, 3},
+			absent:  []int{2},
+		},
+		{
+			name:    "stored again value is kept",
+			size:    2,
+			store:   []int{1, 2, 1},
+			then:    []int{3},
+			present: []int{1, 3},
+			absent:  []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New("test", Config{Size: tt.size})
+			for _, key := range tt.store {
+				c.Store(key, key*10)
+			}
+			for _, key := range tt.load {
+				c.Load(key)
+			}
+			for _, key := range tt.then {
+				c.Store(key, key*10)
+			}
+			for _, key := range tt.present {
+				value, ok := c.Load(key)
+				if !ok || value.(int) != key*10 {
+					t.Errorf("Load(%d) = %v, %v, want %d, true", key, value, ok, key*10)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := c.Load(key); ok {
+					t.Errorf("Load(%d) found an evicted value", key)
+				}
+			}
+			if tt.size > 0 && c.Len() > tt.size {
+				t.Errorf("Len() = %d, want at most %d", c.Len(), tt.size)
+			}
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	tests := []struct {
+		name  string
+		ttl   time.Duration
+		wait  time.Duration
+		found bool
+	}{
+		{"no ttl", 0, 20 * time.Millisecond, true},
+		{"not expired", time.Minute, 20 * time.Millisecond, true},
+		{"expired", 10 * time.Millisecond, 20 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New("test", Config{TTL: tt.ttl})
+			c.Store("key", "value")
+			time.Sleep(tt.wait)
+			_, ok := c.Load("key")
+			if ok != tt.found {
+				t.Errorf("Load() found = %v, want %v", ok, tt.found)
+			}
+			if !tt.found && c.Len() != 0 {
+				t.Errorf("expired value is kept, Len() = %d", c.Len())
+			}
+		})
+	}
+}
+
+func TestCacheDeleteFunc(t *testing.T) {
+	c := New("test", Config{})
+	for i := 0; i < 6; i++ {
+		c.Store(i, i)
+	}
+	c.DeleteFunc(func(key, value interface{}) bool {
+		return value.(int)%2 == 0
+	})
+	for i := 0; i < 6; i++ {
+		_, ok := c.Load(i)
+		if ok != (i%2 == 1) {
+			t.Errorf("Load(%d) found = %v", i, ok)
+		}
+	}
+}
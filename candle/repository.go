@@ -0,0 +1,69 @@
+package candle
+
+import (
+	"context"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+)
+
+type Repository struct {
+	db *pg.DB
+}
+
+func NewRepository(db *pg.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// SaveCandles Merge block candles into stored ones.
+// Candles already updated by the same or a later block are left untouched, so a block can be safely handled twice
+func (r *Repository) SaveCandles(pools []*models.PoolCandle, coins []*models.CoinCandle) error {
+	return r.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		if len(pools) > 0 {
+			_, err := tx.Model(&pools).
+				OnConflict("(liquidity_pool_id, period, start_at) DO UPDATE").
+				Set("high = greatest(pool_candles.high, EXCLUDED.high)").
+				Set("low = least(pool_candles.low, EXCLUDED.low)").
+				Set("close = EXCLUDED.close").
+				Set("first_volume = pool_candles.first_volume + EXCLUDED.first_volume").
+				Set("second_volume = pool_candles.second_volume + EXCLUDED.second_volume").
+				Set("trades_count = pool_candles.trades_count + EXCLUDED.trades_count").
+				Set("last_block_id = EXCLUDED.last_block_id").
+				Where("pool_candles.last_block_id < EXCLUDED.last_block_id").
+				Insert()
+			if err != nil {
+				return err
+			}
+		}
+		if len(coins) > 0 {
+			_, err := tx.Model(&coins).
+				OnConflict("(coin_id, period, start_at) DO UPDATE").
+				Set("high = greatest(coin_candles.high, EXCLUDED.high)").
+				Set("low = least(coin_candles.low, EXCLUDED.low)").
+				Set("close = EXCLUDED.close").
+				Set("volume = coin_candles.volume + EXCLUDED.volume").
+				Set("volume_bip = coin_candles.volume_bip + EXCLUDED.volume_bip").
+				Set("trades_count = coin_candles.trades_count + EXCLUDED.trades_count").
+				Set("last_block_id = EXCLUDED.last_block_id").
+				Where("coin_candles.last_block_id < EXCLUDED.last_block_id").
+				Insert()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Repository) GetPoolCandles(keys []interface{}) ([]models.PoolCandle, error) {
+	var list []models.PoolCandle
+	err := r.db.Model(&list).Where("(liquidity_pool_id, period, start_at) IN (?)", pg.InMulti(keys...)).Select()
+	return list, err
+}
+
+func (r *Repository) GetCoinCandles(keys []interface{}) ([]models.CoinCandle, error) {
+	var list []models.CoinCandle
+	err := r.db.Model(&list).Where("(coin_id, period, start_at) IN (?)", pg.InMulti(keys...)).Select()
+	return list, err
+}
@@ -0,0 +1,266 @@
+package candle
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/MinterTeam/minter-explorer-extender/v2/broadcast"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/sirupsen/logrus"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const precision = 256
+
+type Service struct {
+	repository       *Repository
+	broadcastService *broadcast.Service
+	logger           *logrus.Entry
+}
+
+func NewService(repository *Repository, broadcastService *broadcast.Service, logger *logrus.Entry) *Service {
+	return &Service{
+		repository:       repository,
+		broadcastService: broadcastService,
+		logger:           logger,
+	}
+}
+
+type candleKey struct {
+	id     uint64
+	period string
+}
+
+type ohlcv struct {
+	open         *big.Float
+	high         *big.Float
+	low          *big.Float
+	close        *big.Float
+	firstVolume  *big.Int
+	secondVolume *big.Int
+	trades       uint64
+}
+
+func (c *ohlcv) add(price *big.Float, firstVolume, secondVolume *big.Int) {
+	if c.open == nil {
+		c.open, c.high, c.low = price, price, price
+		c.firstVolume, c.secondVolume = big.NewInt(0), big.NewInt(0)
+	}
+	if price.Cmp(c.high) > 0 {
+		c.high = price
+	}
+	if price.Cmp(c.low) < 0 {
+		c.low = price
+	}
+	c.close = price
+	c.firstVolume.Add(c.firstVolume, firstVolume)
+	c.secondVolume.Add(c.secondVolume, secondVolume)
+	c.trades++
+}
+
+// CandleWorker Build candles from swaps and bonding curve trades of each block
+func (s *Service) CandleWorker(data <-chan *api_pb.BlockResponse) {
+	for b := range data {
+		err := s.HandleBlock(b)
+		if err != nil {
+			s.logger.WithField("block", b.Height).Error(err)
+		}
+	}
+}
+
+func (s *Service) HandleBlock(b *api_pb.BlockResponse) error {
+	blockTime, err := time.Parse("2006-01-02T15:04:05Z", b.Time)
+	if err != nil {
+		return err
+	}
+
+	pools := make(map[candleKey]*ohlcv)
+	coins := make(map[candleKey]*ohlcv)
+
+	for _, tx := range b.Transactions {
+		if tx.Log != "" {
+			continue
+		}
+		switch transaction.Type(tx.Type) {
+		case transaction.TypeBuySwapPool,
+			transaction.TypeSellSwapPool,
+			transaction.TypeSellAllSwapPool:
+			jsonString := strings.Replace(tx.GetTags()["tx.pools"], `\`, "", -1)
+			var tagPools []models.BuySwapPoolTag
+			err = json.Unmarshal([]byte(jsonString), &tagPools)
+			if err != nil {
+				s.logger.WithFields(logrus.Fields{
+					"json":  jsonString,
+					"tx":    tx.Hash,
+					"block": tx.Height,
+				}).Error(err)
+				continue
+			}
+			for _, p := range tagPools {
+				firstVolume, secondVolume := p.ValueIn, p.ValueOut
+				if p.CoinIn > p.CoinOut {
+					firstVolume, secondVolume = p.ValueOut, p.ValueIn
+				}
+				err = addTrade(pools, p.PoolId, firstVolume, secondVolume)
+				if err != nil {
+					s.logger.WithField("tx", tx.Hash).Error(err)
+				}
+			}
+		case transaction.TypeSellCoin,
+			transaction.TypeSellAllCoin,
+			transaction.TypeBuyCoin:
+			coinId, volume, volumeBip, err := getBondingCurveTrade(tx)
+			if err != nil {
+				s.logger.WithField("tx", tx.Hash).Error(err)
+				continue
+			}
+			// only direct trades against the base coin define the coin price
+			if volume == "" {
+				continue
+			}
+			err = addTrade(coins, coinId, volume, volumeBip)
+			if err != nil {
+				s.logger.WithField("tx", tx.Hash).Error(err)
+			}
+		}
+	}
+
+	if len(pools) == 0 && len(coins) == 0 {
+		return nil
+	}
+
+	var poolCandles []*models.PoolCandle
+	var poolKeys []interface{}
+	for k, c := range pools {
+		startAt := blockTime.Truncate(models.CandlePeriods[k.period])
+		poolCandles = append(poolCandles, &models.PoolCandle{
+			LiquidityPoolId: k.id,
+			Period:          k.period,
+			StartAt:         startAt,
+			Open:            c.open.Text('f', 18),
+			High:            c.high.Text('f', 18),
+			Low:             c.low.Text('f', 18),
+			Close:           c.close.Text('f', 18),
+			FirstVolume:     c.firstVolume.String(),
+			SecondVolume:    c.secondVolume.String(),
+			TradesCount:     c.trades,
+			LastBlockId:     b.Height,
+		})
+		poolKeys = append(poolKeys, []interface{}{k.id, k.period, startAt})
+	}
+
+	var coinCandles []*models.CoinCandle
+	var coinKeys []interface{}
+	for k, c := range coins {
+		startAt := blockTime.Truncate(models.CandlePeriods[k.period])
+		coinCandles = append(coinCandles, &models.CoinCandle{
+			CoinId:      k.id,
+			Period:      k.period,
+			StartAt:     startAt,
+			Open:        c.open.Text('f', 18),
+			High:        c.high.Text('f', 18),
+			Low:         c.low.Text('f', 18),
+			Close:       c.close.Text('f', 18),
+			Volume:      c.firstVolume.String(),
+			VolumeBip:   c.secondVolume.String(),
+			TradesCount: c.trades,
+			LastBlockId: b.Height,
+		})
+		coinKeys = append(coinKeys, []interface{}{k.id, k.period, startAt})
+	}
+
+	err = s.repository.SaveCandles(poolCandles, coinCandles)
+	if err != nil {
+		return err
+	}
+
+	return s.publish(poolKeys, coinKeys)
+}
+
+// publish Send the merged candles affected by the block to broadcast
+func (s *Service) publish(poolKeys, coinKeys []interface{}) error {
+	if len(poolKeys) > 0 {
+		list, err := s.repository.GetPoolCandles(poolKeys)
+		if err != nil {
+			return err
+		}
+		s.broadcastService.PoolCandlesChannel() <- list
+	}
+	if len(coinKeys) > 0 {
+		list, err := s.repository.GetCoinCandles(coinKeys)
+		if err != nil {
+			return err
+		}
+		s.broadcastService.CoinCandlesChannel() <- list
+	}
+	return nil
+}
+
+// addTrade Add trade to candles of all periods, price is second volume per one unit of the first
+func addTrade(candles map[candleKey]*ohlcv, id uint64, firstVolume, secondVolume string) error {
+	first, ok := big.NewInt(0).SetString(firstVolume, 10)
+	if !ok {
+		return errors.New("can't convert to big.int")
+	}
+	second, ok := big.NewInt(0).SetString(secondVolume, 10)
+	if !ok {
+		return errors.New("can't convert to big.int")
+	}
+	if first.Sign() <= 0 {
+		return nil
+	}
+	price := new(big.Float).SetPrec(precision).SetInt(second)
+	price.Quo(price, new(big.Float).SetPrec(precision).SetInt(first))
+
+	for period := range models.CandlePeriods {
+		key := candleKey{id: id, period: period}
+		if candles[key] == nil {
+			candles[key] = new(ohlcv)
+		}
+		candles[key].add(price, first, second)
+	}
+	return nil
+}
+
+// getBondingCurveTrade Return traded coin with its volume and base coin volume.
+// Empty volume is returned for conversions between two custom coins
+func getBondingCurveTrade(tx *api_pb.TransactionResponse) (uint64, string, string, error) {
+	var sellCoin, buyCoin uint64
+	var sellValue, buyValue string
+
+	tags := tx.GetTags()
+	switch transaction.Type(tx.Type) {
+	case transaction.TypeSellCoin:
+		txData := new(api_pb.SellCoinData)
+		if err := tx.GetData().UnmarshalTo(txData); err != nil {
+			return 0, "", "", err
+		}
+		sellCoin, sellValue = txData.CoinToSell.Id, txData.ValueToSell
+		buyCoin, buyValue = txData.CoinToBuy.Id, tags["tx.return"]
+	case transaction.TypeSellAllCoin:
+		txData := new(api_pb.SellAllCoinData)
+		if err := tx.GetData().UnmarshalTo(txData); err != nil {
+			return 0, "", "", err
+		}
+		sellCoin, sellValue = txData.CoinToSell.Id, tags["tx.sell_amount"]
+		buyCoin, buyValue = txData.CoinToBuy.Id, tags["tx.return"]
+	case transaction.TypeBuyCoin:
+		txData := new(api_pb.BuyCoinData)
+		if err := tx.GetData().UnmarshalTo(txData); err != nil {
+			return 0, "", "", err
+		}
+		sellCoin, sellValue = txData.CoinToSell.Id, tags["tx.return"]
+		buyCoin, buyValue = txData.CoinToBuy.Id, txData.ValueToBuy
+	}
+
+	switch {
+	case sellCoin == 0 && buyCoin != 0:
+		return buyCoin, buyValue, sellValue, nil
+	case buyCoin == 0 && sellCoin != 0:
+		return sellCoin, sellValue, buyValue, nil
+	}
+	return 0, "", "", nil
+}
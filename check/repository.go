@@ -0,0 +1,114 @@
+package check
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+type Repository struct {
+	db orm.DB
+}
+
+func NewRepository(db *pg.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// WithTx Return repository which runs queries in the transaction
+func (r *Repository) WithTx(tx *pg.Tx) *Repository {
+	return &Repository{
+		db: tx,
+	}
+}
+
+// SaveAttempts Save checks of failed redeem attempts unless they are known and the attempts.
+// Attempts are unique by transaction hash, so a block can be safely handled twice
+func (r *Repository) SaveAttempts(checks []*models.Check, attempts []*models.CheckRedeemAttempt) error {
+	return database.RunInTransaction(r.db, func(tx *pg.Tx) error {
+		_, err := tx.Model(&checks).
+			OnConflict("(data) DO NOTHING").
+			Insert()
+		if err != nil {
+			return err
+		}
+
+		data := make([]string, len(checks))
+		for i, c := range checks {
+			data[i] = c.Data
+		}
+		var stored []*models.Check
+		err = tx.Model(&stored).
+			Column("id", "data").
+			Where("data IN (?)", pg.In(data)).
+			Select()
+		if err != nil {
+			return err
+		}
+		ids := make(map[string]uint64, len(stored))
+		for _, c := range stored {
+			ids[c.Data] = c.ID
+		}
+		for i, a := range attempts {
+			a.CheckID = ids[checks[i].Data]
+		}
+
+		_, err = tx.Model(&attempts).
+			OnConflict("(transaction_hash) DO NOTHING").
+			Insert()
+		return err
+	})
+}
+
+// Expire Mark checks which have not been redeemed until the due block as expired
+func (r *Repository) Expire(height uint64) (int, error) {
+	res, err := r.db.Model((*models.Check)(nil)).
+		Set("status = ?", models.CheckStatusExpired).
+		Where("status = ?", models.CheckStatusIssued).
+		Where("due_block < ?", height).
+		Update()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// GetUndecoded Return checks saved before check fields were decoded, ordered by id
+func (r *Repository) GetUndecoded(fromId uint64, limit int) ([]*models.Check, error) {
+	var list []*models.Check
+	err := r.db.Model(&list).
+		Column("id", "data").
+		Where("due_block IS NULL").
+		Where("id > ?", fromId).
+		Order("id").
+		Limit(limit).
+		Select()
+	return list, err
+}
+
+// UpdateDecoded Save decoded fields of checks, the redemption block and gas are taken from their transactions
+func (r *Repository) UpdateDecoded(list []*models.Check) error {
+	return database.RunInTransaction(r.db, func(tx *pg.Tx) error {
+		for _, c := range list {
+			_, err := tx.Model(c).
+				Column("nonce", "chain_id", "due_block", "coin_id", "value", "gas_coin_id").
+				WherePK().
+				Update()
+			if err != nil {
+				return err
+			}
+		}
+		ids := make([]uint64, len(list))
+		for i, c := range list {
+			ids[i] = c.ID
+		}
+		_, err := tx.Exec(`
+			UPDATE checks SET block_id = t.block_id, gas_amount = (t.tags ->> 'tx.commission_amount')::numeric
+			FROM transactions t
+			WHERE t.id = checks.transaction_id AND checks.id IN (?);
+		`, pg.In(ids))
+		return err
+	})
+}
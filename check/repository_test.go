@@ -0,0 +1,145 @@
+package check
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
+	"github.com/MinterTeam/minter-explorer-extender/v2/env"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+	"os"
+	"testing"
+)
+
+// TestCheckLifecycle Save failed redeem attempts twice and expire checks block by block.
+// Needs a migrated database set by DB_* variables, changes are rolled back
+func TestCheckLifecycle(t *testing.T) {
+	if os.Getenv("DB_NAME") == "" {
+		t.Skip("DB_NAME is not set")
+	}
+	db := database.Connect(env.New())
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	r := NewRepository(db).WithTx(tx)
+
+	// stored checks are due before the test heights, they are expired on the first call and not checked
+	var height uint64
+	_, err = tx.QueryOne(pg.Scan(&height), `SELECT coalesce(max(due_block), 0) + 1 FROM checks;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addressId uint
+	_, err = tx.QueryOne(pg.Scan(&addressId), `
+		INSERT INTO addresses (address) VALUES (repeat('0', 40))
+		ON CONFLICT (address) DO UPDATE SET address = excluded.address
+		RETURNING id;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newCheck := func(data string, dueBlock uint64, status string) *models.Check {
+		return &models.Check{Data: data, FromAddressId: addressId, DueBlock: dueBlock, Value: "1000", Status: status}
+	}
+	// the check was redeemed by a transaction before anyone tried it again
+	redeemed := newCheck("test-check-redeemed", height+10, models.CheckStatusRedeemed)
+	_, err = tx.Model(redeemed).Insert()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saveAttempts := func() {
+		checks := []*models.Check{
+			newCheck("test-check-10", height+10, models.CheckStatusIssued),
+			newCheck("test-check-20", height+20, models.CheckStatusIssued),
+			newCheck(redeemed.Data, redeemed.DueBlock, models.CheckStatusIssued),
+		}
+		attempts := make([]*models.CheckRedeemAttempt, len(checks))
+		for i, c := range checks {
+			attempts[i] = &models.CheckRedeemAttempt{
+				BlockID:           height - 1,
+				TransactionHash:   "test-attempt-" + c.Data,
+				RedeemerAddressID: addressId,
+				Code:              1,
+				Log:               "test",
+				AlreadyRedeemed:   c.Data == redeemed.Data,
+			}
+		}
+		attempts[2].Code = codeCheckUsed
+		err := r.SaveAttempts(checks, attempts)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	saveAttempts()
+	// a replayed block doesn't duplicate checks and attempts
+	saveAttempts()
+
+	data := []string{"test-check-10", "test-check-20", redeemed.Data}
+	var attempts []*models.CheckRedeemAttempt
+	err = tx.Model(&attempts).
+		Relation("Check").
+		Where(`"check"."data" IN (?)`, pg.In(data)).
+		Select()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != len(data) {
+		t.Fatalf("saved %d attempts, want %d", len(attempts), len(data))
+	}
+	for _, a := range attempts {
+		if a.TransactionHash != "test-attempt-"+a.Check.Data {
+			t.Errorf("attempt %s is linked to check %s", a.TransactionHash, a.Check.Data)
+		}
+		if a.Check.Data == redeemed.Data && a.CheckID != redeemed.ID {
+			t.Errorf("attempt of the redeemed check is linked to check %d, want %d", a.CheckID, redeemed.ID)
+		}
+	}
+
+	tests := []struct {
+		height   uint64
+		statuses map[string]string
+	}{
+		{height, map[string]string{
+			"test-check-10": models.CheckStatusIssued,
+			"test-check-20": models.CheckStatusIssued,
+			redeemed.Data:   models.CheckStatusRedeemed,
+		}},
+		// a check can be redeemed at its due block
+		{height + 10, map[string]string{
+			"test-check-10": models.CheckStatusIssued,
+			"test-check-20": models.CheckStatusIssued,
+			redeemed.Data:   models.CheckStatusRedeemed,
+		}},
+		{height + 11, map[string]string{
+			"test-check-10": models.CheckStatusExpired,
+			"test-check-20": models.CheckStatusIssued,
+			redeemed.Data:   models.CheckStatusRedeemed,
+		}},
+		{height + 30, map[string]string{
+			"test-check-10": models.CheckStatusExpired,
+			"test-check-20": models.CheckStatusExpired,
+			redeemed.Data:   models.CheckStatusRedeemed,
+		}},
+	}
+	for _, tt := range tests {
+		_, err := r.Expire(tt.height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var checks []*models.Check
+		err = tx.Model(&checks).Column("data", "status").Where("data IN (?)", pg.In(data)).Select()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(checks) != len(tt.statuses) {
+			t.Fatalf("found %d checks, want %d", len(checks), len(tt.statuses))
+		}
+		for _, c := range checks {
+			if c.Status != tt.statuses[c.Data] {
+				t.Errorf("check %s is %s at %d, want %s", c.Data, c.Status, tt.height, tt.statuses[c.Data])
+			}
+		}
+	}
+}
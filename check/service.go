@@ -0,0 +1,147 @@
+package check
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/address"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/sirupsen/logrus"
+)
+
+// codeCheckUsed Node error code of a redeem attempt of a used check
+const codeCheckUsed = 503
+
+const decodeChunk = 1000
+
+type Service struct {
+	repository        *Repository
+	addressRepository *address.Repository
+	logger            *logrus.Entry
+}
+
+func NewService(repository *Repository, addressRepository *address.Repository, logger *logrus.Entry) *Service {
+	return &Service{
+		repository:        repository,
+		addressRepository: addressRepository,
+		logger: logger.WithFields(logrus.Fields{
+			"service": "Checks",
+		}),
+	}
+}
+
+// HandleBlock Save failed redeem attempts of the block and expire checks which have passed the due block.
+// Redeemed checks are saved with transaction outputs
+func (s *Service) HandleBlock(b *api_pb.BlockResponse) error {
+	var checks []*models.Check
+	var attempts []*models.CheckRedeemAttempt
+	var senders, redeemers []string
+	for _, tx := range b.Transactions {
+		if tx.Log == "" || transaction.Type(tx.Type) != transaction.TypeRedeemCheck {
+			continue
+		}
+		txData := new(api_pb.RedeemCheckData)
+		if err := tx.Data.UnmarshalTo(txData); err != nil {
+			return err
+		}
+		c, sender, err := Decode(txData.RawCheck)
+		if err != nil {
+			s.logger.WithField("tx", tx.Hash).Error(err)
+			continue
+		}
+		c.Status = models.CheckStatusIssued
+		checks = append(checks, c)
+		senders = append(senders, sender)
+		attempts = append(attempts, &models.CheckRedeemAttempt{
+			BlockID:         b.Height,
+			TransactionHash: helpers.RemovePrefix(tx.Hash),
+			Code:            tx.Code,
+			Log:             tx.Log,
+			AlreadyRedeemed: tx.Code == codeCheckUsed,
+		})
+		redeemers = append(redeemers, helpers.RemovePrefix(tx.From))
+	}
+
+	if len(checks) > 0 {
+		addressIds, err := s.addressRepository.FindIdsOrCreate(append(senders, redeemers...))
+		if err != nil {
+			return err
+		}
+		for i, c := range checks {
+			c.FromAddressId = addressIds[senders[i]]
+			attempts[i].RedeemerAddressID = addressIds[redeemers[i]]
+		}
+		err = s.repository.SaveAttempts(checks, attempts)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := s.repository.Expire(b.Height)
+	return err
+}
+
+// DecodeStored Fill decoded fields of checks saved before they were stored
+func (s *Service) DecodeStored() error {
+	var lastId uint64
+	total := 0
+	for {
+		list, err := s.repository.GetUndecoded(lastId, decodeChunk)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		var decoded []*models.Check
+		for _, c := range list {
+			lastId = c.ID
+			rawCheck, err := hex.DecodeString(c.Data)
+			if err != nil {
+				s.logger.WithField("check", c.ID).Error(err)
+				continue
+			}
+			d, _, err := Decode(base64.StdEncoding.EncodeToString(rawCheck))
+			if err != nil {
+				s.logger.WithField("check", c.ID).Error(err)
+				continue
+			}
+			d.ID = c.ID
+			decoded = append(decoded, d)
+		}
+		err = s.repository.UpdateDecoded(decoded)
+		if err != nil {
+			return err
+		}
+		total += len(decoded)
+		s.logger.Warning(fmt.Sprintf("%d checks decoded", total))
+	}
+}
+
+// Decode Return fields of a base64 encoded check and the address of its issuer without prefix
+func Decode(rawCheck string) (*models.Check, string, error) {
+	data, err := transaction.DecodeCheckBase64(rawCheck)
+	if err != nil {
+		return nil, "", err
+	}
+	sender, err := data.Sender()
+	if err != nil {
+		return nil, "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(rawCheck)
+	if err != nil {
+		return nil, "", err
+	}
+	return &models.Check{
+		Data:      hex.EncodeToString(raw),
+		Nonce:     hex.EncodeToString(data.Nonce),
+		ChainID:   uint8(data.ChainID),
+		DueBlock:  data.DueBlock,
+		CoinID:    uint64(data.Coin),
+		Value:     data.Value.String(),
+		GasCoinID: uint64(data.GasCoin),
+	}, helpers.RemovePrefix(sender), nil
+}
@@ -2,14 +2,27 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/address"
+	"github.com/MinterTeam/minter-explorer-extender/v2/cache"
 	"github.com/MinterTeam/minter-explorer-extender/v2/core"
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
 	"github.com/MinterTeam/minter-explorer-extender/v2/env"
+	"github.com/MinterTeam/minter-explorer-extender/v2/events"
 	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
 	"log"
 	"os"
+	"time"
 )
 
 var version = flag.Bool("version", false, "Prints current version")
+var rebuildAddressStats = flag.Bool("rebuild-address-stats", false, "Recalculates address statistics from indexed transactions (extender must be stopped)")
+var rebuildValidatorStats = flag.Bool("rebuild-validator-stats", false, "Recalculates validator statistics from indexed blocks (extender must be stopped)")
+var fillOutputBlocks = flag.Bool("fill-output-blocks", false, "Sets block_id of transaction outputs saved before the table was partitioned")
+var fillAddressRoles = flag.Bool("fill-address-roles", false, "Sets roles of transaction index rows saved before roles were stored")
+var decodeChecks = flag.Bool("decode-checks", false, "Decodes fields of checks saved before they were stored (extender must be stopped)")
+var reconcileWaitList = flag.Bool("reconcile-wait-list", false, "Loads wait list stakes of the addresses given as arguments, or of all addresses in the wait list, from node (extender must be stopped)")
 
 func main() {
 	flag.Parse()
@@ -20,6 +33,17 @@ func main() {
 	}
 
 	envData := env.New()
+
+	if flag.Arg(0) == "migrate" {
+		migrate(envData, flag.Arg(1))
+		os.Exit(0)
+	}
+
+	if flag.Arg(0) == "export-rewards" {
+		exportRewards(envData, flag.Args()[1:])
+		os.Exit(0)
+	}
+
 	ext := core.NewExtender(envData)
 
 	if *version {
@@ -27,7 +51,111 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *rebuildAddressStats {
+		ext.RebuildAddressStats()
+		os.Exit(0)
+	}
+
+	if *rebuildValidatorStats {
+		ext.RebuildValidatorStats()
+		os.Exit(0)
+	}
+
+	if *fillOutputBlocks {
+		ext.FillOutputBlocks()
+		os.Exit(0)
+	}
+
+	if *fillAddressRoles {
+		ext.FillAddressRoles()
+		os.Exit(0)
+	}
+
+	if *decodeChecks {
+		ext.DecodeChecks()
+		os.Exit(0)
+	}
+
+	if *reconcileWaitList {
+		ext.ReconcileWaitList(flag.Args())
+		os.Exit(0)
+	}
+
 	go ext.Metrics.RunApi()
 
 	ext.Run()
 }
+
+// migrate Run `extender migrate up|down|status`
+func migrate(envData *env.ExtenderEnvironment, command string) {
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+	contextLogger := logger.WithField("app", "Minter Explorer Extender")
+
+	db := database.Connect(envData)
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, contextLogger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	switch command {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	case "status":
+		var list []database.MigrationStatus
+		list, err = migrator.Status()
+		for _, m := range list {
+			applied := "pending"
+			if m.AppliedAt != nil {
+				applied = m.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%06d_%s\t%s\n", m.Version, m.Name, applied)
+		}
+	default:
+		err = fmt.Errorf("unknown migrate command %q, expected up, down or status", command)
+	}
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
+
+// exportRewards Run `extender export-rewards <from> <to> <address>...` to write per-block rewards
+// of each address received from the <from> date to the <to> date inclusive into rewards_<address>_<from>_<to>.csv
+func exportRewards(envData *env.ExtenderEnvironment, args []string) {
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+
+	if len(args) < 3 {
+		logger.Fatal("usage: extender export-rewards <from YYYY-MM-DD> <to YYYY-MM-DD> <address>...")
+	}
+	from, err := time.Parse("2006-01-02", args[0])
+	if err != nil {
+		logger.Fatal(err)
+	}
+	to, err := time.Parse("2006-01-02", args[1])
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	db := database.Connect(envData)
+	defer db.Close()
+
+	exporter := events.NewRewardsExporter(events.NewRepository(db, nil), address.NewRepository(db, cache.Config{}))
+	for _, adr := range args[2:] {
+		name := fmt.Sprintf("rewards_%s_%s_%s.csv", adr, args[0], args[1])
+		file, err := os.Create(name)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		count, err := exporter.Export(file, adr, from, to.AddDate(0, 0, 1))
+		file.Close()
+		if err != nil {
+			logger.Fatal(err)
+		}
+		fmt.Printf("%s\t%d rewards\n", name, count)
+	}
+}
@@ -1,25 +1,35 @@
 package coin
 
 import (
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/cache"
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
 	"github.com/go-pg/pg/v10"
-	"sync"
+	"github.com/go-pg/pg/v10/orm"
 )
 
 type Repository struct {
-	DB        *pg.DB
-	cache     *sync.Map
-	coinCache *sync.Map
+	DB        orm.DB
+	cache     *cache.Cache
+	coinCache *cache.Cache
 }
 
-func NewRepository(db *pg.DB) *Repository {
+func NewRepository(db *pg.DB, cacheConfig cache.Config) *Repository {
 	return &Repository{
 		DB:        db,
-		cache:     new(sync.Map), //TODO: добавить реализацию очистки
-		coinCache: new(sync.Map), //TODO: добавить реализацию очистки
+		cache:     cache.New("coin_by_symbol", cacheConfig),
+		coinCache: cache.New("coin", cacheConfig),
 	}
 }
 
+// WithTx Return repository which runs queries in the transaction, caches are shared
+func (r *Repository) WithTx(tx *pg.Tx) *Repository {
+	repository := *r
+	repository.DB = tx
+	return &repository
+}
+
 // Find coin id by symbol
 func (r *Repository) FindCoinIdBySymbol(symbol string) (uint, error) {
 	//First look in the cache
@@ -97,6 +107,7 @@ func (r *Repository) Save(c *models.Coin) error {
 
 func (r *Repository) Update(c *models.Coin) error {
 	_, err := r.DB.Model(c).WherePK().Update()
+	r.coinCache.Delete(c.ID)
 	return err
 }
 
@@ -122,12 +133,38 @@ func (r *Repository) SaveAllNewIfNotExist(coins []*models.Coin) error {
 	return err
 }
 
+// WarmUp Load all coins into the cache
+func (r *Repository) WarmUp() (int, error) {
+	coins, err := r.GetAllCoins()
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range coins {
+		r.cache.Store(c.Symbol, c.ID)
+		r.coinCache.Store(c.ID, c)
+	}
+	return len(coins), nil
+}
+
 func (r *Repository) GetAllCoins() ([]*models.Coin, error) {
 	var coins []*models.Coin
 	err := r.DB.Model(&coins).Order("symbol ASC").Select()
 	return coins, err
 }
 
+// GetChangedSince Return coins created or changed since the block, deleted coins included
+func (r *Repository) GetChangedSince(blockId uint64) ([]*models.Coin, error) {
+	var coins []*models.Coin
+	err := r.DB.Model(&coins).
+		AllWithDeleted().
+		WhereGroup(func(q *orm.Query) (*orm.Query, error) {
+			return q.Where("created_at_block_id >= ?", blockId).
+				WhereOr("id IN (SELECT DISTINCT coin_id FROM coin_history WHERE block_id >= ?)", blockId), nil
+		}).
+		Select()
+	return coins, err
+}
+
 func (r *Repository) DeleteBySymbol(symbol string) error {
 	coin := &models.Coin{Symbol: symbol}
 	_, err := r.DB.Model(coin).Where("symbol = ?symbol").Delete()
@@ -139,6 +176,9 @@ func (r *Repository) UpdateOwnerBySymbol(symbol string, id uint) error {
 		UPDATE coins SET owner_address_id = ?
 		WHERE symbol = ?;
 	`, id, symbol)
+	r.coinCache.DeleteFunc(func(key, value interface{}) bool {
+		return value.(*models.Coin).Symbol == symbol
+	})
 	return err
 }
 
@@ -174,6 +214,7 @@ func (r *Repository) GetLastCoinId() (uint, error) {
 func (r *Repository) UpdateAll(coins []*models.Coin) error {
 	for _, c := range coins {
 		_, err := r.DB.Model(c).WherePK().Update()
+		r.coinCache.Delete(c.ID)
 		if err != nil {
 			return err
 		}
@@ -200,3 +241,58 @@ func (r *Repository) GetConfirmedCoinsId() ([]uint64, error) {
 
 	return result, err
 }
+
+// SaveHistory Save coin changes and update daily rollup.
+// Changes already stored by a replayed block are skipped and aren't rolled up again
+func (r *Repository) SaveHistory(list []*models.CoinHistory) error {
+	return database.RunInTransaction(r.DB, func(tx *pg.Tx) error {
+		blockIds := make([]uint64, len(list))
+		for i, h := range list {
+			blockIds[i] = h.BlockID
+		}
+		var stored []*models.CoinHistory
+		err := tx.Model(&stored).Column("coin_id", "block_id", "cause").Where("block_id IN (?)", pg.In(blockIds)).Select()
+		if err != nil {
+			return err
+		}
+		exists := make(map[string]struct{}, len(stored))
+		for _, h := range stored {
+			exists[historyKey(h)] = struct{}{}
+		}
+		var newList []*models.CoinHistory
+		for _, h := range list {
+			if _, ok := exists[historyKey(h)]; ok {
+				continue
+			}
+			exists[historyKey(h)] = struct{}{}
+			newList = append(newList, h)
+		}
+		if len(newList) == 0 {
+			return nil
+		}
+
+		_, err = tx.Model(&newList).OnConflict("(coin_id, block_id, cause) DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+		daily, err := rollupHistory(newList)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(&daily).
+			OnConflict("(coin_id, date) DO UPDATE").
+			Set("volume = CASE WHEN EXCLUDED.last_block_id >= coin_history_daily.last_block_id THEN EXCLUDED.volume ELSE coin_history_daily.volume END").
+			Set("reserve = CASE WHEN EXCLUDED.last_block_id >= coin_history_daily.last_block_id THEN EXCLUDED.reserve ELSE coin_history_daily.reserve END").
+			Set("price = CASE WHEN EXCLUDED.last_block_id >= coin_history_daily.last_block_id THEN EXCLUDED.price ELSE coin_history_daily.price END").
+			Set("min_price = least(coin_history_daily.min_price, EXCLUDED.min_price)").
+			Set("max_price = greatest(coin_history_daily.max_price, EXCLUDED.max_price)").
+			Set("changes = coin_history_daily.changes + EXCLUDED.changes").
+			Set("last_block_id = greatest(coin_history_daily.last_block_id, EXCLUDED.last_block_id)").
+			Insert()
+		return err
+	})
+}
+
+func historyKey(h *models.CoinHistory) string {
+	return fmt.Sprintf("%d-%d-%s", h.CoinID, h.BlockID, h.Cause)
+}
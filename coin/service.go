@@ -27,7 +27,14 @@ type Service struct {
 	addressRepository     *address.Repository
 	logger                *logrus.Entry
 	jobUpdateCoins        chan []*models.Transaction
-	jobUpdateCoinsFromMap chan map[uint64]struct{}
+	jobUpdateCoinsFromMap chan *UpdateCoinsJob
+}
+
+// UpdateCoinsJob Coins to be refreshed from node after the block with given height
+type UpdateCoinsJob struct {
+	Height    uint64
+	CreatedAt time.Time
+	Coins     map[uint64]struct{}
 }
 
 func NewService(env *env.ExtenderEnvironment, nodeApi *grpc_client.Client, repository *Repository,
@@ -40,7 +47,7 @@ func NewService(env *env.ExtenderEnvironment, nodeApi *grpc_client.Client, repos
 		addressRepository:     addressRepository,
 		logger:                logger,
 		jobUpdateCoins:        make(chan []*models.Transaction, 1),
-		jobUpdateCoinsFromMap: make(chan map[uint64]struct{}, 1),
+		jobUpdateCoinsFromMap: make(chan *UpdateCoinsJob, 1),
 	}
 }
 
@@ -52,11 +59,18 @@ type CreateCoinData struct {
 	Crr            string `json:"crr"`
 }
 
+// WithTx Return service which writes coins in the transaction, job channels are shared
+func (s *Service) WithTx(tx *pg.Tx) *Service {
+	service := *s
+	service.Storage = s.Storage.WithTx(tx)
+	return &service
+}
+
 func (s *Service) GetUpdateCoinsFromTxsJobChannel() chan []*models.Transaction {
 	return s.jobUpdateCoins
 }
 
-func (s *Service) GetUpdateCoinsFromCoinsMapJobChannel() chan map[uint64]struct{} {
+func (s *Service) GetUpdateCoinsFromCoinsMapJobChannel() chan *UpdateCoinsJob {
 	return s.jobUpdateCoinsFromMap
 }
 
@@ -64,6 +78,12 @@ func (s Service) HandleCoinsFromBlock(block *api_pb.BlockResponse) error {
 	var coins []*models.Coin
 	var err error
 	var height uint64
+	var createdBy = make(map[uint]string)
+
+	blockTime, err := time.Parse("2006-01-02T15:04:05Z", block.Time)
+	if err != nil {
+		return err
+	}
 
 	for _, tx := range block.Transactions {
 		if tx.Log != "" || tx.Code > 0 {
@@ -79,6 +99,7 @@ func (s Service) HandleCoinsFromBlock(block *api_pb.BlockResponse) error {
 				return err
 			}
 			coins = append(coins, coin)
+			createdBy[coin.ID] = helpers.RemovePrefix(tx.Hash)
 		case transaction.TypeRecreateCoin:
 			txData := new(api_pb.RecreateCoinData)
 			tx.GetData()
@@ -106,7 +127,7 @@ func (s Service) HandleCoinsFromBlock(block *api_pb.BlockResponse) error {
 				Version:          0,
 			}
 
-			err = s.RecreateCoin(newCoin)
+			err = s.RecreateCoin(newCoin, helpers.RemovePrefix(tx.Hash), blockTime)
 			if err != nil {
 				return err
 			}
@@ -137,19 +158,28 @@ func (s Service) HandleCoinsFromBlock(block *api_pb.BlockResponse) error {
 				Version:          0,
 			}
 
-			err = s.RecreateCoin(newCoin)
+			err = s.RecreateCoin(newCoin, helpers.RemovePrefix(tx.Hash), blockTime)
 			if err != nil {
 				return err
 			}
 		case transaction.TypeMintToken:
-			err = s.MintToken(tx)
+			err = s.MintToken(tx, blockTime)
 		case transaction.TypeBurnToken:
-			err = s.BurnToken(tx)
+			err = s.BurnToken(tx, blockTime)
 		}
 	}
 
 	if len(coins) > 0 {
 		err = s.CreateNewCoins(coins)
+		if err != nil {
+			return err
+		}
+
+		var history []*models.CoinHistory
+		for _, c := range coins {
+			history = append(history, newCoinHistory(c, "", "", block.Height, createdBy[c.ID], blockTime))
+		}
+		err = s.saveHistory(history)
 	}
 
 	return err
@@ -222,17 +252,17 @@ func (s *Service) CreateNewCoins(coins []*models.Coin) error {
 	return err
 }
 
-func (s Service) UpdateCoinsInfoFromCoinsMap(job <-chan map[uint64]struct{}) {
-	for coinsMap := range job {
-		delete(coinsMap, 0)
-		if len(coinsMap) > 0 {
-			coinsForUpdate := make([]uint64, len(coinsMap))
+func (s Service) UpdateCoinsInfoFromCoinsMap(job <-chan *UpdateCoinsJob) {
+	for data := range job {
+		delete(data.Coins, 0)
+		if len(data.Coins) > 0 {
+			coinsForUpdate := make([]uint64, len(data.Coins))
 			i := 0
-			for coinId := range coinsMap {
+			for coinId := range data.Coins {
 				coinsForUpdate[i] = coinId
 				i++
 			}
-			err := s.UpdateCoinsInfo(coinsForUpdate)
+			err := s.UpdateCoinsInfo(coinsForUpdate, data.Height, data.CreatedAt)
 			if err != nil {
 				s.logger.Error(err)
 			}
@@ -240,18 +270,31 @@ func (s Service) UpdateCoinsInfoFromCoinsMap(job <-chan map[uint64]struct{}) {
 	}
 }
 
-func (s *Service) UpdateCoinsInfo(coinIds []uint64) error {
+func (s *Service) UpdateCoinsInfo(coinIds []uint64, height uint64, createdAt time.Time) error {
 	var coins []*models.Coin
+	var history []*models.CoinHistory
 	for _, coinId := range coinIds {
+		// GetCoinFromNode updates the cached model, so keep the previous values first
+		var oldVolume, oldReserve string
+		if c, err := s.Storage.GetById(uint(coinId)); err == nil {
+			oldVolume, oldReserve = c.Volume, c.Reserve
+		}
 		coin, err := s.GetCoinFromNode(coinId)
 		if err != nil {
 			s.logger.Error(err)
 			continue
 		}
 		coins = append(coins, coin)
+		if coin.Volume != oldVolume || coin.Reserve != oldReserve {
+			history = append(history, newCoinHistory(coin, oldVolume, oldReserve, height, models.CoinHistoryCauseNodeRefresh, createdAt))
+		}
 	}
 	if len(coins) > 0 {
-		return s.Storage.UpdateAll(coins)
+		err := s.Storage.UpdateAll(coins)
+		if err != nil {
+			return err
+		}
+		return s.saveHistory(history)
 	}
 	return nil
 }
@@ -302,12 +345,14 @@ func (s *Service) ChangeOwner(symbol, owner string) error {
 	return s.Storage.UpdateOwnerBySymbol(symbol, id)
 }
 
-func (s *Service) RecreateCoin(newCoin *models.Coin) error {
+func (s *Service) RecreateCoin(newCoin *models.Coin, cause string, createdAt time.Time) error {
 	coins, err := s.Storage.GetCoinBySymbol(newCoin.Symbol)
 	if err != nil {
 		return err
 	}
 
+	// the history of the new coin starts from the volume and reserve of the replaced one
+	var oldVolume, oldReserve string
 	for _, c := range coins {
 		if c.Version == 0 {
 			c.Version = uint(len(coins))
@@ -316,12 +361,18 @@ func (s *Service) RecreateCoin(newCoin *models.Coin) error {
 				return err
 			}
 			newCoin.OwnerAddressId = c.OwnerAddressId
+			oldVolume, oldReserve = c.Volume, c.Reserve
 			break
 		}
 	}
 	s.Storage.RemoveFromCacheBySymbol(newCoin.Symbol)
 	err = s.Storage.Add(newCoin)
-	return err
+	if err != nil {
+		return err
+	}
+	return s.saveHistory([]*models.CoinHistory{
+		newCoinHistory(newCoin, oldVolume, oldReserve, uint64(newCoin.CreatedAtBlockId), cause, createdAt),
+	})
 }
 
 func (s *Service) RecreateToken(data *api_pb.RecreateTokenData, txTags map[string]string, height uint64) error {
@@ -411,7 +462,7 @@ func (s *Service) GetBySymbolAndVersion(symbol string, version uint) (*models.Co
 	return nil, errors.New("coin not found")
 }
 
-func (s *Service) MintToken(tx *api_pb.TransactionResponse) error {
+func (s *Service) MintToken(tx *api_pb.TransactionResponse, createdAt time.Time) error {
 	txData := new(api_pb.MintTokenData)
 	if err := tx.GetData().UnmarshalTo(txData); err != nil {
 		return err
@@ -427,14 +478,20 @@ func (s *Service) MintToken(tx *api_pb.TransactionResponse) error {
 
 	coinVolume.Add(coinVolume, addVolume)
 
+	oldVolume := c.Volume
 	c.Volume = coinVolume.String()
 
 	_, err = s.Storage.DB.Model(c).WherePK().Update()
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.saveHistory([]*models.CoinHistory{
+		newCoinHistory(c, oldVolume, c.Reserve, tx.Height, helpers.RemovePrefix(tx.Hash), createdAt),
+	})
 }
 
-func (s *Service) BurnToken(tx *api_pb.TransactionResponse) error {
+func (s *Service) BurnToken(tx *api_pb.TransactionResponse, createdAt time.Time) error {
 	txData := new(api_pb.BurnTokenData)
 	if err := tx.GetData().UnmarshalTo(txData); err != nil {
 		return err
@@ -450,9 +507,107 @@ func (s *Service) BurnToken(tx *api_pb.TransactionResponse) error {
 
 	coinVolume.Sub(coinVolume, burnVolume)
 
+	oldVolume := c.Volume
 	c.Volume = coinVolume.String()
 
 	_, err = s.Storage.DB.Model(c).WherePK().Update()
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.saveHistory([]*models.CoinHistory{
+		newCoinHistory(c, oldVolume, c.Reserve, tx.Height, helpers.RemovePrefix(tx.Hash), createdAt),
+	})
+}
+
+// CalculatePrice Return spot price of one coin unit in base coin by the bonding curve formula
+func CalculatePrice(volume, reserve string, crr uint) string {
+	if crr == 0 {
+		return ""
+	}
+	v, ok := new(big.Float).SetPrec(256).SetString(volume)
+	if !ok || v.Sign() <= 0 {
+		return ""
+	}
+	r, ok := new(big.Float).SetPrec(256).SetString(reserve)
+	if !ok {
+		return ""
+	}
+	price := new(big.Float).SetPrec(256).Mul(r, big.NewFloat(100))
+	price.Quo(price, v.Mul(v, big.NewFloat(float64(crr))))
+	return price.Text('f', 18)
+}
+
+func newCoinHistory(c *models.Coin, oldVolume, oldReserve string, height uint64, cause string, createdAt time.Time) *models.CoinHistory {
+	return &models.CoinHistory{
+		CoinID:     uint64(c.ID),
+		BlockID:    height,
+		Cause:      cause,
+		OldVolume:  oldVolume,
+		NewVolume:  c.Volume,
+		OldReserve: oldReserve,
+		NewReserve: c.Reserve,
+		Price:      CalculatePrice(c.Volume, c.Reserve, c.Crr),
+		CreatedAt:  createdAt,
+	}
+}
+
+// saveHistory Store coins changes and roll them up by day
+func (s *Service) saveHistory(list []*models.CoinHistory) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return s.Storage.SaveHistory(list)
+}
+
+// rollupHistory Roll coins changes up by day
+func rollupHistory(list []*models.CoinHistory) ([]*models.CoinHistoryDaily, error) {
+	var daily []*models.CoinHistoryDaily
+	dailyMap := make(map[string]*models.CoinHistoryDaily)
+	for _, h := range list {
+		date, err := time.Parse("2006-01-02", h.CreatedAt.Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		key := fmt.Sprintf("%d-%s", h.CoinID, date.Format("2006-01-02"))
+		d, ok := dailyMap[key]
+		if !ok {
+			d = &models.CoinHistoryDaily{
+				CoinID:   h.CoinID,
+				Date:     date,
+				MinPrice: h.Price,
+				MaxPrice: h.Price,
+			}
+			dailyMap[key] = d
+			daily = append(daily, d)
+		}
+		if h.BlockID >= d.LastBlockID {
+			d.Volume = h.NewVolume
+			d.Reserve = h.NewReserve
+			d.Price = h.Price
+			d.LastBlockID = h.BlockID
+		}
+		if comparePrices(h.Price, d.MinPrice) < 0 {
+			d.MinPrice = h.Price
+		}
+		if comparePrices(h.Price, d.MaxPrice) > 0 {
+			d.MaxPrice = h.Price
+		}
+		d.Changes++
+	}
+
+	return daily, nil
+}
+
+// comparePrices Compare two decimal prices, an empty price is treated as absent
+func comparePrices(a, b string) int {
+	if a == "" || b == "" {
+		return 0
+	}
+	x, _ := new(big.Float).SetString(a)
+	y, _ := new(big.Float).SetString(b)
+	if x == nil || y == nil {
+		return 0
+	}
+	return x.Cmp(y)
 }
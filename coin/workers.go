@@ -144,6 +144,13 @@ func (s *Service) UpdateCoinsInfoFromTxsWorker(jobs <-chan []*models.Transaction
 				coinsMap[txData.Coin.Id] = struct{}{}
 			}
 		}
-		s.GetUpdateCoinsFromCoinsMapJobChannel() <- coinsMap
+		if len(transactions) == 0 {
+			continue
+		}
+		s.GetUpdateCoinsFromCoinsMapJobChannel() <- &UpdateCoinsJob{
+			Height:    transactions[0].BlockID,
+			CreatedAt: transactions[0].CreatedAt,
+			Coins:     coinsMap,
+		}
 	}
 }
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// BlockHandlerBufferSize Number of blocks a handler may fall behind the main loop before the main loop waits for it
+const BlockHandlerBufferSize = 100
+
+// Retries of a block a handler failed to handle, the delay doubles after each attempt.
+// The extender stops when the block still fails, later blocks depend on it
+const (
+	blockHandlerAttempts   = 8
+	blockHandlerRetryDelay = time.Second
+)
+
+// blockHandler Pass blocks of the main loop to a service one by one in height order.
+// Each handler runs in its own goroutine, so a slow service doesn't stop the others
+type blockHandler struct {
+	handle     func(*api_pb.BlockResponse) error
+	blocks     chan *api_pb.BlockResponse
+	height     uint64 // last handled block
+	attempts   int
+	retryDelay time.Duration
+	log        *logrus.Entry
+}
+
+func newBlockHandler(name string, handle func(*api_pb.BlockResponse) error, logger *logrus.Entry) *blockHandler {
+	return &blockHandler{
+		handle:     handle,
+		blocks:     make(chan *api_pb.BlockResponse, BlockHandlerBufferSize),
+		attempts:   blockHandlerAttempts,
+		retryDelay: blockHandlerRetryDelay,
+		log: logger.WithFields(logrus.Fields{
+			"handler": name,
+		}),
+	}
+}
+
+// Send Queue the block, blocks must be sent in height order
+func (h *blockHandler) Send(b *api_pb.BlockResponse) {
+	h.blocks <- b
+}
+
+// Run Handle queued blocks, a block which isn't higher than the last handled one is skipped.
+// A failed block is retried, the extender stops if it can't be handled.
+// Only one Run is started for a handler
+func (h *blockHandler) Run() {
+	for b := range h.blocks {
+		if b.Height <= h.height {
+			h.log.WithField("block", b.Height).Warning("block is already handled")
+			continue
+		}
+		err := h.handleWithRetry(b)
+		if err != nil {
+			h.log.WithField("block", b.Height).Fatal(err)
+		}
+		h.height = b.Height
+	}
+}
+
+// handleWithRetry Handle the block, retry it with a growing delay on errors. Return the error of the last attempt
+func (h *blockHandler) handleWithRetry(b *api_pb.BlockResponse) error {
+	delay := h.retryDelay
+	var err error
+	for attempt := 1; attempt <= h.attempts; attempt++ {
+		err = h.handle(b)
+		if err == nil || attempt == h.attempts {
+			break
+		}
+		h.log.WithFields(logrus.Fields{
+			"block":   b.Height,
+			"attempt": attempt,
+		}).Error(err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return err
+}
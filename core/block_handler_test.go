@@ -0,0 +1,81 @@
+package core
+
+import (
+	"errors"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/sirupsen/logrus"
+	"testing"
+)
+
+func TestBlockHandlerRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		attempts int
+		wantErr  bool
+	}{
+		{"no errors", 0, 3, false},
+		{"recovered after retries", 2, 3, false},
+		{"failed every attempt", 3, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := newBlockHandler("test", func(*api_pb.BlockResponse) error {
+				calls++
+				if calls <= tt.failures {
+					return errors.New("failed")
+				}
+				return nil
+			}, logrus.NewEntry(logrus.New()))
+			h.attempts = tt.attempts
+			h.retryDelay = 0
+
+			err := h.handleWithRetry(&api_pb.BlockResponse{Height: 10})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handleWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := tt.failures + 1
+			if tt.wantErr {
+				want = tt.attempts
+			}
+			if calls != want {
+				t.Errorf("handled %d times, want %d", calls, want)
+			}
+		})
+	}
+}
+
+func TestBlockHandlerRun(t *testing.T) {
+	var handled []uint64
+	failed := false
+	h := newBlockHandler("test", func(b *api_pb.BlockResponse) error {
+		// the first attempt of block 11 fails
+		if b.Height == 11 && !failed {
+			failed = true
+			return errors.New("failed")
+		}
+		handled = append(handled, b.Height)
+		return nil
+	}, logrus.NewEntry(logrus.New()))
+	h.retryDelay = 0
+
+	for _, height := range []uint64{10, 11, 11, 9, 12} {
+		h.Send(&api_pb.BlockResponse{Height: height})
+	}
+	close(h.blocks)
+	h.Run()
+
+	want := []uint64{10, 11, 12}
+	if len(handled) != len(want) {
+		t.Fatalf("handled blocks %v, want %v", handled, want)
+	}
+	for i := range want {
+		if handled[i] != want[i] {
+			t.Fatalf("handled blocks %v, want %v", handled, want)
+		}
+	}
+	if h.height != 12 {
+		t.Errorf("height = %d, want 12", h.height)
+	}
+}
@@ -2,7 +2,6 @@ package core
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	genesisUploader "github.com/MinterTeam/explorer-genesis-uploader/core"
 	genesisEnv "github.com/MinterTeam/explorer-genesis-uploader/env"
@@ -11,15 +10,26 @@ import (
 	"github.com/MinterTeam/minter-explorer-extender/v2/balance"
 	"github.com/MinterTeam/minter-explorer-extender/v2/block"
 	"github.com/MinterTeam/minter-explorer-extender/v2/broadcast"
+	"github.com/MinterTeam/minter-explorer-extender/v2/bulk"
+	"github.com/MinterTeam/minter-explorer-extender/v2/cache"
+	"github.com/MinterTeam/minter-explorer-extender/v2/candle"
+	"github.com/MinterTeam/minter-explorer-extender/v2/check"
 	"github.com/MinterTeam/minter-explorer-extender/v2/coin"
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
 	"github.com/MinterTeam/minter-explorer-extender/v2/env"
 	"github.com/MinterTeam/minter-explorer-extender/v2/events"
+	"github.com/MinterTeam/minter-explorer-extender/v2/governance"
 	"github.com/MinterTeam/minter-explorer-extender/v2/liquidity_pool"
+	"github.com/MinterTeam/minter-explorer-extender/v2/lock"
 	"github.com/MinterTeam/minter-explorer-extender/v2/metrics"
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-extender/v2/multisig"
 	"github.com/MinterTeam/minter-explorer-extender/v2/orderbook"
+	"github.com/MinterTeam/minter-explorer-extender/v2/price"
+	"github.com/MinterTeam/minter-explorer-extender/v2/stake"
 	"github.com/MinterTeam/minter-explorer-extender/v2/transaction"
 	"github.com/MinterTeam/minter-explorer-extender/v2/validator"
+	"github.com/MinterTeam/minter-explorer-extender/v2/validator_stats"
 	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
 	"github.com/MinterTeam/minter-go-sdk/v2/api/grpc_client"
 	"github.com/MinterTeam/node-grpc-gateway/api_pb"
@@ -34,32 +44,54 @@ import (
 )
 
 const ChasingModDiff = 121
+const AddressStatsRebuildChunk = 10000
+
+// AddressRolesFillChunk Number of blocks whose index rows get roles in one statement
+const AddressRolesFillChunk = 10000
+
+// OutputBlocksFillChunk Number of blocks whose transaction outputs get block_id in one statement
+const OutputBlocksFillChunk = 10000
 
 var Version string
 
 type Extender struct {
-	Metrics              *metrics.Metrics
-	env                  *env.ExtenderEnvironment
-	nodeApi              *grpc_client.Client
-	blockService         *block.Service
-	addressService       *address.Service
-	blockRepository      *block.Repository
-	validatorService     *validator.Service
-	validatorRepository  *validator.Repository
-	transactionService   *transaction.Service
-	eventService         *events.Service
-	balanceService       *balance.Service
-	coinService          *coin.Service
-	broadcastService     *broadcast.Service
-	orderBookService     *orderbook.Service
-	chasingMode          bool
-	startBlockHeight     uint64
-	currentNodeHeight    uint64
-	lastLPSnapshotHeight uint64
-	log                  *logrus.Entry
-	lpSnapshotChannel    chan *api_pb.BlockResponse
-	lpWorkerChannel      chan *api_pb.BlockResponse
-	orderBookChannel     chan *api_pb.BlockResponse
+	Metrics               *metrics.Metrics
+	env                   *env.ExtenderEnvironment
+	db                    *pg.DB
+	nodeApi               *grpc_client.Client
+	blockService          *block.Service
+	addressService        *address.Service
+	blockRepository       *block.Repository
+	validatorService      *validator.Service
+	validatorRepository   *validator.Repository
+	transactionService    *transaction.Service
+	transactionRepository *transaction.Repository
+	eventService          *events.Service
+	balanceService        *balance.Service
+	coinService           *coin.Service
+	broadcastService      *broadcast.Service
+	orderBookService      *orderbook.Service
+	priceService          *price.Service
+	candleService         *candle.Service
+	validatorStatsService *validator_stats.Service
+	stakeService          *stake.Service
+	lockService           *lock.Service
+	governanceService     *governance.Service
+	multisigService       *multisig.Service
+	checkService          *check.Service
+	partitionManager      *database.PartitionManager
+	chasingMode           bool
+	startBlockHeight      uint64
+	currentNodeHeight     uint64
+	lastLPSnapshotHeight  uint64
+	log                   *logrus.Entry
+	lpSnapshotChannel     chan *api_pb.BlockResponse
+	lpWorkerChannel       chan *api_pb.BlockResponse
+	orderBookChannel      chan *api_pb.BlockResponse
+	candleChannel         chan *api_pb.BlockResponse
+	eventHandler          *blockHandler
+	blockHandlers         []*blockHandler
+	partitionChannel      chan uint64
 }
 
 type ExtenderElapsedTime struct {
@@ -141,27 +173,24 @@ func NewExtender(env *env.ExtenderEnvironment) *Extender {
 		"app":     "Minter Explorer Extender",
 	})
 
-	//Init DB
-	pgOptions := &pg.Options{
-		Addr:     fmt.Sprintf("%s:%s", env.DbHost, env.DbPort),
-		User:     env.DbUser,
-		Password: env.DbPassword,
-		Database: env.DbName,
-	}
-	if os.Getenv("POSTGRES_SSL_ENABLED") == "true" {
-		pgOptions.TLSConfig = &tls.Config{
-			InsecureSkipVerify: true,
-		}
-	}
-
 	//hookImpl := eventHook{
 	//	log:        logrus.New(),
 	//	beforeTime: time.Now(),
 	//}
 
-	db := pg.Connect(pgOptions)
+	//Init DB
+	db := database.Connect(env)
 	//db.AddQueryHook(hookImpl)
 
+	migrator, err := database.NewMigrator(db, contextLogger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	err = migrator.CheckActual()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
 	uploader := genesisUploader.New(genesisEnv.Config{
 		Debug:              false,
 		PostgresHost:       env.DbHost,
@@ -178,7 +207,7 @@ func NewExtender(env *env.ExtenderEnvironment) *Extender {
 		StakeChunkSize:     uint64(env.StakeChunkSize),
 		ValidatorChunkSize: uint64(env.StakeChunkSize),
 	})
-	err := uploader.Do()
+	err = uploader.Do()
 	if err != nil {
 		logger.Warn(err)
 	}
@@ -195,58 +224,231 @@ func NewExtender(env *env.ExtenderEnvironment) *Extender {
 	}
 
 	// Repositories
-	blockRepository := block.NewRepository(db)
-	validatorRepository := validator.NewRepository(db, contextLogger)
-	transactionRepository := transaction.NewRepository(db)
-	addressRepository := address.NewRepository(db)
-	coinRepository := coin.NewRepository(db)
-	eventsRepository := events.NewRepository(db)
+	bulkWriter, err := bulk.NewWriter(env.BulkCopyTables)
+	if err != nil {
+		panic(err)
+	}
+	cacheConfig := cache.Config{
+		Size: env.CacheSize,
+		TTL:  time.Duration(env.CacheTTLMinutes) * time.Minute,
+	}
+	blockRepository := block.NewRepository(db, bulkWriter)
+	validatorRepository := validator.NewRepository(db, cacheConfig, contextLogger)
+	transactionRepository := transaction.NewRepository(db, bulkWriter)
+	addressRepository := address.NewRepository(db, cacheConfig)
+	coinRepository := coin.NewRepository(db, cacheConfig)
+	eventsRepository := events.NewRepository(db, bulkWriter)
 	balanceRepository := balance.NewRepository(db)
 
 	liquidityPoolRepository := liquidity_pool.NewRepository(db)
+	multisigRepository := multisig.NewRepository(db)
 
 	orderbookRepository := orderbook.NewRepository(db)
 
 	coins.GlobalRepository = coins.NewRepository(db) //temporary solution
 
+	if env.CacheWarmUp {
+		warmUpCaches(contextLogger, addressRepository, coinRepository, validatorRepository, env.CacheSize)
+	}
+
 	// Services
 	addressService := address.NewService(env, addressRepository, contextLogger)
-	broadcastService := broadcast.NewService(env, addressRepository, coinRepository, nodeApi, contextLogger)
+	validatorService := validator.NewService(env, nodeApi, validatorRepository, addressRepository, coinRepository, contextLogger)
+	priceService := price.NewService(env, price.NewRepository(db), coinRepository, liquidityPoolRepository, validatorService.GetUpdateBipValueJobChannel(), contextLogger)
+	broadcastService := broadcast.NewService(env, addressRepository, coinRepository, priceService, nodeApi, contextLogger)
 	balanceService := balance.NewService(env, balanceRepository, nodeApi, addressService, coinRepository, broadcastService, contextLogger)
 	coinService := coin.NewService(env, nodeApi, coinRepository, addressRepository, contextLogger)
-	validatorService := validator.NewService(env, nodeApi, validatorRepository, addressRepository, coinRepository, contextLogger)
-	eventService := events.NewService(env, eventsRepository, validatorRepository, addressRepository, coinRepository, coinService, blockRepository, orderbookRepository, balanceRepository, broadcastService, contextLogger, nodeStatus.InitialHeight+1)
+	eventService := events.NewService(env, eventsRepository, validatorRepository, addressRepository, coinRepository, coinService, blockRepository, orderbookRepository, balanceRepository, broadcastService, contextLogger)
 	orderBookService := orderbook.NewService(db, addressRepository, liquidityPoolRepository, contextLogger)
 
-	return &Extender{
-		Metrics:             metrics.New(),
-		env:                 env,
-		nodeApi:             nodeApi,
-		blockService:        block.NewBlockService(blockRepository, validatorRepository, broadcastService),
-		eventService:        eventService,
-		blockRepository:     blockRepository,
-		validatorService:    validatorService,
-		transactionService:  transaction.NewService(env, transactionRepository, addressRepository, validatorRepository, coinRepository, coinService, broadcastService, contextLogger, validatorService.GetUnbondSaverJobChannel(), liquidityPoolRepository, validatorService.GetMoveStakeJobChannel()),
-		addressService:      addressService,
-		validatorRepository: validatorRepository,
-		balanceService:      balanceService,
-		coinService:         coinService,
-		broadcastService:    broadcastService,
-		orderBookService:    orderBookService,
-		chasingMode:         false,
-		currentNodeHeight:   0,
-		startBlockHeight:    nodeStatus.InitialHeight + 1,
-		log:                 contextLogger,
-		lpSnapshotChannel:   make(chan *api_pb.BlockResponse),
-		lpWorkerChannel:     make(chan *api_pb.BlockResponse),
-		orderBookChannel:    make(chan *api_pb.BlockResponse),
+	partitionManager := database.NewPartitionManager(db, env.PartitionSizeBlocks, env.PartitionsAhead, contextLogger)
+	if env.RewardsRaw {
+		partitionManager.AddTable("rewards", env.RewardsRetentionBlocks)
+	}
+
+	ext := &Extender{
+		Metrics:               metrics.New(),
+		env:                   env,
+		db:                    db,
+		nodeApi:               nodeApi,
+		blockService:          block.NewBlockService(blockRepository, validatorRepository, broadcastService),
+		eventService:          eventService,
+		blockRepository:       blockRepository,
+		validatorService:      validatorService,
+		transactionRepository: transactionRepository,
+		transactionService:    transaction.NewService(env, transactionRepository, addressRepository, validatorRepository, coinRepository, coinService, broadcastService, contextLogger, validatorService.GetUnbondSaverJobChannel(), liquidityPoolRepository, validatorService.GetMoveStakeJobChannel(), multisigRepository),
+		addressService:        addressService,
+		validatorRepository:   validatorRepository,
+		balanceService:        balanceService,
+		coinService:           coinService,
+		broadcastService:      broadcastService,
+		orderBookService:      orderBookService,
+		priceService:          priceService,
+		candleService:         candle.NewService(candle.NewRepository(db), broadcastService, contextLogger),
+		validatorStatsService: validator_stats.NewService(validator_stats.NewRepository(db), validatorRepository, broadcastService, env.ValidatorStatsWindow, env.ValidatorMissStreakAlert, contextLogger),
+		stakeService:          stake.NewService(env, nodeApi, stake.NewRepository(db), addressRepository, validatorRepository, broadcastService, balanceService.UpdateAddressesChannel(), contextLogger),
+		lockService:           lock.NewService(env, lock.NewRepository(db), addressRepository, balanceService.UpdateAddressesChannel(), contextLogger),
+		governanceService:     governance.NewService(nodeApi, governance.NewRepository(db), addressRepository, validatorRepository, contextLogger),
+		multisigService:       multisig.NewService(multisigRepository, addressRepository, contextLogger),
+		checkService:          check.NewService(check.NewRepository(db), addressRepository, contextLogger),
+		partitionManager:      partitionManager,
+		chasingMode:           false,
+		currentNodeHeight:     0,
+		startBlockHeight:      nodeStatus.InitialHeight + 1,
+		log:                   contextLogger,
+		lpSnapshotChannel:     make(chan *api_pb.BlockResponse),
+		lpWorkerChannel:       make(chan *api_pb.BlockResponse),
+		orderBookChannel:      make(chan *api_pb.BlockResponse),
+		candleChannel:         make(chan *api_pb.BlockResponse, 100),
+		partitionChannel:      make(chan uint64, 1),
+	}
+
+	// events are saved in height order, so rewards are queued for aggregation in height order too
+	ext.eventHandler = newBlockHandler("events", ext.handleEventResponse, contextLogger)
+	// services which need every block in height order
+	ext.blockHandlers = []*blockHandler{
+		newBlockHandler("validator statistics", ext.validatorStatsService.HandleBlock, contextLogger),
+		newBlockHandler("stakes", ext.stakeService.HandleLedgerBlock, contextLogger),
+		newBlockHandler("locks", ext.lockService.HandleBlock, contextLogger),
+		newBlockHandler("governance", ext.governanceService.HandleBlock, contextLogger),
+		newBlockHandler("multisig", ext.multisigService.HandleBlock, contextLogger),
+		newBlockHandler("checks", ext.checkService.HandleBlock, contextLogger),
+	}
+	return ext
+}
+
+// warmUpCaches Load hot keys into repository caches, a failed warm-up only makes the first blocks slower
+func warmUpCaches(logger *logrus.Entry, addressRepository *address.Repository, coinRepository *coin.Repository,
+	validatorRepository *validator.Repository, limit int) {
+	if limit <= 0 {
+		limit = 100000
+	}
+	addresses, err := addressRepository.WarmUp(limit)
+	if err != nil {
+		logger.Error(err)
+	}
+	coinsCount, err := coinRepository.WarmUp()
+	if err != nil {
+		logger.Error(err)
+	}
+	keys, err := validatorRepository.WarmUp()
+	if err != nil {
+		logger.Error(err)
 	}
+	logger.WithFields(logrus.Fields{
+		"addresses":  addresses,
+		"coins":      coinsCount,
+		"validators": keys,
+	}).Info("caches are warmed up")
 }
 
 func (ext *Extender) GetInfo() {
 	fmt.Printf("%s v%s\n", "Minter Explorer Extender", Version)
 }
 
+// RebuildAddressStats Recalculate address_stats from all indexed transactions
+func (ext *Extender) RebuildAddressStats() {
+	lastExplorerBlock, err := ext.blockRepository.GetLastFromDB()
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+
+	err = ext.transactionRepository.ClearAddressStats()
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+
+	for from := uint64(1); from <= lastExplorerBlock.ID; from += AddressStatsRebuildChunk {
+		to := from + AddressStatsRebuildChunk - 1
+		if to > lastExplorerBlock.ID {
+			to = lastExplorerBlock.ID
+		}
+		err = ext.transactionRepository.RebuildAddressStats(from, to)
+		if err != nil {
+			ext.log.Fatal(err)
+		}
+		ext.log.Warning(fmt.Sprintf("Address stats rebuilt up to block %d of %d", to, lastExplorerBlock.ID))
+	}
+}
+
+// FillAddressRoles Set roles of index_transaction_by_address rows indexed before roles were stored
+func (ext *Extender) FillAddressRoles() {
+	lastExplorerBlock, err := ext.blockRepository.GetLastFromDB()
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+
+	for from := uint64(1); from <= lastExplorerBlock.ID; from += AddressRolesFillChunk {
+		to := from + AddressRolesFillChunk - 1
+		if to > lastExplorerBlock.ID {
+			to = lastExplorerBlock.ID
+		}
+		err = ext.transactionRepository.FillAddressRoles(from, to)
+		if err != nil {
+			ext.log.Fatal(err)
+		}
+		ext.log.Warning(fmt.Sprintf("Address roles filled up to block %d of %d", to, lastExplorerBlock.ID))
+	}
+}
+
+// FillOutputBlocks Set block_id of transaction_outputs rows saved before the table was partitioned
+func (ext *Extender) FillOutputBlocks() {
+	lastExplorerBlock, err := ext.blockRepository.GetLastFromDB()
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+
+	for from := uint64(1); from <= lastExplorerBlock.ID; from += OutputBlocksFillChunk {
+		to := from + OutputBlocksFillChunk - 1
+		if to > lastExplorerBlock.ID {
+			to = lastExplorerBlock.ID
+		}
+		err = ext.transactionRepository.FillOutputBlocks(from, to)
+		if err != nil {
+			ext.log.Fatal(err)
+		}
+		ext.log.Warning(fmt.Sprintf("Output blocks filled up to block %d of %d", to, lastExplorerBlock.ID))
+	}
+}
+
+// RebuildValidatorStats Recalculate validator_stats and validator_daily_uptime from all indexed blocks
+func (ext *Extender) RebuildValidatorStats() {
+	lastExplorerBlock, err := ext.blockRepository.GetLastFromDB()
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+
+	err = ext.validatorStatsService.Rebuild(lastExplorerBlock.ID)
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+}
+
+// DecodeChecks Fill decoded fields of checks saved before they were stored
+func (ext *Extender) DecodeChecks() {
+	err := ext.checkService.DecodeStored()
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+}
+
+// ReconcileWaitList Load wait list stakes of addresses from node at the last indexed block and publish changes
+func (ext *Extender) ReconcileWaitList(addresses []string) {
+	lastExplorerBlock, err := ext.blockRepository.GetLastFromDB()
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+
+	updates, err := ext.stakeService.ReconcileWaitList(lastExplorerBlock.ID, addresses)
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+	if len(updates) > 0 {
+		ext.broadcastService.PublishWaitList(updates)
+	}
+	ext.log.Warning(fmt.Sprintf("Wait list reconciled at block %d, %d stakes changed", lastExplorerBlock.ID, len(updates)))
+}
+
 func (ext *Extender) Run() {
 	//check connections to node
 	_, err := ext.nodeApi.Status()
@@ -274,6 +476,12 @@ func (ext *Extender) Run() {
 		height = ext.startBlockHeight
 	}
 
+	// Partitions must exist before the first block is saved
+	err = ext.partitionManager.EnsurePartitions(height)
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+
 	for {
 		eet := ExtenderElapsedTime{
 			Height:                       height,
@@ -307,29 +515,49 @@ func (ext *Extender) Run() {
 
 		eet.GettingBlock = time.Since(countStart)
 
-		countStart = time.Now()
-		ext.handleCoinsFromTransactions(blockResponse)
-		eet.HandleCoinsFromTransactions = time.Since(countStart)
-
-		countStart = time.Now()
-		ext.handleAddressesFromResponses(blockResponse)
-		eet.HandleAddressesFromResponses = time.Since(countStart)
-
-		countStart = time.Now()
-		ext.handleBlockResponse(blockResponse)
-		eet.HandleBlockResponse = time.Since(countStart)
+		if ext.env.StrictBlockMode {
+			countStart = time.Now()
+			ext.handleBlockStrict(blockResponse)
+			eet.HandleBlockResponse = time.Since(countStart)
+		} else {
+			countStart = time.Now()
+			ext.handleCoinsFromTransactions(blockResponse)
+			eet.HandleCoinsFromTransactions = time.Since(countStart)
+
+			countStart = time.Now()
+			ext.handleAddressesFromResponses(blockResponse)
+			eet.HandleAddressesFromResponses = time.Since(countStart)
+
+			countStart = time.Now()
+			ext.handleBlockResponse(blockResponse)
+			eet.HandleBlockResponse = time.Since(countStart)
+		}
 
 		ext.balanceService.UpdateChannel() <- blockResponse
 
-		go ext.handleEventResponse(height, blockResponse)
+		if !ext.env.StrictBlockMode {
+			ext.eventHandler.Send(blockResponse)
+		}
 
 		if len(blockResponse.Transactions) > 0 {
-			ext.orderBookChannel <- blockResponse
+			if !ext.env.StrictBlockMode {
+				ext.orderBookChannel <- blockResponse
+			}
+			ext.candleChannel <- blockResponse
+		}
+
+		for _, h := range ext.blockHandlers {
+			h.Send(blockResponse)
+		}
+
+		ext.priceService.GetUpdatePricesJobChannel() <- height
+
+		select {
+		case ext.partitionChannel <- height:
+		default:
 		}
 
-		//ext.validatorService.GetUpdateStakesJobChannel() <- height
 		ext.validatorService.GetUpdateValidatorsJobChannel() <- height
-		ext.validatorService.GetClearJobChannel() <- height
 
 		eet.Total = time.Since(start)
 		ext.printSpentTimeLog(eet)
@@ -362,13 +590,13 @@ func (ext *Extender) runWorkers() {
 		go ext.transactionService.SaveTxValidatorWorker(ext.transactionService.GetSaveTxValidatorJobChannel())
 	}
 	go ext.validatorService.UpdateValidatorsWorker(ext.validatorService.GetUpdateValidatorsJobChannel())
-
-	//go ext.validatorService.UpdateStakesWorker(ext.validatorService.GetUpdateStakesJobChannel())
+	go ext.validatorService.UpdateBipValueWorker(ext.validatorService.GetUpdateBipValueJobChannel())
 
 	// Events
-	for w := 1; w <= ext.env.WrkSaveRewardsCount; w++ {
-		go ext.eventService.SaveRewardsWorker(ext.eventService.GetSaveRewardsJobChannel())
-	}
+	go ext.eventHandler.Run()
+	// one worker merges queued blocks into one upsert, concurrent workers would update the same rows
+	// and wait on each other's locks. A block is added to the sums once, see aggregated_reward_blocks
+	go ext.eventService.SaveRewardsWorker(ext.eventService.GetSaveRewardsJobChannel())
 	for w := 1; w <= ext.env.WrkSaveSlashesCount; w++ {
 		go ext.eventService.SaveSlashesWorker(ext.eventService.GetSaveSlashesJobChannel())
 	}
@@ -386,12 +614,25 @@ func (ext *Extender) runWorkers() {
 	//Move Stake
 	go ext.validatorService.MoveStakeWorker(ext.validatorService.GetMoveStakeJobChannel())
 
-	go ext.validatorService.ClearMoveStakeAndUnbondWorker(ext.validatorService.GetClearJobChannel())
-
 	//OrderBook
 	go ext.orderBookService.OrderBookWorker(ext.orderBookChannel)
 	go ext.orderBookService.UpdateOrderBookWorker(ext.orderBookService.UpdateOrderChannel())
 
+	//Candles
+	go ext.candleService.CandleWorker(ext.candleChannel)
+
+	//Validator statistics, stakes, locks, governance, multisig and checks
+	for _, h := range ext.blockHandlers {
+		go h.Run()
+	}
+	go ext.stakeService.ReconcileWorker()
+
+	//Partitions
+	go ext.partitionManager.PartitionWorker(ext.partitionChannel)
+
+	//Prices
+	go ext.priceService.UpdatePricesWorker(ext.priceService.GetUpdatePricesJobChannel())
+
 	//Broadcast
 	go ext.broadcastService.Manager()
 }
@@ -425,6 +666,80 @@ func (ext *Extender) handleBlockResponse(response *api_pb.BlockResponse) {
 
 }
 
+// handleBlockStrict Save coins, block, transactions, events and orders of the block in one database transaction.
+// Addresses and validators are saved before it, they don't depend on the block and are read by other workers
+func (ext *Extender) handleBlockStrict(response *api_pb.BlockResponse) {
+	blockTime, err := time.Parse("2006-01-02T15:04:05Z", response.Time)
+	if err != nil {
+		ext.log.Panic(err)
+	}
+
+	ext.handleAddressesFromResponses(response)
+
+	err = ext.validatorService.HandleBlockResponse(response)
+	if err != nil {
+		ext.log.Panic(err)
+	}
+
+	var (
+		blockService *block.Service
+		txList       []*models.Transaction
+		rewards      []*models.Reward
+	)
+	err = ext.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		if len(response.Transactions) > 0 {
+			err := ext.coinService.WithTx(tx).HandleCoinsFromBlock(response)
+			if err != nil {
+				return err
+			}
+		}
+
+		blockService = ext.blockService.WithTx(tx)
+		err := blockService.HandleBlockResponse(response)
+		if err != nil {
+			return err
+		}
+
+		err = ext.saveBlockValidatorLinks(ext.blockRepository.WithTx(tx), response)
+		if err != nil {
+			return err
+		}
+
+		if response.TransactionCount > 0 {
+			txList, err = ext.transactionService.WithTx(tx).SaveTransactions(response.Height, blockTime, response.Transactions)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(response.Events) > 0 {
+			rewards, err = ext.eventService.WithTx(tx).SaveEventResponse(response.Height, response)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(response.Transactions) > 0 {
+			return ext.orderBookService.WithTx(tx).HandleBlock(response)
+		}
+		return nil
+	})
+	if err != nil {
+		ext.log.WithField("block", response.Height).Fatal(err)
+	}
+
+	ext.blockService.SetBlockCache(blockService.GetBlockCache())
+
+	if len(txList) > 0 {
+		ext.transactionService.QueueJobs(response.Transactions, txList)
+		ext.coinService.GetUpdateCoinsFromTxsJobChannel() <- txList
+		ext.broadcastService.TransactionsChannel() <- txList
+	}
+	if len(rewards) > 0 {
+		ext.eventService.SaveRewards(response.Height, blockTime, rewards)
+	}
+}
+
 func (ext *Extender) handleCoinsFromTransactions(block *api_pb.BlockResponse) {
 	if len(block.Transactions) == 0 {
 		return
@@ -454,19 +769,27 @@ func (ext *Extender) handleTransactions(response *api_pb.BlockResponse) {
 	}
 }
 
-func (ext *Extender) handleEventResponse(blockHeight uint64, response *api_pb.BlockResponse) {
+func (ext *Extender) handleEventResponse(response *api_pb.BlockResponse) error {
 	if len(response.Events) > 0 {
 		//Save events
-		err := ext.eventService.HandleEventResponse(blockHeight, response)
+		err := ext.eventService.HandleEventResponse(response.Height, response)
 		if err != nil {
 			ext.log.Fatal(err)
 		}
 	}
+	return nil
 }
 
 func (ext *Extender) linkBlockValidator(response *api_pb.BlockResponse) {
+	err := ext.saveBlockValidatorLinks(ext.blockRepository, response)
+	if err != nil {
+		ext.log.Fatal(err)
+	}
+}
+
+func (ext *Extender) saveBlockValidatorLinks(repository *block.Repository, response *api_pb.BlockResponse) error {
 	if response.Height == 1 {
-		return
+		return nil
 	}
 	var links []*models.BlockValidator
 	for _, v := range response.Validators {
@@ -482,10 +805,7 @@ func (ext *Extender) linkBlockValidator(response *api_pb.BlockResponse) {
 		}
 		links = append(links, &link)
 	}
-	err := ext.blockRepository.LinkWithValidators(links)
-	if err != nil {
-		ext.log.Fatal(err)
-	}
+	return repository.LinkWithValidators(links)
 }
 
 func (ext *Extender) saveTransactions(blockHeight uint64, blockCreatedAt time.Time, transactions []*api_pb.TransactionResponse) {
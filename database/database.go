@@ -0,0 +1,37 @@
+package database
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/env"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"os"
+)
+
+// Connect Open connection to the explorer database
+func Connect(env *env.ExtenderEnvironment) *pg.DB {
+	pgOptions := &pg.Options{
+		Addr:     fmt.Sprintf("%s:%s", env.DbHost, env.DbPort),
+		User:     env.DbUser,
+		Password: env.DbPassword,
+		Database: env.DbName,
+	}
+	if os.Getenv("POSTGRES_SSL_ENABLED") == "true" {
+		pgOptions.TLSConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
+	}
+	return pg.Connect(pgOptions)
+}
+
+// RunInTransaction Run fn in the transaction if db is already one, otherwise start a new transaction
+func RunInTransaction(db orm.DB, fn func(tx *pg.Tx) error) error {
+	switch conn := db.(type) {
+	case *pg.Tx:
+		return fn(conn)
+	case *pg.DB:
+		return conn.RunInTransaction(db.Context(), fn)
+	}
+	return fmt.Errorf("transactions are not supported by %T", db)
+}
@@ -0,0 +1,259 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/go-pg/pg/v10"
+	"github.com/sirupsen/logrus"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+var createTable = regexp.MustCompile(`(?i)create table (?:if not exists )?(\w+)`)
+
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type SchemaMigration struct {
+	Version   uint64    `pg:",pk"`
+	Name      string    `pg:",use_zero"`
+	AppliedAt time.Time `pg:"default:now()"`
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *pg.DB
+	migrations []Migration
+	logger     *logrus.Entry
+}
+
+func NewMigrator(db *pg.DB, logger *logrus.Entry) (*Migrator, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger,
+	}, nil
+}
+
+// Up Apply all pending migrations, each one in its own transaction
+func (m *Migrator) Up() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err = m.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+			if _, err := tx.Exec(migration.Up); err != nil {
+				return err
+			}
+			_, err := tx.Model(&SchemaMigration{Version: migration.Version, Name: migration.Name}).Insert()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %06d_%s: %s", migration.Version, migration.Name, err)
+		}
+		m.logger.Warning(fmt.Sprintf("Migration %06d_%s applied", migration.Version, migration.Name))
+	}
+	return nil
+}
+
+// Down Revert the last applied migration
+func (m *Migrator) Down() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		err = m.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+			if _, err := tx.Exec(migration.Down); err != nil {
+				return err
+			}
+			_, err := tx.Model(&SchemaMigration{Version: migration.Version}).WherePK().Delete()
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %06d_%s: %s", migration.Version, migration.Name, err)
+		}
+		m.logger.Warning(fmt.Sprintf("Migration %06d_%s reverted", migration.Version, migration.Name))
+		return nil
+	}
+	return errors.New("no applied migrations")
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var list []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if sm, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &sm.AppliedAt
+		}
+		list = append(list, status)
+	}
+	return list, nil
+}
+
+// CheckActual Return error if the database schema is behind the binary
+func (m *Migrator) CheckActual() error {
+	list, err := m.Status()
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		if s.AppliedAt == nil {
+			return fmt.Errorf("database schema is out of date, migration %06d_%s is not applied, run `extender migrate up`", s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// applied Return applied migrations.
+// Databases created before migrations were introduced already have the baseline schema, so it is marked as applied
+// when every table of the baseline exists. A database with only a part of them must be fixed by hand
+func (m *Migrator) applied() (map[uint64]SchemaMigration, error) {
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+			version    bigint  NOT NULL primary key,
+			name       varchar NOT NULL,
+			applied_at timestamp with time zone NOT NULL DEFAULT now()
+		);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []SchemaMigration
+	err = m.db.Model(&list).Order("version").Select()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 && len(m.migrations) > 0 {
+		baseline, err := m.adoptBaseline(m.migrations[0])
+		if err != nil {
+			return nil, err
+		}
+		if baseline != nil {
+			list = append(list, *baseline)
+		}
+	}
+
+	result := make(map[uint64]SchemaMigration)
+	for _, sm := range list {
+		result[sm.Version] = sm
+	}
+	return result, nil
+}
+
+// adoptBaseline Mark the baseline migration as applied if all its tables exist.
+// Return nil if none of them exist, the baseline is applied as usual then
+func (m *Migrator) adoptBaseline(baseline Migration) (*SchemaMigration, error) {
+	tables := baselineTables(baseline)
+	var missing []string
+	_, err := m.db.Query(&missing, `SELECT t FROM unnest(?::text[]) t WHERE to_regclass('public.' || t) IS NULL`, pg.Array(tables))
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) == len(tables) {
+		return nil, nil
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("database is partially initialised, tables of migration %06d_%s are missing: %s",
+			baseline.Version, baseline.Name, strings.Join(missing, ", "))
+	}
+
+	sm := &SchemaMigration{Version: baseline.Version, Name: baseline.Name, AppliedAt: time.Now()}
+	_, err = m.db.Model(sm).Insert()
+	if err != nil {
+		return nil, err
+	}
+	m.logger.Warning(fmt.Sprintf("Existing schema found, migration %06d_%s marked as applied", sm.Version, sm.Name))
+	return sm, nil
+}
+
+// baselineTables Return names of tables created by the migration
+func baselineTables(migration Migration) []string {
+	var tables []string
+	for _, match := range createTable.FindAllStringSubmatch(migration.Up, -1) {
+		tables = append(tables, strings.ToLower(match[1]))
+	}
+	return tables
+}
+
+func loadMigrations() ([]Migration, error) {
+	files, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, f := range files {
+		match := migrationFileName.FindStringSubmatch(f.Name())
+		if match == nil {
+			return nil, fmt.Errorf("wrong migration file name %s", f.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		data, err := migrationFiles.ReadFile("migrations/" + f.Name())
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	var list []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %06d_%s must have both up and down files", migration.Version, migration.Name)
+		}
+		list = append(list, *migration)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
@@ -0,0 +1,27 @@
+DROP TABLE IF EXISTS moved_stakes;
+DROP TABLE IF EXISTS token_contracts;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS validator_bans;
+DROP TABLE IF EXISTS liquidity_pool_trades;
+DROP TABLE IF EXISTS transaction_liquidity_pool;
+DROP TABLE IF EXISTS address_liquidity_pools;
+DROP TABLE IF EXISTS liquidity_pools;
+DROP TABLE IF EXISTS checks;
+DROP TABLE IF EXISTS unbonds;
+DROP TABLE IF EXISTS stakes;
+DROP TABLE IF EXISTS slashes;
+DROP TABLE IF EXISTS aggregated_rewards;
+DROP TABLE IF EXISTS index_transaction_by_address;
+DROP TABLE IF EXISTS transaction_validator;
+DROP TABLE IF EXISTS transaction_outputs;
+DROP TABLE IF EXISTS invalid_transactions;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS balances;
+DROP TABLE IF EXISTS coins;
+DROP TABLE IF EXISTS block_validator;
+DROP TABLE IF EXISTS blocks;
+DROP TABLE IF EXISTS validator_public_keys;
+DROP TABLE IF EXISTS validators;
+DROP TABLE IF EXISTS addresses;
+DROP TYPE IF EXISTS rewards_role;
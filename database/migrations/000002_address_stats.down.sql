@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS address_stats_transactions;
+DROP TABLE IF EXISTS address_fees;
+DROP TABLE IF EXISTS address_counterparties;
+DROP TABLE IF EXISTS address_stats;
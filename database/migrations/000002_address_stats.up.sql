@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS address_stats
+(
+    address_id           bigint         NOT NULL primary key references addresses (id) on delete cascade,
+    first_block_id       bigint         NOT NULL,
+    last_block_id        bigint         NOT NULL,
+    sent_count           bigint         NOT NULL DEFAULT 0,
+    received_count       bigint         NOT NULL DEFAULT 0,
+    counterparties_count bigint         NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS address_counterparties
+(
+    address_id      bigint NOT NULL references addresses (id) on delete cascade,
+    counterparty_id bigint NOT NULL references addresses (id) on delete cascade,
+    unique (address_id, counterparty_id)
+);
+
+-- commissions paid by address in every gas coin
+CREATE TABLE IF NOT EXISTS address_fees
+(
+    address_id bigint         NOT NULL references addresses (id) on delete cascade,
+    coin_id    integer        NOT NULL references coins (id) on delete cascade,
+    value      numeric(70, 0) NOT NULL DEFAULT 0,
+    unique (address_id, coin_id)
+);
+
+-- transactions added to address_stats, a replayed or reindexed block is added exactly once
+CREATE TABLE IF NOT EXISTS address_stats_transactions
+(
+    block_id bigint                NOT NULL,
+    hash     character varying(64) NOT NULL,
+    PRIMARY KEY (block_id, hash)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS coin_history_daily;
+DROP TABLE IF EXISTS coin_history;
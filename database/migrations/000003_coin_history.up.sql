@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS coin_history
+(
+    coin_id     integer          NOT NULL references coins (id) on delete cascade,
+    block_id    bigint           NOT NULL,
+    cause       varchar          NOT NULL,
+    old_volume  numeric(70, 0),
+    new_volume  numeric(70, 0),
+    old_reserve numeric(70, 0),
+    new_reserve numeric(70, 0),
+    price       numeric(100, 18),
+    created_at  timestamp with time zone NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS coin_history_coin_id_block_id_cause_index ON coin_history USING btree (coin_id, block_id, cause);
+CREATE INDEX IF NOT EXISTS coin_history_block_id_index ON coin_history USING btree (block_id);
+
+CREATE TABLE IF NOT EXISTS coin_history_daily
+(
+    coin_id       integer          NOT NULL references coins (id) on delete cascade,
+    date          date             NOT NULL,
+    volume        numeric(70, 0),
+    reserve       numeric(70, 0),
+    price         numeric(100, 18),
+    min_price     numeric(100, 18),
+    max_price     numeric(100, 18),
+    changes       integer          NOT NULL DEFAULT 0,
+    last_block_id bigint           NOT NULL,
+    unique (coin_id, date)
+);
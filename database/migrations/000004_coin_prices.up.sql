@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS coin_prices
+(
+    block_id   bigint           NOT NULL,
+    coin_id    integer          NOT NULL references coins (id) on delete cascade,
+    price_bip  numeric(100, 18) NOT NULL,
+    price_usd  numeric(100, 18),
+    source     varchar(16)      NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (coin_id, block_id)
+);
+CREATE INDEX IF NOT EXISTS coin_prices_block_id_index ON coin_prices USING btree (block_id);
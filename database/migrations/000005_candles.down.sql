@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS coin_candles;
+DROP TABLE IF EXISTS pool_candles;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS pool_candles
+(
+    liquidity_pool_id integer          NOT NULL references liquidity_pools (id) on delete cascade,
+    period            varchar(4)       NOT NULL,
+    start_at          timestamp with time zone NOT NULL,
+    open              numeric(100, 18) NOT NULL,
+    high              numeric(100, 18) NOT NULL,
+    low               numeric(100, 18) NOT NULL,
+    close             numeric(100, 18) NOT NULL,
+    first_volume      numeric(100, 0)  NOT NULL,
+    second_volume     numeric(100, 0)  NOT NULL,
+    trades_count      integer          NOT NULL DEFAULT 0,
+    last_block_id     bigint           NOT NULL,
+    PRIMARY KEY (liquidity_pool_id, period, start_at)
+);
+
+CREATE TABLE IF NOT EXISTS coin_candles
+(
+    coin_id       integer          NOT NULL references coins (id) on delete cascade,
+    period        varchar(4)       NOT NULL,
+    start_at      timestamp with time zone NOT NULL,
+    open          numeric(100, 18) NOT NULL,
+    high          numeric(100, 18) NOT NULL,
+    low           numeric(100, 18) NOT NULL,
+    close         numeric(100, 18) NOT NULL,
+    volume        numeric(70, 0)   NOT NULL,
+    volume_bip    numeric(70, 0)   NOT NULL,
+    trades_count  integer          NOT NULL DEFAULT 0,
+    last_block_id bigint           NOT NULL,
+    PRIMARY KEY (coin_id, period, start_at)
+);
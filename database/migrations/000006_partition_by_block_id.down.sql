@@ -0,0 +1,32 @@
+-- Move rows of all partitions back into the legacy table and make it a regular table again
+CREATE FUNCTION unpartition_by_block_id(tbl text, pk text) RETURNS void AS
+$$
+BEGIN
+    EXECUTE format('ALTER TABLE %I DETACH PARTITION %I', tbl, tbl || '_legacy');
+    EXECUTE format('ALTER TABLE %I DROP CONSTRAINT %I', tbl || '_legacy', tbl || '_legacy_block_id_check');
+    EXECUTE format('INSERT INTO %I SELECT * FROM %I', tbl || '_legacy', tbl);
+    IF pk IS NOT NULL THEN
+        EXECUTE format('ALTER SEQUENCE %I OWNED BY %I.%I', tbl || '_' || pk || '_seq', tbl || '_legacy', pk);
+    END IF;
+    EXECUTE format('DROP TABLE %I', tbl);
+    EXECUTE format('ALTER TABLE %I RENAME TO %I', tbl || '_legacy', tbl);
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT unpartition_by_block_id('transactions', 'id');
+SELECT unpartition_by_block_id('transaction_outputs', 'id');
+SELECT unpartition_by_block_id('index_transaction_by_address', NULL);
+SELECT unpartition_by_block_id('block_validator', NULL);
+SELECT unpartition_by_block_id('events', NULL);
+SELECT unpartition_by_block_id('slashes', 'id');
+
+DROP FUNCTION unpartition_by_block_id(text, text);
+
+ALTER TABLE transaction_outputs DROP COLUMN block_id;
+
+ALTER TABLE transaction_outputs ADD FOREIGN KEY (transaction_id) REFERENCES transactions (id) ON DELETE CASCADE;
+ALTER TABLE transaction_validator ADD FOREIGN KEY (transaction_id) REFERENCES transactions (id) ON DELETE CASCADE;
+ALTER TABLE index_transaction_by_address ADD FOREIGN KEY (transaction_id) REFERENCES transactions (id) ON DELETE CASCADE;
+ALTER TABLE checks ADD FOREIGN KEY (transaction_id) REFERENCES transactions (id) ON DELETE CASCADE;
+ALTER TABLE transaction_liquidity_pool ADD FOREIGN KEY (transaction_id) REFERENCES transactions (id) ON DELETE CASCADE;
+ALTER TABLE liquidity_pool_trades ADD FOREIGN KEY (transaction_id) REFERENCES transactions (id) ON DELETE CASCADE;
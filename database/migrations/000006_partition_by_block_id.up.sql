@@ -0,0 +1,94 @@
+-- Partitioned transactions are unique only by (id, block_id), so foreign keys to transactions (id) can't be kept.
+-- They are not restored as (transaction_id, block_id) keys either: transaction_validator, checks,
+-- transaction_liquidity_pool and liquidity_pool_trades don't store the block of the transaction,
+-- so orphaned rows of these tables are possible when transactions are deleted. transaction_outputs and
+-- index_transaction_by_address have block_id, but a key to a partitioned table can't be added NOT VALID
+-- and checking all existing rows is the full scan this migration avoids
+ALTER TABLE transaction_outputs DROP CONSTRAINT IF EXISTS transaction_outputs_transaction_id_fkey;
+ALTER TABLE transaction_validator DROP CONSTRAINT IF EXISTS transaction_validator_transaction_id_fkey;
+ALTER TABLE index_transaction_by_address DROP CONSTRAINT IF EXISTS index_transaction_by_address_transaction_id_fkey;
+ALTER TABLE checks DROP CONSTRAINT IF EXISTS checks_transaction_id_fkey;
+ALTER TABLE transaction_liquidity_pool DROP CONSTRAINT IF EXISTS transaction_liquidity_pool_transaction_id_fkey;
+ALTER TABLE liquidity_pool_trades DROP CONSTRAINT IF EXISTS liquidity_pool_trades_transaction_id_fkey;
+
+-- A column with a constant default is added without rewriting the table. Existing outputs get block_id 0,
+-- which stays in the legacy partition; `extender -fill-output-blocks` sets their blocks in batches afterwards
+ALTER TABLE transaction_outputs ADD COLUMN block_id bigint NOT NULL DEFAULT 0;
+ALTER TABLE transaction_outputs ALTER COLUMN block_id DROP DEFAULT;
+
+-- Turn the table into a partitioned one keeping all existing rows in the <table>_legacy partition.
+-- The legacy partition ends after the last block of bound_tbl, the table itself when it is NULL.
+-- The check constraint lets ATTACH PARTITION skip the full table scan
+CREATE FUNCTION partition_by_block_id(tbl text, pk text, bound_tbl text) RETURNS void AS
+$$
+DECLARE
+    upper_bound bigint;
+BEGIN
+    EXECUTE format('ALTER TABLE %I RENAME TO %I', tbl, tbl || '_legacy');
+    EXECUTE format('SELECT coalesce(max(block_id), 0) + 1 FROM %I', coalesce(bound_tbl, tbl || '_legacy')) INTO upper_bound;
+    EXECUTE format('CREATE TABLE %I (LIKE %I INCLUDING DEFAULTS) PARTITION BY RANGE (block_id)', tbl, tbl || '_legacy');
+    IF pk IS NOT NULL THEN
+        EXECUTE format('ALTER TABLE %I ADD PRIMARY KEY (%I, block_id)', tbl, pk);
+        EXECUTE format('ALTER SEQUENCE %I OWNED BY %I.%I', tbl || '_' || pk || '_seq', tbl, pk);
+    END IF;
+    EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I CHECK (block_id < %s) NOT VALID', tbl || '_legacy', tbl || '_legacy_block_id_check', upper_bound);
+    EXECUTE format('ALTER TABLE %I VALIDATE CONSTRAINT %I', tbl || '_legacy', tbl || '_legacy_block_id_check');
+    EXECUTE format('ALTER TABLE %I ATTACH PARTITION %I FOR VALUES FROM (MINVALUE) TO (%s)', tbl, tbl || '_legacy', upper_bound);
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT partition_by_block_id('transactions', 'id', NULL);
+CREATE INDEX ON transactions USING btree (block_id DESC, from_address_id);
+CREATE INDEX ON transactions USING btree (from_address_id);
+CREATE INDEX ON transactions USING hash (hash);
+CREATE INDEX ON transactions USING gin (tags jsonb_path_ops);
+CREATE INDEX ON transactions USING btree (((tags ->> 'tx.order_id'):: int));
+
+-- block_id of existing outputs isn't known yet, they belong to blocks of the legacy transactions
+SELECT partition_by_block_id('transaction_outputs', 'id', 'transactions_legacy');
+CREATE INDEX ON transaction_outputs USING btree (coin_id);
+CREATE INDEX ON transaction_outputs USING btree (transaction_id);
+CREATE INDEX ON transaction_outputs USING btree (to_address_id);
+
+SELECT partition_by_block_id('index_transaction_by_address', NULL, NULL);
+ALTER TABLE index_transaction_by_address ADD UNIQUE (address_id, transaction_id, block_id);
+CREATE INDEX ON index_transaction_by_address USING btree (transaction_id);
+CREATE INDEX ON index_transaction_by_address USING btree (block_id, address_id);
+
+SELECT partition_by_block_id('block_validator', NULL, NULL);
+CREATE INDEX ON block_validator USING btree (block_id);
+CREATE INDEX ON block_validator USING btree (validator_id);
+
+SELECT partition_by_block_id('events', NULL, NULL);
+CREATE INDEX ON events USING btree (block_id);
+CREATE INDEX ON events USING btree (type);
+
+SELECT partition_by_block_id('slashes', 'id', NULL);
+CREATE INDEX ON slashes USING btree (address_id);
+CREATE INDEX ON slashes USING btree (block_id);
+CREATE INDEX ON slashes USING btree (coin_id);
+CREATE INDEX ON slashes USING btree (validator_id);
+
+DROP FUNCTION partition_by_block_id(text, text, text);
+
+-- Foreign keys of partitioned tables to regular tables. The <table>_legacy partitions reuse their equal constraints,
+-- so existing rows are not checked again
+ALTER TABLE transactions ADD FOREIGN KEY (from_address_id) REFERENCES addresses (id) ON DELETE CASCADE;
+ALTER TABLE transactions ADD FOREIGN KEY (block_id) REFERENCES blocks (id) ON DELETE CASCADE;
+ALTER TABLE transactions ADD FOREIGN KEY (gas_coin_id) REFERENCES coins (id) ON DELETE CASCADE;
+
+ALTER TABLE transaction_outputs ADD FOREIGN KEY (to_address_id) REFERENCES addresses (id) ON DELETE CASCADE;
+ALTER TABLE transaction_outputs ADD FOREIGN KEY (coin_id) REFERENCES coins (id) ON DELETE CASCADE;
+
+ALTER TABLE index_transaction_by_address ADD FOREIGN KEY (block_id) REFERENCES blocks (id) ON DELETE CASCADE;
+ALTER TABLE index_transaction_by_address ADD FOREIGN KEY (address_id) REFERENCES addresses (id) ON DELETE CASCADE;
+
+ALTER TABLE block_validator ADD FOREIGN KEY (block_id) REFERENCES blocks (id) ON DELETE CASCADE;
+ALTER TABLE block_validator ADD FOREIGN KEY (validator_id) REFERENCES validators (id) ON DELETE CASCADE;
+
+ALTER TABLE events ADD FOREIGN KEY (block_id) REFERENCES blocks (id) ON DELETE CASCADE;
+
+ALTER TABLE slashes ADD FOREIGN KEY (address_id) REFERENCES addresses (id) ON DELETE CASCADE;
+ALTER TABLE slashes ADD FOREIGN KEY (block_id) REFERENCES blocks (id) ON DELETE CASCADE;
+ALTER TABLE slashes ADD FOREIGN KEY (validator_id) REFERENCES validators (id) ON DELETE CASCADE;
+ALTER TABLE slashes ADD FOREIGN KEY (coin_id) REFERENCES coins (id) ON DELETE CASCADE;
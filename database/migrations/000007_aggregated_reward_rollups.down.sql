@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS aggregated_reward_blocks;
+DROP TABLE IF EXISTS aggregated_reward_rollups;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS aggregated_reward_rollups
+(
+    period        varchar(5)               NOT NULL,
+    time_id       timestamp with time zone NOT NULL,
+    address_id    bigint                   NOT NULL references addresses (id) on delete cascade,
+    validator_id  integer                  NOT NULL references validators (id) on delete cascade,
+    role          rewards_role             NOT NULL,
+    from_block_id integer                  NOT NULL,
+    to_block_id   integer                  NOT NULL,
+    amount        numeric(70, 0)           NOT NULL,
+    PRIMARY KEY (period, time_id, address_id, validator_id, role)
+);
+CREATE INDEX IF NOT EXISTS aggregated_reward_rollups_address_id_index ON aggregated_reward_rollups USING btree (address_id, period, time_id);
+CREATE INDEX IF NOT EXISTS aggregated_reward_rollups_validator_id_index ON aggregated_reward_rollups USING btree (validator_id, period, time_id);
+
+-- blocks whose rewards are added to aggregated_rewards and aggregated_reward_rollups,
+-- a replayed block or a block which comes after a later one is added exactly once
+CREATE TABLE IF NOT EXISTS aggregated_reward_blocks
+(
+    block_id   bigint                   NOT NULL PRIMARY KEY,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+-- the last aggregated block is replayed after a restart
+INSERT INTO aggregated_reward_blocks (block_id)
+SELECT max(to_block_id) FROM aggregated_rewards HAVING max(to_block_id) IS NOT NULL
+ON CONFLICT DO NOTHING;
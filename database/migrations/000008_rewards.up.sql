@@ -0,0 +1,11 @@
+-- Raw rewards are written only with APP_REWARDS_RAW=1, partitions are created by the extender
+CREATE TABLE IF NOT EXISTS rewards
+(
+    block_id     bigint         NOT NULL,
+    address_id   bigint         NOT NULL,
+    validator_id integer        NOT NULL,
+    role         rewards_role   NOT NULL,
+    amount       numeric(70, 0) NOT NULL,
+    PRIMARY KEY (block_id, address_id, validator_id, role)
+) PARTITION BY RANGE (block_id);
+CREATE INDEX IF NOT EXISTS rewards_address_id_block_id_index ON rewards USING btree (address_id, block_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS validator_daily_uptime;
+DROP TABLE IF EXISTS validator_stats;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS validator_stats
+(
+    validator_id          integer NOT NULL PRIMARY KEY references validators (id) on delete cascade,
+    signed_blocks         bigint  NOT NULL DEFAULT 0,
+    missed_blocks         bigint  NOT NULL DEFAULT 0,
+    proposed_blocks       bigint  NOT NULL DEFAULT 0,
+    missed_in_window      integer NOT NULL DEFAULT 0,
+    miss_streak           integer NOT NULL DEFAULT 0,
+    max_miss_streak       integer NOT NULL DEFAULT 0,
+    jails_count           integer NOT NULL DEFAULT 0,
+    last_jailed_block_id  bigint,
+    jailed_until_block_id bigint,
+    last_block_id         bigint  NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS validator_daily_uptime
+(
+    validator_id    integer       NOT NULL references validators (id) on delete cascade,
+    date            date          NOT NULL,
+    signed_blocks   integer       NOT NULL DEFAULT 0,
+    missed_blocks   integer       NOT NULL DEFAULT 0,
+    proposed_blocks integer       NOT NULL DEFAULT 0,
+    uptime          numeric(5, 2) NOT NULL DEFAULT 0,
+    last_block_id   bigint        NOT NULL,
+    PRIMARY KEY (validator_id, date)
+);
+CREATE INDEX IF NOT EXISTS validator_daily_uptime_date_index ON validator_daily_uptime USING btree (date);
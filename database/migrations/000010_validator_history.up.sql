@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS validator_history
+(
+    id                 bigserial                NOT NULL PRIMARY KEY,
+    validator_id       integer                  NOT NULL references validators (id) on delete cascade,
+    block_id           bigint                   NOT NULL,
+    source             varchar(16)              NOT NULL,
+    transaction_hash   varchar(64),
+    changes            text[]                   NOT NULL,
+    public_key         varchar(64)              NOT NULL,
+    status             integer,
+    commission         integer,
+    total_stake        numeric(70, 0),
+    owner_address_id   bigint,
+    control_address_id bigint,
+    reward_address_id  bigint,
+    created_at         timestamp with time zone NOT NULL DEFAULT current_timestamp
+);
+CREATE INDEX IF NOT EXISTS validator_history_validator_id_block_id_index ON validator_history USING btree (validator_id, block_id);
+CREATE INDEX IF NOT EXISTS validator_history_changes_index ON validator_history USING gin (changes);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS stakes_block_id_index;
+ALTER TABLE stakes DROP COLUMN IF EXISTS block_id;
@@ -0,0 +1,2 @@
+ALTER TABLE stakes ADD COLUMN IF NOT EXISTS block_id bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS stakes_block_id_index ON stakes USING btree (block_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS moved_stakes_pending_block_id_index;
+ALTER TABLE moved_stakes DROP COLUMN IF EXISTS transaction_hash;
+ALTER TABLE moved_stakes DROP COLUMN IF EXISTS status;
+ALTER TABLE moved_stakes DROP COLUMN IF EXISTS id;
+
+DROP INDEX IF EXISTS unbonds_pending_block_id_index;
+ALTER TABLE unbonds DROP COLUMN IF EXISTS transaction_hash;
+ALTER TABLE unbonds DROP COLUMN IF EXISTS status;
+ALTER TABLE unbonds DROP COLUMN IF EXISTS id;
@@ -0,0 +1,9 @@
+ALTER TABLE unbonds ADD COLUMN IF NOT EXISTS id bigserial PRIMARY KEY;
+ALTER TABLE unbonds ADD COLUMN IF NOT EXISTS status varchar(16) NOT NULL DEFAULT 'pending';
+ALTER TABLE unbonds ADD COLUMN IF NOT EXISTS transaction_hash varchar(64);
+CREATE INDEX IF NOT EXISTS unbonds_pending_block_id_index ON unbonds USING btree (block_id) WHERE status = 'pending';
+
+ALTER TABLE moved_stakes ADD COLUMN IF NOT EXISTS id bigserial PRIMARY KEY;
+ALTER TABLE moved_stakes ADD COLUMN IF NOT EXISTS status varchar(16) NOT NULL DEFAULT 'pending';
+ALTER TABLE moved_stakes ADD COLUMN IF NOT EXISTS transaction_hash varchar(64);
+CREATE INDEX IF NOT EXISTS moved_stakes_pending_block_id_index ON moved_stakes USING btree (block_id) WHERE status = 'pending';
@@ -0,0 +1,4 @@
+ALTER TABLE stakes DROP COLUMN IF EXISTS locked_until_block_id;
+ALTER TABLE stakes DROP COLUMN IF EXISTS is_locked;
+
+DROP TABLE IF EXISTS locks;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS locks
+(
+    id               bigserial      NOT NULL PRIMARY KEY,
+    type             varchar(8)     NOT NULL,
+    owner_address_id bigint         NOT NULL references addresses (id) on delete cascade,
+    coin_id          integer references coins (id) on delete restrict,
+    value            numeric(70, 0),
+    due_block_id     bigint         NOT NULL,
+    block_id         bigint         NOT NULL,
+    transaction_hash varchar(64)    NOT NULL UNIQUE,
+    status           varchar(16)    NOT NULL DEFAULT 'locked'
+);
+CREATE INDEX IF NOT EXISTS locks_owner_address_id_index ON locks USING btree (owner_address_id);
+CREATE INDEX IF NOT EXISTS locks_locked_due_block_id_index ON locks USING btree (due_block_id) WHERE status = 'locked';
+
+ALTER TABLE stakes ADD COLUMN IF NOT EXISTS is_locked boolean NOT NULL DEFAULT false;
+ALTER TABLE stakes ADD COLUMN IF NOT EXISTS locked_until_block_id bigint;
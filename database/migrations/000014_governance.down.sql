@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS commission_history;
+
+DROP VIEW IF EXISTS halt_block_vote_tallies;
+DROP VIEW IF EXISTS update_vote_tallies;
+DROP VIEW IF EXISTS commission_vote_tallies;
+
+DROP TABLE IF EXISTS halt_block_votes;
+DROP TABLE IF EXISTS update_votes;
+DROP TABLE IF EXISTS commission_votes;
@@ -0,0 +1,65 @@
+CREATE TABLE IF NOT EXISTS commission_votes
+(
+    id               bigserial      NOT NULL PRIMARY KEY,
+    height           bigint         NOT NULL,
+    validator_id     integer        NOT NULL references validators (id) on delete cascade,
+    address_id       bigint         NOT NULL references addresses (id) on delete cascade,
+    coin_id          integer        NOT NULL,
+    prices           jsonb          NOT NULL,
+    stake            numeric(70, 0) NOT NULL,
+    stake_block_id   bigint,
+    block_id         bigint         NOT NULL,
+    transaction_hash varchar(64)    NOT NULL UNIQUE
+);
+CREATE INDEX IF NOT EXISTS commission_votes_height_index ON commission_votes USING btree (height);
+
+CREATE TABLE IF NOT EXISTS update_votes
+(
+    id               bigserial      NOT NULL PRIMARY KEY,
+    height           bigint         NOT NULL,
+    validator_id     integer        NOT NULL references validators (id) on delete cascade,
+    address_id       bigint         NOT NULL references addresses (id) on delete cascade,
+    version          varchar(64)    NOT NULL,
+    stake            numeric(70, 0) NOT NULL,
+    stake_block_id   bigint,
+    block_id         bigint         NOT NULL,
+    transaction_hash varchar(64)    NOT NULL UNIQUE
+);
+CREATE INDEX IF NOT EXISTS update_votes_height_index ON update_votes USING btree (height);
+
+CREATE TABLE IF NOT EXISTS halt_block_votes
+(
+    id               bigserial      NOT NULL PRIMARY KEY,
+    height           bigint         NOT NULL,
+    validator_id     integer        NOT NULL references validators (id) on delete cascade,
+    address_id       bigint         NOT NULL references addresses (id) on delete cascade,
+    stake            numeric(70, 0) NOT NULL,
+    stake_block_id   bigint,
+    block_id         bigint         NOT NULL,
+    transaction_hash varchar(64)    NOT NULL UNIQUE
+);
+CREATE INDEX IF NOT EXISTS halt_block_votes_height_index ON halt_block_votes USING btree (height);
+
+-- only the last vote of a validator for a height is counted
+CREATE OR REPLACE VIEW commission_vote_tallies AS
+SELECT height, coin_id, prices, count(*) AS votes, sum(stake) AS stake
+FROM (SELECT DISTINCT ON (height, validator_id) * FROM commission_votes ORDER BY height, validator_id, block_id DESC) v
+GROUP BY height, coin_id, prices;
+
+CREATE OR REPLACE VIEW update_vote_tallies AS
+SELECT height, version, count(*) AS votes, sum(stake) AS stake
+FROM (SELECT DISTINCT ON (height, validator_id) * FROM update_votes ORDER BY height, validator_id, block_id DESC) v
+GROUP BY height, version;
+
+CREATE OR REPLACE VIEW halt_block_vote_tallies AS
+SELECT height, count(*) AS votes, sum(stake) AS stake
+FROM (SELECT DISTINCT ON (height, validator_id) * FROM halt_block_votes ORDER BY height, validator_id, block_id DESC) v
+GROUP BY height;
+
+CREATE TABLE IF NOT EXISTS commission_history
+(
+    block_id   bigint                   NOT NULL PRIMARY KEY,
+    coin_id    integer                  NOT NULL,
+    prices     jsonb                    NOT NULL,
+    created_at timestamp with time zone NOT NULL
+);
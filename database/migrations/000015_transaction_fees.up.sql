@@ -0,0 +1,22 @@
+-- partitions are created by the extender
+CREATE TABLE IF NOT EXISTS transaction_fees
+(
+    block_id                 bigint         NOT NULL,
+    transaction_hash         varchar(64)    NOT NULL,
+    type                     smallint       NOT NULL,
+    failed                   boolean        NOT NULL,
+    commission_block_id      bigint         NOT NULL,
+    price_coin_id            integer        NOT NULL,
+    base_commission          numeric(70, 0) NOT NULL,
+    payload_commission       numeric(70, 0) NOT NULL,
+    gas_price                integer        NOT NULL,
+    commission_in_price_coin numeric(70, 0) NOT NULL,
+    commission_in_base_coin  numeric(70, 0) NOT NULL,
+    gas_coin_id              integer        NOT NULL,
+    gas_coin_amount          numeric(70, 0) NOT NULL,
+    conversion               varchar(16)    NOT NULL,
+    pool_id                  bigint,
+    PRIMARY KEY (block_id, transaction_hash)
+) PARTITION BY RANGE (block_id);
+CREATE INDEX IF NOT EXISTS transaction_fees_transaction_hash_index ON transaction_fees USING btree (transaction_hash);
+CREATE INDEX IF NOT EXISTS transaction_fees_commission_block_id_index ON transaction_fees USING btree (commission_block_id);
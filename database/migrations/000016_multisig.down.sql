@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS multisig_signers;
+DROP TABLE IF EXISTS multisig_owners;
+DROP TABLE IF EXISTS multisig_wallets;
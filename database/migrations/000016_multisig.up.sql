@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS multisig_wallets
+(
+    address_id       bigint      NOT NULL references addresses (id) on delete cascade,
+    threshold        integer     NOT NULL,
+    from_block_id    bigint      NOT NULL,
+    to_block_id      bigint,
+    transaction_hash varchar(64) NOT NULL,
+    PRIMARY KEY (address_id, from_block_id)
+);
+CREATE INDEX IF NOT EXISTS multisig_wallets_current_index ON multisig_wallets USING btree (address_id) WHERE to_block_id IS NULL;
+
+CREATE TABLE IF NOT EXISTS multisig_owners
+(
+    multisig_address_id bigint  NOT NULL,
+    from_block_id       bigint  NOT NULL,
+    owner_address_id    bigint  NOT NULL references addresses (id) on delete cascade,
+    weight              integer NOT NULL,
+    PRIMARY KEY (multisig_address_id, from_block_id, owner_address_id),
+    FOREIGN KEY (multisig_address_id, from_block_id) REFERENCES multisig_wallets (address_id, from_block_id) on delete cascade
+);
+CREATE INDEX IF NOT EXISTS multisig_owners_owner_address_id_index ON multisig_owners USING btree (owner_address_id);
+
+CREATE TABLE IF NOT EXISTS multisig_signers
+(
+    block_id            bigint      NOT NULL,
+    transaction_hash    varchar(64) NOT NULL,
+    multisig_address_id bigint      NOT NULL,
+    signer_address_id   bigint      NOT NULL,
+    weight              integer,
+    PRIMARY KEY (transaction_hash, signer_address_id)
+);
+CREATE INDEX IF NOT EXISTS multisig_signers_multisig_address_id_index ON multisig_signers USING btree (multisig_address_id, block_id);
+CREATE INDEX IF NOT EXISTS multisig_signers_signer_address_id_index ON multisig_signers USING btree (signer_address_id, block_id);
@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS check_redeem_attempts;
+
+DELETE FROM checks WHERE transaction_id IS NULL OR to_address_id IS NULL;
+DROP INDEX IF EXISTS checks_issued_due_block_index;
+DROP INDEX IF EXISTS checks_data_index;
+CREATE INDEX IF NOT EXISTS checks_check_index ON checks USING btree (data);
+ALTER TABLE checks DROP COLUMN IF EXISTS status;
+ALTER TABLE checks DROP COLUMN IF EXISTS block_id;
+ALTER TABLE checks DROP COLUMN IF EXISTS gas_amount;
+ALTER TABLE checks DROP COLUMN IF EXISTS gas_coin_id;
+ALTER TABLE checks DROP COLUMN IF EXISTS value;
+ALTER TABLE checks DROP COLUMN IF EXISTS coin_id;
+ALTER TABLE checks DROP COLUMN IF EXISTS due_block;
+ALTER TABLE checks DROP COLUMN IF EXISTS chain_id;
+ALTER TABLE checks DROP COLUMN IF EXISTS nonce;
+ALTER TABLE checks ALTER COLUMN to_address_id SET NOT NULL;
+ALTER TABLE checks ALTER COLUMN transaction_id SET NOT NULL;
+ALTER TABLE checks DROP COLUMN IF EXISTS id;
@@ -0,0 +1,31 @@
+-- checks saved twice by a replayed block are kept once
+DELETE FROM checks a USING checks b WHERE a.ctid > b.ctid AND a.data = b.data;
+
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS id bigserial PRIMARY KEY;
+ALTER TABLE checks ALTER COLUMN transaction_id DROP NOT NULL;
+ALTER TABLE checks ALTER COLUMN to_address_id DROP NOT NULL;
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS nonce varchar(64);
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS chain_id smallint;
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS due_block bigint;
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS coin_id integer;
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS value numeric(70, 0);
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS gas_coin_id integer;
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS gas_amount numeric(70, 0);
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS block_id bigint;
+ALTER TABLE checks ADD COLUMN IF NOT EXISTS status varchar(16) NOT NULL DEFAULT 'redeemed';
+DROP INDEX IF EXISTS checks_check_index;
+CREATE UNIQUE INDEX IF NOT EXISTS checks_data_index ON checks USING btree (data);
+CREATE INDEX IF NOT EXISTS checks_issued_due_block_index ON checks USING btree (due_block) WHERE status = 'issued';
+
+CREATE TABLE IF NOT EXISTS check_redeem_attempts
+(
+    id                  bigserial   NOT NULL PRIMARY KEY,
+    check_id            bigint      NOT NULL references checks (id) on delete cascade,
+    block_id            bigint      NOT NULL,
+    transaction_hash    varchar(64) NOT NULL UNIQUE,
+    redeemer_address_id bigint      NOT NULL references addresses (id) on delete cascade,
+    code                integer     NOT NULL,
+    log                 text        NOT NULL,
+    already_redeemed    boolean     NOT NULL
+);
+CREATE INDEX IF NOT EXISTS check_redeem_attempts_check_id_index ON check_redeem_attempts USING btree (check_id);
@@ -0,0 +1,9 @@
+DROP VIEW IF EXISTS invalid_transaction_stats;
+
+DROP INDEX IF EXISTS invalid_transactions_code_created_at_index;
+ALTER TABLE invalid_transactions DROP COLUMN IF EXISTS error_available_value;
+ALTER TABLE invalid_transactions DROP COLUMN IF EXISTS error_required_value;
+ALTER TABLE invalid_transactions DROP COLUMN IF EXISTS error_coin_id;
+ALTER TABLE invalid_transactions DROP COLUMN IF EXISTS error_info;
+ALTER TABLE invalid_transactions DROP COLUMN IF EXISTS error_message;
+ALTER TABLE invalid_transactions DROP COLUMN IF EXISTS code;
@@ -0,0 +1,20 @@
+ALTER TABLE invalid_transactions ADD COLUMN IF NOT EXISTS code integer;
+ALTER TABLE invalid_transactions ADD COLUMN IF NOT EXISTS error_message text;
+ALTER TABLE invalid_transactions ADD COLUMN IF NOT EXISTS error_info jsonb;
+ALTER TABLE invalid_transactions ADD COLUMN IF NOT EXISTS error_coin_id integer;
+ALTER TABLE invalid_transactions ADD COLUMN IF NOT EXISTS error_required_value numeric(70, 0);
+ALTER TABLE invalid_transactions ADD COLUMN IF NOT EXISTS error_available_value numeric(70, 0);
+CREATE INDEX IF NOT EXISTS invalid_transactions_code_created_at_index ON invalid_transactions USING btree (code, created_at);
+
+-- failures per UTC day, error code and transaction type
+CREATE OR REPLACE VIEW invalid_transaction_stats AS
+SELECT (created_at AT TIME ZONE 'UTC')::date AS date,
+       code,
+       type,
+       count(*)                        AS count,
+       count(DISTINCT from_address_id) AS addresses,
+       min(block_id)                   AS first_block_id,
+       max(block_id)                   AS last_block_id
+FROM invalid_transactions
+WHERE code IS NOT NULL
+GROUP BY 1, 2, 3;
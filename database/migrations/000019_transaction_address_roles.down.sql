@@ -0,0 +1,3 @@
+DELETE FROM index_transaction_by_address
+WHERE NOT roles && ARRAY['sender', 'recipient', 'check_issuer']::varchar(32)[];
+ALTER TABLE index_transaction_by_address DROP COLUMN IF EXISTS roles;
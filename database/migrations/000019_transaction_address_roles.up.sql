@@ -0,0 +1,2 @@
+-- roles of rows indexed before are filled by `extender -fill-address-roles` in block range batches
+ALTER TABLE index_transaction_by_address ADD COLUMN IF NOT EXISTS roles varchar(32)[];
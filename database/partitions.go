@@ -0,0 +1,185 @@
+package database
+
+import (
+	"fmt"
+	"github.com/go-pg/pg/v10"
+	"github.com/sirupsen/logrus"
+	"regexp"
+	"strconv"
+)
+
+// PartitionedTables Tables partitioned by range of block_id
+var PartitionedTables = []string{
+	"transactions",
+	"transaction_outputs",
+	"index_transaction_by_address",
+	"block_validator",
+	"events",
+	"slashes",
+	"transaction_fees",
+}
+
+var partitionUpperBound = regexp.MustCompile(`TO \('?(\d+)'?\)`)
+
+type partition struct {
+	name       string
+	upperBound uint64
+}
+
+type PartitionManager struct {
+	db          *pg.DB
+	size        uint64
+	ahead       uint64
+	tables      []string
+	retention   map[string]uint64
+	upperBounds map[string]uint64
+	expireAt    map[string]uint64
+	logger      *logrus.Entry
+}
+
+func NewPartitionManager(db *pg.DB, size, ahead uint64, logger *logrus.Entry) *PartitionManager {
+	return &PartitionManager{
+		db:          db,
+		size:        size,
+		ahead:       ahead,
+		tables:      PartitionedTables,
+		retention:   make(map[string]uint64),
+		upperBounds: make(map[string]uint64),
+		expireAt:    make(map[string]uint64),
+		logger:      logger,
+	}
+}
+
+// AddTable Manage partitions of an optional table. Partitions which end more than retentionBlocks
+// below the indexed height are dropped, zero retention keeps all of them
+func (m *PartitionManager) AddTable(table string, retentionBlocks uint64) {
+	m.tables = append(m.tables[:len(m.tables):len(m.tables)], table)
+	if retentionBlocks > 0 {
+		m.retention[table] = retentionBlocks
+	}
+}
+
+// PartitionWorker Keep partitions created ahead of the indexed height
+func (m *PartitionManager) PartitionWorker(heights <-chan uint64) {
+	for height := range heights {
+		err := m.EnsurePartitions(height)
+		if err != nil {
+			m.logger.Error(err)
+		}
+		err = m.DropExpiredPartitions(height)
+		if err != nil {
+			m.logger.Error(err)
+		}
+	}
+}
+
+// EnsurePartitions Create partitions of all tables up to height + size * ahead.
+// New partitions start at the current upper bound so they never overlap the legacy one
+func (m *PartitionManager) EnsurePartitions(height uint64) error {
+	target := height + m.size*m.ahead
+	for _, table := range m.tables {
+		bound, ok := m.upperBounds[table]
+		if ok && bound > target {
+			continue
+		}
+		partitions, err := m.getPartitions(table)
+		if err != nil {
+			return err
+		}
+		// a table without partitions starts at the indexed height instead of the first block
+		bound = height - height%m.size
+		for i, p := range partitions {
+			if i == 0 || p.upperBound > bound {
+				bound = p.upperBound
+			}
+		}
+		for bound <= target {
+			name := fmt.Sprintf("%s_%d_%d", table, bound, bound+m.size)
+			_, err = m.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%d) TO (%d);`,
+				name, table, bound, bound+m.size))
+			if err != nil {
+				return err
+			}
+			m.logger.Warning(fmt.Sprintf("Partition %s created", name))
+			bound += m.size
+		}
+		m.upperBounds[table] = bound
+	}
+	return nil
+}
+
+// DropExpiredPartitions Drop partitions of tables with retention which end below height - retention.
+// Partitions are listed only when the oldest one expires, new partitions always end later
+func (m *PartitionManager) DropExpiredPartitions(height uint64) error {
+	for table, retention := range m.retention {
+		if height <= retention {
+			continue
+		}
+		if expireAt, ok := m.expireAt[table]; ok && height < expireAt {
+			continue
+		}
+		partitions, err := m.getPartitions(table)
+		if err != nil {
+			return err
+		}
+		// without partitions left the next one ends at least a partition size later
+		expireAt := height + m.size
+		for _, p := range partitions {
+			if p.upperBound > height-retention {
+				if p.upperBound+retention < expireAt {
+					expireAt = p.upperBound + retention
+				}
+				continue
+			}
+			_, err = m.db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s;`, p.name))
+			if err != nil {
+				return err
+			}
+			m.logger.Warning(fmt.Sprintf("Partition %s dropped", p.name))
+		}
+		m.expireAt[table] = expireAt
+	}
+	return nil
+}
+
+// getPartitions Return partitions of the table with their block_id upper bounds
+func (m *PartitionManager) getPartitions(table string) ([]partition, error) {
+	var rows []struct {
+		Name  string
+		Bound string
+	}
+	_, err := m.db.Query(&rows, `
+		SELECT c.relname AS name, pg_get_expr(c.relpartbound, c.oid) AS bound
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = ?::regclass;
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	var partitions []partition
+	for _, row := range rows {
+		bound, ok, err := parseUpperBound(row.Bound)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		partitions = append(partitions, partition{name: row.Name, upperBound: bound})
+	}
+	return partitions, nil
+}
+
+// parseUpperBound Return the upper bound of the partition bound expression, false for the default partition
+func parseUpperBound(bound string) (uint64, bool, error) {
+	match := partitionUpperBound.FindStringSubmatch(bound)
+	if match == nil {
+		return 0, false, nil
+	}
+	v, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return v, true, nil
+}
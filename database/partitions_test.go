@@ -0,0 +1,32 @@
+package database
+
+import "testing"
+
+func TestParseUpperBound(t *testing.T) {
+	tests := []struct {
+		name    string
+		bound   string
+		want    uint64
+		ok      bool
+		wantErr bool
+	}{
+		{"quoted", "FOR VALUES FROM ('1000000') TO ('2000000')", 2000000, true, false},
+		{"unquoted", "FOR VALUES FROM (0) TO (1000000)", 1000000, true, false},
+		{"legacy from minvalue", "FOR VALUES FROM (MINVALUE) TO ('4000000')", 4000000, true, false},
+		{"to maxvalue", "FOR VALUES FROM ('4000000') TO (MAXVALUE)", 0, false, false},
+		{"default", "DEFAULT", 0, false, false},
+		{"empty", "", 0, false, false},
+		{"overflow", "FOR VALUES FROM (0) TO (99999999999999999999)", 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseUpperBound(tt.bound)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseUpperBound(%q) error = %v, wantErr %v", tt.bound, err, tt.wantErr)
+			}
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("parseUpperBound(%q) = %d, %v, want %d, %v", tt.bound, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
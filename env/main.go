@@ -4,6 +4,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type ExtenderEnvironment struct {
@@ -35,7 +36,20 @@ type ExtenderEnvironment struct {
 	WrkUpdateTxsIndexNumBlocks      int
 	WrkUpdateTxsIndexTime           int
 	RewardAggregateEveryBlocksCount uint64
-	RewardAggregateTimeInterval     string
+	RewardAggregateTimeIntervals    []string
+	UsdCoinId                       uint64
+	PartitionSizeBlocks             uint64
+	PartitionsAhead                 uint64
+	BulkCopyTables                  []string
+	StrictBlockMode                 bool
+	CacheSize                       int
+	CacheTTLMinutes                 int
+	CacheWarmUp                     bool
+	RewardsRaw                      bool
+	RewardsRetentionBlocks          uint64
+	ValidatorStatsWindow            uint64
+	ValidatorMissStreakAlert        uint64
+	StakesReconcileBlocks           uint64
 }
 
 func New() *ExtenderEnvironment {
@@ -118,8 +132,80 @@ func New() *ExtenderEnvironment {
 		logger.Fatal(err)
 	}
 
+	var usdCoinId int64
+	if os.Getenv("APP_USD_COIN_ID") != "" {
+		usdCoinId, err = strconv.ParseInt(os.Getenv("APP_USD_COIN_ID"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	var partitionSizeBlocks int64 = 1000000
+	if os.Getenv("APP_PARTITION_SIZE_BLOCKS") != "" {
+		partitionSizeBlocks, err = strconv.ParseInt(os.Getenv("APP_PARTITION_SIZE_BLOCKS"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+	var partitionsAhead int64 = 2
+	if os.Getenv("APP_PARTITIONS_AHEAD") != "" {
+		partitionsAhead, err = strconv.ParseInt(os.Getenv("APP_PARTITIONS_AHEAD"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	var cacheSize int64 = 100000
+	if os.Getenv("APP_CACHE_SIZE") != "" {
+		cacheSize, err = strconv.ParseInt(os.Getenv("APP_CACHE_SIZE"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+	var cacheTTLMinutes int64 = 60
+	if os.Getenv("APP_CACHE_TTL_MINUTES") != "" {
+		cacheTTLMinutes, err = strconv.ParseInt(os.Getenv("APP_CACHE_TTL_MINUTES"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	var rewardsRetentionBlocks int64
+	if os.Getenv("APP_REWARDS_RETENTION_BLOCKS") != "" {
+		rewardsRetentionBlocks, err = strconv.ParseInt(os.Getenv("APP_REWARDS_RETENTION_BLOCKS"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	var validatorStatsWindow int64 = 1000
+	if os.Getenv("APP_VALIDATOR_STATS_WINDOW") != "" {
+		validatorStatsWindow, err = strconv.ParseInt(os.Getenv("APP_VALIDATOR_STATS_WINDOW"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+	var validatorMissStreakAlert int64 = 10
+	if os.Getenv("APP_VALIDATOR_MISS_STREAK_ALERT") != "" {
+		validatorMissStreakAlert, err = strconv.ParseInt(os.Getenv("APP_VALIDATOR_MISS_STREAK_ALERT"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	var stakesReconcileBlocks int64 = 720
+	if os.Getenv("APP_STAKES_RECONCILE_BLOCKS") != "" {
+		stakesReconcileBlocks, err = strconv.ParseInt(os.Getenv("APP_STAKES_RECONCILE_BLOCKS"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
 	envData := new(ExtenderEnvironment)
 	envData.Debug = os.Getenv("EXTENDER_DEBUG") == "1"
+	envData.StrictBlockMode = os.Getenv("APP_STRICT_BLOCK_MODE") == "1"
+	envData.CacheWarmUp = os.Getenv("APP_CACHE_WARMUP") == "1"
+	envData.RewardsRaw = os.Getenv("APP_REWARDS_RAW") == "1"
 	envData.BaseCoin = os.Getenv("MINTER_BASE_COIN")
 	envData.DbHost = os.Getenv("DB_HOST")
 	envData.DbPort = os.Getenv("DB_PORT")
@@ -129,7 +215,6 @@ func New() *ExtenderEnvironment {
 	envData.NodeApi = os.Getenv("NODE_GRPC")
 	envData.WsLink = os.Getenv("CENTRIFUGO_LINK")
 	envData.WsKey = os.Getenv("CENTRIFUGO_SECRET")
-	envData.RewardAggregateTimeInterval = os.Getenv("APP_REWARDS_TIME_INTERVAL")
 	envData.TxChunkSize = int(txChunkSize)
 	envData.EventsChunkSize = int(eventsChunkSize)
 	envData.WrkSaveTxsCount = int(wrkSaveTxsCount)
@@ -148,5 +233,25 @@ func New() *ExtenderEnvironment {
 	envData.WrkUpdateTxsIndexTime = int(wrkUpdateTxsIndexTime)
 	envData.RewardAggregateEveryBlocksCount = uint64(rewardAggregateEveryBlocksCount)
 	envData.ApiPort = int(extenderApiPort)
+	envData.UsdCoinId = uint64(usdCoinId)
+	envData.PartitionSizeBlocks = uint64(partitionSizeBlocks)
+	envData.PartitionsAhead = uint64(partitionsAhead)
+	envData.CacheSize = int(cacheSize)
+	envData.CacheTTLMinutes = int(cacheTTLMinutes)
+	envData.RewardsRetentionBlocks = uint64(rewardsRetentionBlocks)
+	envData.ValidatorStatsWindow = uint64(validatorStatsWindow)
+	envData.ValidatorMissStreakAlert = uint64(validatorMissStreakAlert)
+	envData.StakesReconcileBlocks = uint64(stakesReconcileBlocks)
+	// the daily rollup read by the API is always kept, APP_REWARDS_TIME_INTERVAL adds other periods
+	envData.RewardAggregateTimeIntervals = []string{"day"}
+	for _, period := range strings.Split(os.Getenv("APP_REWARDS_TIME_INTERVAL"), ",") {
+		period = strings.TrimSpace(period)
+		if period != "" && period != "day" {
+			envData.RewardAggregateTimeIntervals = append(envData.RewardAggregateTimeIntervals, period)
+		}
+	}
+	if os.Getenv("APP_BULK_COPY_TABLES") != "" {
+		envData.BulkCopyTables = strings.Split(os.Getenv("APP_BULK_COPY_TABLES"), ",")
+	}
 	return envData
 }
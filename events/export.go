@@ -0,0 +1,95 @@
+package events
+
+import (
+	"encoding/csv"
+	"github.com/MinterTeam/minter-explorer-extender/v2/address"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"io"
+	"strconv"
+	"time"
+)
+
+// RewardsReportRow Reward of an address received in one block
+type RewardsReportRow struct {
+	BlockID   uint64
+	CreatedAt time.Time
+	PublicKey string
+	Role      string
+	Amount    string
+}
+
+// GetAddressRewards Return raw rewards of the address for blocks created in [from, to)
+func (r *Repository) GetAddressRewards(addressId uint, from, to time.Time) ([]RewardsReportRow, error) {
+	var blocks struct {
+		FromId uint64
+		ToId   uint64
+	}
+	_, err := r.db.QueryOne(&blocks, `
+		SELECT coalesce(min(id), 0) AS from_id, coalesce(max(id), 0) AS to_id
+		FROM blocks WHERE created_at >= ? AND created_at < ?;
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if blocks.ToId == 0 {
+		return nil, nil
+	}
+
+	// block range is passed as constants, so only partitions of the range are scanned
+	var rows []RewardsReportRow
+	_, err = r.db.Query(&rows, `
+		SELECT r.block_id, b.created_at, v.public_key, r.role, r.amount
+		FROM rewards r
+		JOIN blocks b ON b.id = r.block_id
+		JOIN validators v ON v.id = r.validator_id
+		WHERE r.address_id = ? AND r.block_id BETWEEN ? AND ?
+		ORDER BY r.block_id, v.public_key, r.role;
+	`, addressId, blocks.FromId, blocks.ToId)
+	return rows, err
+}
+
+// RewardsExporter Write per-block rewards of an address as CSV, requires APP_REWARDS_RAW=1
+type RewardsExporter struct {
+	repository        *Repository
+	addressRepository *address.Repository
+}
+
+func NewRewardsExporter(repository *Repository, addressRepository *address.Repository) *RewardsExporter {
+	return &RewardsExporter{
+		repository:        repository,
+		addressRepository: addressRepository,
+	}
+}
+
+// Export Write rewards of the address for blocks created in [from, to), return the number of rows
+func (e *RewardsExporter) Export(w io.Writer, address string, from, to time.Time) (int, error) {
+	addressId, err := e.addressRepository.FindId(helpers.RemovePrefix(address))
+	if err != nil {
+		return 0, err
+	}
+	rows, err := e.repository.GetAddressRewards(addressId, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	writer := csv.NewWriter(w)
+	err = writer.Write([]string{"block", "time", "validator", "role", "amount_pip", "amount"})
+	if err != nil {
+		return 0, err
+	}
+	for _, row := range rows {
+		err = writer.Write([]string{
+			strconv.FormatUint(row.BlockID, 10),
+			row.CreatedAt.UTC().Format(time.RFC3339),
+			"Mp" + row.PublicKey,
+			row.Role,
+			row.Amount,
+			helpers.Pip2BipStr(helpers.StringToBigInt(row.Amount)),
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	writer.Flush()
+	return len(rows), writer.Error()
+}
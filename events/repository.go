@@ -1,38 +1,80 @@
 package events
 
 import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/bulk"
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
 	"github.com/go-pg/pg/v10"
-	"time"
+	"github.com/go-pg/pg/v10/orm"
 )
 
 type Repository struct {
-	db *pg.DB
+	db         orm.DB
+	bulkWriter *bulk.Writer
 }
 
-func NewRepository(db *pg.DB) *Repository {
+func NewRepository(db *pg.DB, bulkWriter *bulk.Writer) *Repository {
 	return &Repository{
-		db: db,
+		db:         db,
+		bulkWriter: bulkWriter,
 	}
 }
 
+// WithTx Return repository which runs queries in the transaction
+func (r *Repository) WithTx(tx *pg.Tx) *Repository {
+	repository := *r
+	repository.db = tx
+	return &repository
+}
+
 func (r *Repository) SaveSlashes(slashes []*models.Slash) error {
 	_, err := r.db.Model(&slashes).Insert()
 	return err
 }
 
-func (r *Repository) GetRewardsByDay(date time.Time) ([]*models.AggregatedReward, error) {
-	var result []*models.AggregatedReward
-	err := r.db.Model(&result).Where("time_id = ?", date.Format("2006-01-02")).Select()
-	return result, err
+// SaveRewards Save raw rewards, rows of a replayed block are skipped
+func (r *Repository) SaveRewards(rewards []*models.Reward) error {
+	_, err := r.db.Model(&rewards).OnConflict("DO NOTHING").Insert()
+	return err
+}
+
+// AddAggregatedBlocks Mark blocks as aggregated and return the ones which weren't marked before.
+// Rewards of a block are added to the sums only when it is marked, so a block is never counted twice
+func (r *Repository) AddAggregatedBlocks(heights []uint64) ([]uint64, error) {
+	var added []uint64
+	_, err := r.db.Query(&added, `
+		INSERT INTO aggregated_reward_blocks (block_id) SELECT unnest(?::bigint[])
+		ON CONFLICT DO NOTHING
+		RETURNING block_id;
+	`, pg.Array(heights))
+	return added, err
 }
 
+// SaveAggregatedRewards Add amounts to daily rewards and widen their block ranges
 func (r *Repository) SaveAggregatedRewards(rewards []*models.AggregatedReward) error {
-	_, err := r.db.Model(&rewards).OnConflict("(time_id, address_id, validator_id, role) DO UPDATE").Insert()
+	_, err := r.db.Model(&rewards).
+		OnConflict("(time_id, address_id, validator_id, role) DO UPDATE").
+		Set("amount = aggregated_reward.amount + EXCLUDED.amount").
+		Set("from_block_id = least(aggregated_reward.from_block_id, EXCLUDED.from_block_id)").
+		Set("to_block_id = greatest(aggregated_reward.to_block_id, EXCLUDED.to_block_id)").
+		Insert()
+	return err
+}
+
+// SaveAggregatedRewardRollups Same as SaveAggregatedRewards for hourly and monthly rollups
+func (r *Repository) SaveAggregatedRewardRollups(rewards []*models.AggregatedRewardRollup) error {
+	_, err := r.db.Model(&rewards).
+		OnConflict("(period, time_id, address_id, validator_id, role) DO UPDATE").
+		Set("amount = aggregated_reward_rollup.amount + EXCLUDED.amount").
+		Set("from_block_id = least(aggregated_reward_rollup.from_block_id, EXCLUDED.from_block_id)").
+		Set("to_block_id = greatest(aggregated_reward_rollup.to_block_id, EXCLUDED.to_block_id)").
+		Insert()
 	return err
 }
 
 func (r *Repository) Add(list []models.Event) error {
+	if r.bulkWriter.IsEnabled(bulk.TableEvents) {
+		return r.bulkWriter.SaveEvents(r.db, list)
+	}
 	_, err := r.db.Model(&list).Insert()
 	return err
 }
@@ -0,0 +1,200 @@
+package events
+
+import (
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"github.com/go-pg/pg/v10"
+	"math/big"
+	"time"
+)
+
+// RewardsJob Rewards of the block with given height
+type RewardsJob struct {
+	Height    uint64
+	CreatedAt time.Time
+	Rewards   []*models.Reward
+}
+
+type rewardBucket struct {
+	period      string
+	timeId      int64
+	addressId   uint
+	validatorId uint64
+	role        string
+}
+
+type rewardTotal struct {
+	timeId    time.Time
+	fromBlock uint64
+	toBlock   uint64
+	amount    *big.Int
+}
+
+func (s *Service) GetSaveRewardsJobChannel() chan *RewardsJob {
+	return s.jobSaveRewards
+}
+
+// SaveRewards Queue rewards of the block for aggregation
+func (s *Service) SaveRewards(height uint64, createdAt time.Time, rewards []*models.Reward) {
+	s.GetSaveRewardsJobChannel() <- &RewardsJob{
+		Height:    height,
+		CreatedAt: createdAt,
+		Rewards:   rewards,
+	}
+}
+
+// SaveRewardsWorker Aggregate rewards into every period from APP_REWARDS_TIME_INTERVAL.
+// Jobs which are already queued are merged into one upsert, up to APP_REWARDS_AGGREGATE_BLOCKS_COUNT blocks
+func (s *Service) SaveRewardsWorker(jobs <-chan *RewardsJob) {
+	for job := range jobs {
+		batch := []*RewardsJob{job}
+	queued:
+		for uint64(len(batch)) < s.env.RewardAggregateEveryBlocksCount {
+			select {
+			case j := <-jobs:
+				batch = append(batch, j)
+			default:
+				break queued
+			}
+		}
+		err := s.aggregateRewards(batch)
+		helpers.HandleError(err)
+	}
+}
+
+func (s *Service) aggregateRewards(batch []*RewardsJob) error {
+	heights := make([]uint64, len(batch))
+	for i, job := range batch {
+		heights[i] = job.Height
+	}
+
+	return database.RunInTransaction(s.repository.db, func(tx *pg.Tx) error {
+		repository := s.repository.WithTx(tx)
+		added, err := repository.AddAggregatedBlocks(heights)
+		if err != nil {
+			return err
+		}
+		// blocks which are already aggregated are skipped
+		pending := make(map[uint64]bool, len(added))
+		for _, height := range added {
+			pending[height] = true
+		}
+		var jobs []*RewardsJob
+		for _, job := range batch {
+			if pending[job.Height] {
+				jobs = append(jobs, job)
+				delete(pending, job.Height)
+			}
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+
+		raw, daily, rollups, err := s.sumRewards(jobs)
+		if err != nil {
+			return err
+		}
+		if len(raw) > 0 {
+			if err := repository.SaveRewards(raw); err != nil {
+				return err
+			}
+		}
+		if len(daily) > 0 {
+			if err := repository.SaveAggregatedRewards(daily); err != nil {
+				return err
+			}
+		}
+		if len(rollups) > 0 {
+			return repository.SaveAggregatedRewardRollups(rollups)
+		}
+		return nil
+	})
+}
+
+// sumRewards Sum rewards of the jobs per period, address, validator and role
+func (s *Service) sumRewards(jobs []*RewardsJob) ([]*models.Reward, []*models.AggregatedReward, []*models.AggregatedRewardRollup, error) {
+	totals := make(map[rewardBucket]*rewardTotal)
+	var raw []*models.Reward
+	for _, job := range jobs {
+		if s.env.RewardsRaw {
+			raw = append(raw, mergeRewards(job.Height, job.Rewards)...)
+		}
+		for _, period := range s.env.RewardAggregateTimeIntervals {
+			timeId, ok := models.RewardPeriodStart(period, job.CreatedAt)
+			if !ok {
+				return nil, nil, nil, fmt.Errorf("unknown rewards period %s", period)
+			}
+			for _, r := range job.Rewards {
+				amount, ok := big.NewInt(0).SetString(r.Amount, 10)
+				if !ok {
+					return nil, nil, nil, fmt.Errorf("wrong reward amount %s at block %d", r.Amount, job.Height)
+				}
+				key := rewardBucket{period, timeId.Unix(), r.AddressID, r.ValidatorID, r.Role}
+				total, exist := totals[key]
+				if !exist {
+					totals[key] = &rewardTotal{timeId: timeId, fromBlock: job.Height, toBlock: job.Height, amount: amount}
+					continue
+				}
+				if job.Height < total.fromBlock {
+					total.fromBlock = job.Height
+				}
+				if job.Height > total.toBlock {
+					total.toBlock = job.Height
+				}
+				total.amount.Add(total.amount, amount)
+			}
+		}
+	}
+
+	var (
+		daily   []*models.AggregatedReward
+		rollups []*models.AggregatedRewardRollup
+	)
+	for key, total := range totals {
+		if key.period == models.RewardPeriodDay {
+			daily = append(daily, &models.AggregatedReward{
+				FromBlockID: total.fromBlock,
+				ToBlockID:   total.toBlock,
+				AddressID:   uint64(key.addressId),
+				ValidatorID: key.validatorId,
+				Role:        key.role,
+				Amount:      total.amount.String(),
+				TimeID:      total.timeId,
+			})
+			continue
+		}
+		rollups = append(rollups, &models.AggregatedRewardRollup{
+			Period:      key.period,
+			TimeID:      total.timeId,
+			AddressID:   uint64(key.addressId),
+			ValidatorID: key.validatorId,
+			Role:        key.role,
+			FromBlockID: total.fromBlock,
+			ToBlockID:   total.toBlock,
+			Amount:      total.amount.String(),
+		})
+	}
+	return raw, daily, rollups, nil
+}
+
+// mergeRewards Sum rewards of the block with the same address, validator and role
+func mergeRewards(height uint64, rewards []*models.Reward) []*models.Reward {
+	var list []*models.Reward
+	merged := make(map[rewardBucket]*models.Reward)
+	for _, r := range rewards {
+		key := rewardBucket{addressId: r.AddressID, validatorId: r.ValidatorID, role: r.Role}
+		reward, ok := merged[key]
+		if !ok {
+			reward = &models.Reward{BlockID: height, AddressID: r.AddressID, ValidatorID: r.ValidatorID, Role: r.Role, Amount: r.Amount}
+			merged[key] = reward
+			list = append(list, reward)
+			continue
+		}
+		total, _ := big.NewInt(0).SetString(reward.Amount, 10)
+		amount, _ := big.NewInt(0).SetString(r.Amount, 10)
+		reward.Amount = total.Add(total, amount).String()
+	}
+	return list
+}
@@ -16,8 +16,8 @@ import (
 	"github.com/MinterTeam/node-grpc-gateway/api_pb"
 	"github.com/go-pg/pg/v10"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
 	"math"
-	"math/big"
 	"strings"
 	"time"
 )
@@ -33,16 +33,20 @@ type Service struct {
 	blockRepository     *block.Repository
 	broadcastService    *broadcast.Service
 	orderRepository     *orderbook.Repository
-	jobSaveRewards      chan []*models.Reward
+	jobSaveRewards      chan *RewardsJob
 	jobSaveSlashes      chan []*models.Slash
-	startBlock          uint64
 	logger              *logrus.Entry
 }
 
 func NewService(env *env.ExtenderEnvironment, repository *Repository, validatorRepository *validator.Repository,
 	addressRepository *address.Repository, coinRepository *coin.Repository, coinService *coin.Service,
 	blockRepository *block.Repository, orderRepository *orderbook.Repository, balanceRepository *balance.Repository, broadcastService *broadcast.Service,
-	logger *logrus.Entry, startBlock uint64) *Service {
+	logger *logrus.Entry) *Service {
+	for _, period := range env.RewardAggregateTimeIntervals {
+		if _, ok := models.RewardPeriodStart(period, time.Now()); !ok {
+			logger.WithField("period", period).Fatal("unknown rewards period")
+		}
+	}
 	return &Service{
 		env:                 env,
 		repository:          repository,
@@ -54,15 +58,75 @@ func NewService(env *env.ExtenderEnvironment, repository *Repository, validatorR
 		blockRepository:     blockRepository,
 		orderRepository:     orderRepository,
 		broadcastService:    broadcastService,
-		startBlock:          startBlock,
-		jobSaveRewards:      make(chan []*models.Reward, env.WrkSaveRewardsCount),
+		jobSaveRewards:      make(chan *RewardsJob, env.WrkSaveRewardsCount),
 		jobSaveSlashes:      make(chan []*models.Slash, env.WrkSaveSlashesCount),
 		logger:              logger,
 	}
 }
 
+// WithTx Return service which saves events in the transaction, job channels are shared
+func (s *Service) WithTx(tx *pg.Tx) *Service {
+	service := *s
+	service.repository = s.repository.WithTx(tx)
+	service.orderRepository = s.orderRepository.WithTx(tx)
+	return &service
+}
+
 // HandleEventResponse Handle response and save block to DB
 func (s *Service) HandleEventResponse(blockHeight uint64, responseEvents *api_pb.BlockResponse) error {
+	eventList, rewards, slashes, err := s.handleEvents(blockHeight, responseEvents)
+	if err != nil {
+		return err
+	}
+
+	if len(eventList) > 0 {
+		err = s.repository.Add(eventList)
+		if err != nil {
+			s.logger.Error(err)
+		}
+	}
+
+	if len(rewards) > 0 {
+		blockTime, err := time.Parse("2006-01-02T15:04:05Z", responseEvents.Time)
+		if err != nil {
+			return err
+		}
+		s.SaveRewards(blockHeight, blockTime, rewards)
+	}
+
+	if len(slashes) > 0 {
+		s.saveSlashes(slashes)
+	}
+
+	return nil
+}
+
+// SaveEventResponse Save events and slashes of the block synchronously.
+// Rewards are returned to be aggregated after the database transaction is committed
+func (s *Service) SaveEventResponse(blockHeight uint64, responseEvents *api_pb.BlockResponse) ([]*models.Reward, error) {
+	eventList, rewards, slashes, err := s.handleEvents(blockHeight, responseEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(eventList) > 0 {
+		err = s.repository.Add(eventList)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(slashes) > 0 {
+		err = s.repository.SaveSlashes(slashes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rewards, nil
+}
+
+func (s *Service) handleEvents(blockHeight uint64, responseEvents *api_pb.BlockResponse) ([]models.Event, []*models.Reward, []*models.Slash, error) {
 	var (
 		eventList         []models.Event
 		rewards           []*models.Reward
@@ -70,15 +134,33 @@ func (s *Service) HandleEventResponse(blockHeight uint64, responseEvents *api_pb
 		coinsForUpdateMap = make(map[uint64]struct{})
 	)
 
-	for _, event := range responseEvents.Events {
+	eventStructs := make([]proto.Message, len(responseEvents.Events))
+	var addresses []string
+	for i, event := range responseEvents.Events {
 		eventStruct, err := event.UnmarshalNew()
 		if err != nil {
-			return err
+			return nil, nil, nil, err
 		}
+		eventStructs[i] = eventStruct
+
+		switch e := eventStruct.(type) {
+		case *api_pb.RewardEvent:
+			addresses = append(addresses, helpers.RemovePrefix(e.Address))
+		case *api_pb.SlashEvent:
+			addresses = append(addresses, helpers.RemovePrefix(e.Address))
+		}
+	}
+	addressIds, err := s.addressRepository.FindIdsOrCreate(addresses)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for i, event := range responseEvents.Events {
+		eventStruct := eventStructs[i]
 
 		jsonEvent, err := json.Marshal(eventStruct)
 		if err != nil {
-			return err
+			return nil, nil, nil, err
 		}
 
 		if !event.MessageIs(&api_pb.RewardEvent{}) && !event.MessageIs(&api_pb.StakeKickEvent{}) {
@@ -91,9 +173,9 @@ func (s *Service) HandleEventResponse(blockHeight uint64, responseEvents *api_pb
 
 		switch e := eventStruct.(type) {
 		case *api_pb.RewardEvent:
-			reward, err := s.handleRewardEvent(blockHeight, e)
+			reward, err := s.handleRewardEvent(blockHeight, e, addressIds)
 			if err != nil {
-				return err
+				return nil, nil, nil, err
 			}
 			rewards = append(rewards, reward)
 
@@ -105,13 +187,7 @@ func (s *Service) HandleEventResponse(blockHeight uint64, responseEvents *api_pb
 				}).Error(err)
 				continue
 			}
-			addressId, err := s.addressRepository.FindId(helpers.RemovePrefix(e.Address))
-			if err != nil {
-				s.logger.WithFields(logrus.Fields{
-					"address": e.Address,
-				}).Error(err)
-				continue
-			}
+			addressId := addressIds[helpers.RemovePrefix(e.Address)]
 
 			validatorId, err := s.validatorRepository.FindIdByPk(helpers.RemovePrefix(e.ValidatorPubKey))
 			if err != nil {
@@ -129,34 +205,10 @@ func (s *Service) HandleEventResponse(blockHeight uint64, responseEvents *api_pb
 				ValidatorID: uint64(validatorId),
 			})
 		case *api_pb.StakeKickEvent:
-			addressId, err := s.addressRepository.FindIdOrCreate(helpers.RemovePrefix(e.Address))
-			if err != nil {
-				s.logger.Error(err)
-				continue
-			}
-
-			vId, err := s.validatorRepository.FindIdByPk(helpers.RemovePrefix(e.ValidatorPubKey))
-			if err != nil {
-				s.logger.Error(err)
-				continue
-			}
-
+			// kicked stakes are moved to the wait list by the stake ledger
 			coinsForUpdateMap[e.Coin] = struct{}{}
-			stk := &models.Stake{
-				OwnerAddressID: addressId,
-				CoinID:         uint(e.Coin),
-				ValidatorID:    vId,
-				Value:          e.Amount,
-				IsKicked:       true,
-				BipValue:       "0",
-			}
-
-			err = s.validatorRepository.UpdateStake(stk)
-			if err != nil {
-				s.logger.WithField("stake", stk).Error(err)
-			}
-		case *api_pb.UnbondEvent:
-			continue
+		case *api_pb.UnbondEvent, *api_pb.StakeMoveEvent:
+			// pending unbonds and moved stakes are completed by the stake ledger at the block of the event
 		case *api_pb.UpdateCommissionsEvent:
 			s.broadcastService.CommissionsChannel() <- e
 		case *api_pb.JailEvent:
@@ -196,124 +248,25 @@ func (s *Service) HandleEventResponse(blockHeight uint64, responseEvents *api_pb
 		}
 	}
 
-	if len(eventList) > 0 {
-		err := s.repository.Add(eventList)
+	if len(coinsForUpdateMap) > 0 {
+		blockTime, err := time.Parse("2006-01-02T15:04:05Z", responseEvents.Time)
 		if err != nil {
 			s.logger.Error(err)
 		}
+		s.coinService.GetUpdateCoinsFromCoinsMapJobChannel() <- &coin.UpdateCoinsJob{
+			Height:    blockHeight,
+			CreatedAt: blockTime,
+			Coins:     coinsForUpdateMap,
+		}
 	}
 
-	if len(coinsForUpdateMap) > 0 {
-		s.coinService.GetUpdateCoinsFromCoinsMapJobChannel() <- coinsForUpdateMap
-	}
-
-	if len(rewards) > 0 {
-		s.saveRewards(rewards)
-	}
-
-	if len(slashes) > 0 {
-		s.saveSlashes(slashes)
-	}
-
-	return nil
-}
-
-func (s *Service) GetSaveRewardsJobChannel() chan []*models.Reward {
-	return s.jobSaveRewards
+	return eventList, rewards, slashes, nil
 }
 
 func (s *Service) GetSaveSlashesJobChannel() chan []*models.Slash {
 	return s.jobSaveSlashes
 }
 
-func (s *Service) SaveRewardsWorker(jobs <-chan []*models.Reward) {
-	for rewards := range jobs {
-		b, err := s.blockRepository.GetById(rewards[0].BlockID)
-		if err != nil {
-			s.logger.Fatal(err)
-		}
-
-		timeId, err := time.Parse("2006-01-02", b.CreatedAt.Format("2006-01-02"))
-		if err != nil {
-			s.logger.Fatal(err)
-		}
-
-		exist, err := s.repository.GetRewardsByDay(timeId)
-		if err != nil && err != pg.ErrNoRows {
-			s.logger.Fatal(err)
-		}
-
-		rewardsMap := map[string][]*models.Reward{}
-		for _, reward := range rewards {
-			key := fmt.Sprintf("%d-%d-%s", reward.AddressID, reward.ValidatorID, reward.Role)
-			rewardsMap[key] = append(rewardsMap[key], reward)
-		}
-
-		if (err != nil && err == pg.ErrNoRows) || len(exist) == 0 {
-			startBlock := rewards[0].BlockID - 120
-			if startBlock < s.startBlock {
-				startBlock = s.startBlock
-			}
-
-			var aggregated []*models.AggregatedReward
-			for _, userRewards := range rewardsMap {
-				total := big.NewInt(0)
-				for _, r := range userRewards {
-					amount, _ := big.NewInt(0).SetString(r.Amount, 10)
-					total.Add(total, amount)
-				}
-
-				aggregated = append(aggregated, &models.AggregatedReward{
-					FromBlockID: startBlock,
-					ToBlockID:   userRewards[0].BlockID,
-					AddressID:   uint64(userRewards[0].AddressID),
-					ValidatorID: userRewards[0].ValidatorID,
-					Role:        userRewards[0].Role,
-					Amount:      total.String(),
-					TimeID:      timeId,
-				})
-			}
-			err = s.repository.SaveAggregatedRewards(aggregated)
-			helpers.HandleError(err)
-			continue
-		}
-
-		existRewardsMap := map[string]*models.AggregatedReward{}
-		for _, reward := range exist {
-			key := fmt.Sprintf("%d-%d-%s", reward.AddressID, reward.ValidatorID, reward.Role)
-			existRewardsMap[key] = reward
-		}
-
-		var aggregated []*models.AggregatedReward
-		for _, userRewards := range rewardsMap {
-			key := fmt.Sprintf("%d-%d-%s", userRewards[0].AddressID, userRewards[0].ValidatorID, userRewards[0].Role)
-			total := big.NewInt(0)
-			for _, r := range userRewards {
-				amount, _ := big.NewInt(0).SetString(r.Amount, 10)
-				total.Add(total, amount)
-			}
-
-			if existRewardsMap[key] != nil {
-				existAmount, _ := big.NewInt(0).SetString(existRewardsMap[key].Amount, 10)
-				total.Add(total, existAmount)
-			}
-
-			aggregated = append(aggregated, &models.AggregatedReward{
-				FromBlockID: userRewards[0].BlockID,
-				ToBlockID:   userRewards[0].BlockID,
-				AddressID:   uint64(userRewards[0].AddressID),
-				ValidatorID: userRewards[0].ValidatorID,
-				Role:        userRewards[0].Role,
-				Amount:      total.String(),
-				TimeID:      timeId,
-			})
-		}
-
-		err = s.repository.SaveAggregatedRewards(aggregated)
-		helpers.HandleError(err)
-	}
-}
-
 func (s *Service) SaveSlashesWorker(jobs <-chan []*models.Slash) {
 	for slashes := range jobs {
 		err := s.repository.SaveSlashes(slashes)
@@ -321,10 +274,6 @@ func (s *Service) SaveSlashesWorker(jobs <-chan []*models.Slash) {
 	}
 }
 
-func (s *Service) saveRewards(rewards []*models.Reward) {
-	s.GetSaveRewardsJobChannel() <- rewards
-}
-
 func (s *Service) saveSlashes(slashes []*models.Slash) {
 	chunksCount := int(math.Ceil(float64(len(slashes)) / float64(s.env.EventsChunkSize)))
 	for i := 0; i < chunksCount; i++ {
@@ -337,14 +286,8 @@ func (s *Service) saveSlashes(slashes []*models.Slash) {
 	}
 }
 
-func (s *Service) handleRewardEvent(blockHeight uint64, e *api_pb.RewardEvent) (*models.Reward, error) {
-	addressId, err := s.addressRepository.FindId(helpers.RemovePrefix(e.Address))
-	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"address": e.Address,
-		}).Error(err)
-		return nil, err
-	}
+func (s *Service) handleRewardEvent(blockHeight uint64, e *api_pb.RewardEvent, addressIds map[string]uint) (*models.Reward, error) {
+	addressId := addressIds[helpers.RemovePrefix(e.Address)]
 
 	validatorId, err := s.validatorRepository.FindIdByPk(helpers.RemovePrefix(e.ValidatorPubKey))
 	if err != nil {
@@ -0,0 +1,188 @@
+package governance
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"math/big"
+	"strings"
+)
+
+// priceKeys Keys of the commission price list with base prices of transaction types
+var priceKeys = map[transaction.Type]string{
+	transaction.TypeSend:                    "send",
+	transaction.TypeSellCoin:                "sellBancor",
+	transaction.TypeSellAllCoin:             "sellAllBancor",
+	transaction.TypeBuyCoin:                 "buyBancor",
+	transaction.TypeCreateCoin:              "createCoin",
+	transaction.TypeDeclareCandidacy:        "declareCandidacy",
+	transaction.TypeDelegate:                "delegate",
+	transaction.TypeUnbond:                  "unbond",
+	transaction.TypeRedeemCheck:             "redeemCheck",
+	transaction.TypeSetCandidateOnline:      "setCandidateOn",
+	transaction.TypeSetCandidateOffline:     "setCandidateOff",
+	transaction.TypeCreateMultisig:          "createMultisig",
+	transaction.TypeMultisend:               "multisendBase",
+	transaction.TypeEditCandidate:           "editCandidate",
+	transaction.TypeSetHaltBlock:            "setHaltBlock",
+	transaction.TypeRecreateCoin:            "recreateCoin",
+	transaction.TypeEditCoinOwner:           "editTickerOwner",
+	transaction.TypeEditMultisig:            "editMultisig",
+	transaction.TypeEditCandidatePublicKey:  "editCandidatePublicKey",
+	transaction.TypeCreateSwapPool:          "createSwapPool",
+	transaction.TypeAddLiquidity:            "addLiquidity",
+	transaction.TypeRemoveLiquidity:         "removeLiquidity",
+	transaction.TypeSellSwapPool:            "sellPoolBase",
+	transaction.TypeBuySwapPool:             "buyPoolBase",
+	transaction.TypeSellAllSwapPool:         "sellAllPoolBase",
+	transaction.TypeEditCommissionCandidate: "editCandidateCommission",
+	transaction.TypeMintToken:               "mintToken",
+	transaction.TypeBurnToken:               "burnToken",
+	transaction.TypeCreateToken:             "createToken",
+	transaction.TypeRecreateToken:           "recreateToken",
+	transaction.TypeVoteCommission:          "voteCommission",
+	transaction.TypeVoteUpdate:              "voteUpdate",
+	transaction.TypeAddLimitOrder:           "addLimitOrder",
+	transaction.TypeRemoveLimitOrder:        "removeLimitOrder",
+	transaction.TypeMoveStake:               "moveStake",
+	transaction.TypeLockStake:               "lockStake",
+	transaction.TypeLock:                    "lock",
+}
+
+// newFee Return the commission breakdown of the transaction by the price list set before the block
+func newFee(height uint64, tx *api_pb.TransactionResponse, commissions *models.CommissionHistory) (*models.TransactionFee, error) {
+	var prices map[string]string
+	if err := json.Unmarshal(commissions.Prices, &prices); err != nil {
+		return nil, err
+	}
+	price := func(key string) (*big.Int, error) {
+		value, ok := big.NewInt(0).SetString(prices[key], 10)
+		if !ok {
+			return nil, fmt.Errorf("can't convert %s price %s to big.Int", key, prices[key])
+		}
+		return value, nil
+	}
+
+	failed := tx.Log != ""
+	base, err := baseCommission(tx, failed, price)
+	if err != nil {
+		return nil, err
+	}
+	payloadByte, err := price("payloadByte")
+	if err != nil {
+		return nil, err
+	}
+	payload := big.NewInt(0).Mul(payloadByte, big.NewInt(int64(len(tx.Payload)+len(tx.ServiceData))))
+	inPriceCoin := big.NewInt(0).Add(base, payload)
+	inPriceCoin.Mul(inPriceCoin, big.NewInt(0).SetUint64(tx.GasPrice))
+
+	tags := tx.GetTags()
+	fee := &models.TransactionFee{
+		BlockID:               height,
+		TransactionHash:       helpers.RemovePrefix(tx.Hash),
+		Type:                  uint8(tx.Type),
+		Failed:                failed,
+		CommissionBlockID:     commissions.BlockID,
+		PriceCoinID:           commissions.CoinID,
+		BaseCommission:        base.String(),
+		PayloadCommission:     payload.String(),
+		GasPrice:              tx.GasPrice,
+		CommissionInPriceCoin: inPriceCoin.String(),
+		CommissionInBaseCoin:  tagValue(tags["tx.commission_in_base_coin"]),
+		GasCoinID:             tx.GasCoin.Id,
+		GasCoinAmount:         tagValue(tags["tx.commission_amount"]),
+		Conversion:            tags["tx.commission_conversion"],
+	}
+	if fee.Conversion == "pool" {
+		var details models.TagCommissionDetails
+		err = json.Unmarshal([]byte(strings.Replace(tags["tx.commission_details"], `\`, "", -1)), &details)
+		if err != nil {
+			return nil, err
+		}
+		fee.PoolID = &details.PoolID
+	}
+	return fee, nil
+}
+
+// baseCommission Return the price of the transaction type in the price coin, without payload and gas price
+func baseCommission(tx *api_pb.TransactionResponse, failed bool, price func(key string) (*big.Int, error)) (*big.Int, error) {
+	if failed {
+		return price("failedTx")
+	}
+	key, ok := priceKeys[transaction.Type(tx.Type)]
+	if !ok {
+		return big.NewInt(0), nil
+	}
+	base, err := price(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// some types also pay for each additional item or for the ticker length
+	var deltaKey string
+	var count int
+	switch transaction.Type(tx.Type) {
+	case transaction.TypeMultisend:
+		txData := new(api_pb.MultiSendData)
+		if err := tx.Data.UnmarshalTo(txData); err != nil {
+			return nil, err
+		}
+		deltaKey, count = "multisendDelta", len(txData.List)-1
+	case transaction.TypeSellSwapPool:
+		txData := new(api_pb.SellSwapPoolData)
+		if err := tx.Data.UnmarshalTo(txData); err != nil {
+			return nil, err
+		}
+		deltaKey, count = "sellPoolDelta", len(txData.Coins)-2
+	case transaction.TypeBuySwapPool:
+		txData := new(api_pb.BuySwapPoolData)
+		if err := tx.Data.UnmarshalTo(txData); err != nil {
+			return nil, err
+		}
+		deltaKey, count = "buyPoolDelta", len(txData.Coins)-2
+	case transaction.TypeSellAllSwapPool:
+		txData := new(api_pb.SellAllSwapPoolData)
+		if err := tx.Data.UnmarshalTo(txData); err != nil {
+			return nil, err
+		}
+		deltaKey, count = "sellAllPoolDelta", len(txData.Coins)-2
+	case transaction.TypeCreateCoin:
+		txData := new(api_pb.CreateCoinData)
+		if err := tx.Data.UnmarshalTo(txData); err != nil {
+			return nil, err
+		}
+		deltaKey, count = tickerKey(txData.Symbol), 1
+	case transaction.TypeCreateToken:
+		txData := new(api_pb.CreateTokenData)
+		if err := tx.Data.UnmarshalTo(txData); err != nil {
+			return nil, err
+		}
+		deltaKey, count = tickerKey(txData.Symbol), 1
+	}
+	if count <= 0 {
+		return base, nil
+	}
+	delta, err := price(deltaKey)
+	if err != nil {
+		return nil, err
+	}
+	return base.Add(base, delta.Mul(delta, big.NewInt(int64(count)))), nil
+}
+
+// tickerKey Return the key of the price of a new ticker with the symbol length
+func tickerKey(symbol string) string {
+	if len(symbol) <= 6 {
+		return fmt.Sprintf("createTicker%d", len(symbol))
+	}
+	return "createTicker710"
+}
+
+func tagValue(value string) string {
+	if value == "" {
+		return "0"
+	}
+	return value
+}
@@ -0,0 +1,96 @@
+package governance
+
+import (
+	"fmt"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"math/big"
+	"testing"
+)
+
+func TestBaseCommission(t *testing.T) {
+	prices := map[string]int64{
+		"failedTx":         1,
+		"send":             10,
+		"multisendBase":    10,
+		"multisendDelta":   5,
+		"sellPoolBase":     100,
+		"sellPoolDelta":    50,
+		"buyPoolBase":      100,
+		"buyPoolDelta":     50,
+		"sellAllPoolBase":  100,
+		"sellAllPoolDelta": 50,
+		"createCoin":       0,
+		"createToken":      0,
+		"createTicker3":    1000000,
+		"createTicker4":    100000,
+		"createTicker5":    10000,
+		"createTicker6":    1000,
+		"createTicker710":  100,
+	}
+	price := func(key string) (*big.Int, error) {
+		p, ok := prices[key]
+		if !ok {
+			return nil, fmt.Errorf("price %s not found", key)
+		}
+		return big.NewInt(p), nil
+	}
+	coins := func(n int) []*api_pb.Coin {
+		list := make([]*api_pb.Coin, n)
+		for i := range list {
+			list[i] = &api_pb.Coin{Id: uint64(i)}
+		}
+		return list
+	}
+	sends := func(n int) []*api_pb.SendData {
+		list := make([]*api_pb.SendData, n)
+		for i := range list {
+			list[i] = &api_pb.SendData{Value: "1"}
+		}
+		return list
+	}
+
+	tests := []struct {
+		name    string
+		txType  transaction.Type
+		data    proto.Message
+		failed  bool
+		want    int64
+		wantErr bool
+	}{
+		{"send", transaction.TypeSend, &api_pb.SendData{Value: "1"}, false, 10, false},
+		{"failed send", transaction.TypeSend, &api_pb.SendData{Value: "1"}, true, 1, false},
+		{"multisend of one", transaction.TypeMultisend, &api_pb.MultiSendData{List: sends(1)}, false, 10, false},
+		{"multisend of three", transaction.TypeMultisend, &api_pb.MultiSendData{List: sends(3)}, false, 20, false},
+		{"sell in one pool", transaction.TypeSellSwapPool, &api_pb.SellSwapPoolData{Coins: coins(2)}, false, 100, false},
+		{"sell by route of four coins", transaction.TypeSellSwapPool, &api_pb.SellSwapPoolData{Coins: coins(4)}, false, 200, false},
+		{"buy by route of three coins", transaction.TypeBuySwapPool, &api_pb.BuySwapPoolData{Coins: coins(3)}, false, 150, false},
+		{"sell all by route of five coins", transaction.TypeSellAllSwapPool, &api_pb.SellAllSwapPoolData{Coins: coins(5)}, false, 250, false},
+		{"coin with 3 letters", transaction.TypeCreateCoin, &api_pb.CreateCoinData{Symbol: "ABC"}, false, 1000000, false},
+		{"coin with 6 letters", transaction.TypeCreateCoin, &api_pb.CreateCoinData{Symbol: "ABCDEF"}, false, 1000, false},
+		{"token with 10 letters", transaction.TypeCreateToken, &api_pb.CreateTokenData{Symbol: "ABCDEFGHIJ"}, false, 100, false},
+		{"unknown type", transaction.Type(0xff), &api_pb.SendData{Value: "1"}, false, 0, false},
+		{"missing price", transaction.TypeDelegate, &api_pb.DelegateData{}, false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := anypb.New(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx := &api_pb.TransactionResponse{Type: uint64(tt.txType), Data: data}
+			got, err := baseCommission(tx, tt.failed, price)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("baseCommission() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Cmp(big.NewInt(tt.want)) != 0 {
+				t.Errorf("baseCommission() = %s, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package governance
+
+import (
+	"context"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+)
+
+type Repository struct {
+	db *pg.DB
+}
+
+func NewRepository(db *pg.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// BlockChanges Votes, commission price lists and transaction fees of a block which are written in one database transaction
+type BlockChanges struct {
+	CommissionVotes []*models.CommissionVote
+	UpdateVotes     []*models.UpdateVote
+	HaltBlockVotes  []*models.HaltBlockVote
+	Commissions     []*models.CommissionHistory
+	Fees            []*models.TransactionFee
+}
+
+func (c *BlockChanges) isEmpty() bool {
+	return len(c.CommissionVotes) == 0 && len(c.UpdateVotes) == 0 && len(c.HaltBlockVotes) == 0 &&
+		len(c.Commissions) == 0 && len(c.Fees) == 0
+}
+
+// SaveBlock Save votes, commission price lists and transaction fees of a block.
+// Rows are unique by transaction hash or block, so a block can be safely handled twice
+func (r *Repository) SaveBlock(c *BlockChanges) error {
+	return r.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		if len(c.CommissionVotes) > 0 {
+			_, err := tx.Model(&c.CommissionVotes).OnConflict("(transaction_hash) DO NOTHING").Insert()
+			if err != nil {
+				return err
+			}
+		}
+		if len(c.UpdateVotes) > 0 {
+			_, err := tx.Model(&c.UpdateVotes).OnConflict("(transaction_hash) DO NOTHING").Insert()
+			if err != nil {
+				return err
+			}
+		}
+		if len(c.HaltBlockVotes) > 0 {
+			_, err := tx.Model(&c.HaltBlockVotes).OnConflict("(transaction_hash) DO NOTHING").Insert()
+			if err != nil {
+				return err
+			}
+		}
+		if len(c.Commissions) > 0 {
+			_, err := tx.Model(&c.Commissions).OnConflict("(block_id) DO NOTHING").Insert()
+			if err != nil {
+				return err
+			}
+		}
+		if len(c.Fees) > 0 {
+			_, err := tx.Model(&c.Fees).OnConflict("(block_id, transaction_hash) DO NOTHING").Insert()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetCommissions Return the commission price list which is in force for transactions of the block
+func (r *Repository) GetCommissions(height uint64) (*models.CommissionHistory, error) {
+	commissions := new(models.CommissionHistory)
+	err := r.db.Model(commissions).
+		Where("block_id < ?", height).
+		Order("block_id DESC").
+		Limit(1).
+		Select()
+	return commissions, err
+}
@@ -0,0 +1,255 @@
+package governance
+
+import (
+	"encoding/json"
+	"github.com/MinterTeam/minter-explorer-extender/v2/address"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-extender/v2/validator"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"github.com/MinterTeam/minter-go-sdk/v2/api/grpc_client"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/go-pg/pg/v10"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+type Service struct {
+	nodeApi             *grpc_client.Client
+	repository          *Repository
+	addressRepository   *address.Repository
+	validatorRepository *validator.Repository
+	commissions         *models.CommissionHistory // price list in force for the next block
+	logger              *logrus.Entry
+}
+
+func NewService(nodeApi *grpc_client.Client, repository *Repository, addressRepository *address.Repository,
+	validatorRepository *validator.Repository, logger *logrus.Entry) *Service {
+	return &Service{
+		nodeApi:             nodeApi,
+		repository:          repository,
+		addressRepository:   addressRepository,
+		validatorRepository: validatorRepository,
+		logger: logger.WithFields(logrus.Fields{
+			"service": "Governance",
+		}),
+	}
+}
+
+// HandleBlock Save votes, commission price lists and transaction fees of the block.
+// Fees depend on the price lists of previous blocks, so blocks must be handled in order
+func (s *Service) HandleBlock(b *api_pb.BlockResponse) error {
+	blockTime, err := time.Parse("2006-01-02T15:04:05Z", b.Time)
+	if err != nil {
+		return err
+	}
+
+	changes := new(BlockChanges)
+	current := s.commissions
+	if len(b.Transactions) > 0 && current == nil {
+		var fromNode bool
+		current, fromNode, err = s.loadCommissions(b.Height, blockTime)
+		if err != nil {
+			return err
+		}
+		if fromNode {
+			changes.Commissions = append(changes.Commissions, current)
+		}
+	}
+
+	for _, tx := range b.Transactions {
+		fee, err := newFee(b.Height, tx, current)
+		if err != nil {
+			return err
+		}
+		changes.Fees = append(changes.Fees, fee)
+
+		// failed votes don't count
+		if tx.Log != "" {
+			continue
+		}
+		switch transaction.Type(tx.Type) {
+		case transaction.TypeVoteCommission:
+			txData := new(api_pb.VoteCommissionData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return err
+			}
+			v, err := s.getVoter(txData.PubKey, tx.From, b.Height)
+			if err != nil {
+				return err
+			}
+			prices, err := pricesJson(txData, "pubKey", "height", "coin")
+			if err != nil {
+				return err
+			}
+			changes.CommissionVotes = append(changes.CommissionVotes, &models.CommissionVote{
+				Height:          txData.Height,
+				ValidatorID:     v.validatorId,
+				AddressID:       v.addressId,
+				CoinID:          txData.Coin.Id,
+				Prices:          prices,
+				Stake:           v.stake,
+				StakeBlockID:    v.stakeBlockId,
+				BlockID:         b.Height,
+				TransactionHash: helpers.RemovePrefix(tx.Hash),
+			})
+		case transaction.TypeVoteUpdate:
+			txData := new(api_pb.VoteUpdateData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return err
+			}
+			v, err := s.getVoter(txData.PubKey, tx.From, b.Height)
+			if err != nil {
+				return err
+			}
+			changes.UpdateVotes = append(changes.UpdateVotes, &models.UpdateVote{
+				Height:          txData.Height,
+				ValidatorID:     v.validatorId,
+				AddressID:       v.addressId,
+				Version:         txData.Version,
+				Stake:           v.stake,
+				StakeBlockID:    v.stakeBlockId,
+				BlockID:         b.Height,
+				TransactionHash: helpers.RemovePrefix(tx.Hash),
+			})
+		case transaction.TypeSetHaltBlock:
+			txData := new(api_pb.SetHaltBlockData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return err
+			}
+			v, err := s.getVoter(txData.PubKey, tx.From, b.Height)
+			if err != nil {
+				return err
+			}
+			changes.HaltBlockVotes = append(changes.HaltBlockVotes, &models.HaltBlockVote{
+				Height:          txData.Height,
+				ValidatorID:     v.validatorId,
+				AddressID:       v.addressId,
+				Stake:           v.stake,
+				StakeBlockID:    v.stakeBlockId,
+				BlockID:         b.Height,
+				TransactionHash: helpers.RemovePrefix(tx.Hash),
+			})
+		}
+	}
+
+	for _, event := range b.Events {
+		if !event.MessageIs(&api_pb.UpdateCommissionsEvent{}) {
+			continue
+		}
+		e := new(api_pb.UpdateCommissionsEvent)
+		if err := event.UnmarshalTo(e); err != nil {
+			return err
+		}
+		prices, err := pricesJson(e, "coin")
+		if err != nil {
+			return err
+		}
+		// the new list is set at the end of the block and applies to the next blocks
+		current = &models.CommissionHistory{
+			BlockID:   b.Height,
+			CoinID:    e.Coin,
+			Prices:    prices,
+			CreatedAt: blockTime,
+		}
+		changes.Commissions = append(changes.Commissions, current)
+	}
+
+	if !changes.isEmpty() {
+		err = s.repository.SaveBlock(changes)
+		if err != nil {
+			return err
+		}
+	}
+	s.commissions = current
+	return nil
+}
+
+// loadCommissions Return the price list in force for transactions of the block.
+// When no list is stored yet it is loaded from node at the previous block and has to be saved
+func (s *Service) loadCommissions(height uint64, blockTime time.Time) (*models.CommissionHistory, bool, error) {
+	commissions, err := s.repository.GetCommissions(height)
+	if err == nil {
+		return commissions, false, nil
+	}
+	if err != pg.ErrNoRows {
+		return nil, false, err
+	}
+	response, err := s.nodeApi.PriceCommission(height - 1)
+	if err != nil {
+		return nil, false, err
+	}
+	prices, err := pricesJson(response, "coin")
+	if err != nil {
+		return nil, false, err
+	}
+	return &models.CommissionHistory{
+		BlockID:   height - 1,
+		CoinID:    response.Coin.Id,
+		Prices:    prices,
+		CreatedAt: blockTime,
+	}, true, nil
+}
+
+// voter Validator which has voted, the address of the vote transaction and the total stake of the validator.
+// StakeBlockID is the block of the stake, it is empty when the stake isn't known at the vote block
+type voter struct {
+	validatorId  uint
+	addressId    uint
+	stake        string
+	stakeBlockId *uint64
+}
+
+// getVoter Return the voter with the total stake of the validator at the vote block from node.
+// If node has no state of the block, the last stored total stake is used
+func (s *Service) getVoter(publicKey, from string, height uint64) (*voter, error) {
+	validatorId, err := s.validatorRepository.FindIdByPk(helpers.RemovePrefix(publicKey))
+	if err != nil {
+		return nil, err
+	}
+	addressId, err := s.addressRepository.FindIdOrCreate(helpers.RemovePrefix(from))
+	if err != nil {
+		return nil, err
+	}
+	v := &voter{validatorId: validatorId, addressId: addressId, stake: "0"}
+
+	candidate, err := s.nodeApi.Candidate(publicKey, height)
+	if err == nil {
+		v.stake = candidate.TotalStake
+		v.stakeBlockId = &height
+		return v, nil
+	}
+	s.logger.WithFields(logrus.Fields{
+		"block":      height,
+		"public_key": publicKey,
+		"error":      err,
+	}).Warning("stake at the vote block is not available from node, the stored one is used")
+
+	validator, err := s.validatorRepository.GetById(validatorId)
+	if err != nil {
+		return nil, err
+	}
+	if validator.TotalStake != nil {
+		v.stake = *validator.TotalStake
+	}
+	return v, nil
+}
+
+// pricesJson Return the price list of a vote or an event without the listed fields,
+// so votes and price lists in force can be compared
+func pricesJson(m proto.Message, exclude ...string) (json.RawMessage, error) {
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var prices map[string]json.RawMessage
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, err
+	}
+	for _, field := range exclude {
+		delete(prices, field)
+	}
+	return json.Marshal(prices)
+}
@@ -77,6 +77,14 @@ func (r *Repository) UpdateLiquidityPoolById(lp *models.LiquidityPool) error {
 	return err
 }
 
+func (r *Repository) UpdateLiquidityBip(pools []models.LiquidityPool) error {
+	_, err := r.db.Model(&pools).
+		Column("liquidity_bip").
+		WherePK().
+		Update()
+	return err
+}
+
 func (r *Repository) LinkAddressLiquidityPool(addressId uint, liquidityPoolId uint64) error {
 	addressLiquidityPool := &models.AddressLiquidityPool{
 		LiquidityPoolId: liquidityPoolId,
@@ -132,6 +140,13 @@ func (r *Repository) GetAll() ([]models.LiquidityPool, error) {
 	return list, err
 }
 
+// GetUpdatedSince Return pools changed since the block
+func (r *Repository) GetUpdatedSince(blockId uint64) ([]models.LiquidityPool, error) {
+	var list []models.LiquidityPool
+	err := r.db.Model(&list).Where("updated_at_block_id >= ?", blockId).Select()
+	return list, err
+}
+
 func (r *Repository) GetLastSnapshot() (*models.LiquidityPoolSnapshot, error) {
 	var lps = new(models.LiquidityPoolSnapshot)
 	err := r.db.Model(lps).Order("block_id desc").Limit(1).Select()
@@ -0,0 +1,59 @@
+package lock
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+)
+
+type Repository struct {
+	db orm.DB
+}
+
+func NewRepository(db *pg.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// WithTx Return repository which runs queries in the transaction
+func (r *Repository) WithTx(tx *pg.Tx) *Repository {
+	return &Repository{
+		db: tx,
+	}
+}
+
+// SaveLocks Save locks of the block, stakes locked by LockStake transactions are flagged by the stake ledger.
+// Locks are unique by transaction hash, so a block can be safely handled twice
+func (r *Repository) SaveLocks(locks []*models.Lock) error {
+	_, err := r.db.Model(&locks).
+		OnConflict("(transaction_hash) DO NOTHING").
+		Insert()
+	return err
+}
+
+// Release Mark locks which are due at the height or earlier as released, clear flags of stakes unlocked by then.
+// Return released locks
+func (r *Repository) Release(height uint64) ([]*models.Lock, error) {
+	var released []*models.Lock
+	err := database.RunInTransaction(r.db, func(tx *pg.Tx) error {
+		_, err := tx.Model(&released).
+			Set("status = ?", models.LockStatusReleased).
+			Where("status = ?", models.LockStatusLocked).
+			Where("due_block_id <= ?", height).
+			Returning("*").
+			Update()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model((*models.Stake)(nil)).
+			Set("is_locked = false").
+			Set("locked_until_block_id = null").
+			Where("is_locked = true").
+			Where("locked_until_block_id <= ?", height).
+			Update()
+		return err
+	})
+	return released, err
+}
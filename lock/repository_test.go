@@ -0,0 +1,166 @@
+package lock
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
+	"github.com/MinterTeam/minter-explorer-extender/v2/env"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+	"os"
+	"testing"
+)
+
+// TestRelease Save locks twice and release them block by block.
+// Needs a migrated database set by DB_* variables, changes are rolled back
+func TestRelease(t *testing.T) {
+	if os.Getenv("DB_NAME") == "" {
+		t.Skip("DB_NAME is not set")
+	}
+	db := database.Connect(env.New())
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	r := NewRepository(db).WithTx(tx)
+
+	// stored locks are due before the test heights, they are released on the first call and not checked
+	var height uint64
+	_, err = tx.QueryOne(pg.Scan(&height), `SELECT coalesce(max(due_block_id), 0) + 1 FROM locks;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addressId uint
+	_, err = tx.QueryOne(pg.Scan(&addressId), `
+		INSERT INTO addresses (address) VALUES (repeat('0', 40))
+		ON CONFLICT (address) DO UPDATE SET address = excluded.address
+		RETURNING id;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stakeId uint
+	_, err = tx.QueryOne(pg.Scan(&stakeId), `
+		WITH v AS (
+			INSERT INTO validators (public_key) VALUES (repeat('0', 64))
+			ON CONFLICT (public_key) DO UPDATE SET public_key = excluded.public_key
+			RETURNING id
+		), c AS (
+			INSERT INTO coins (id, symbol, crr) VALUES (0, 'BIP', 0)
+			ON CONFLICT (id) DO UPDATE SET symbol = coins.symbol
+			RETURNING id
+		)
+		INSERT INTO stakes (owner_address_id, validator_id, coin_id, value, bip_value, is_locked, locked_until_block_id)
+		SELECT ?, v.id, c.id, 1000, 1000, true, ? FROM v, c
+		ON CONFLICT (owner_address_id, validator_id, coin_id, is_kicked)
+		DO UPDATE SET is_locked = excluded.is_locked, locked_until_block_id = excluded.locked_until_block_id
+		RETURNING id;`, addressId, height+10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinId, value := uint64(0), "1000"
+	newLock := func(hash, lockType string, dueBlockId uint64) *models.Lock {
+		l := &models.Lock{
+			Type:            lockType,
+			OwnerAddressID:  addressId,
+			DueBlockID:      dueBlockId,
+			BlockID:         height - 1,
+			TransactionHash: hash,
+			Status:          models.LockStatusLocked,
+		}
+		if lockType == models.LockTypeCoin {
+			l.CoinID, l.Value = &coinId, &value
+		}
+		return l
+	}
+	hashes := []string{"test-coin-5", "test-stake-10", "test-coin-20"}
+	saveLocks := func() {
+		err := r.SaveLocks([]*models.Lock{
+			newLock(hashes[0], models.LockTypeCoin, height+5),
+			newLock(hashes[1], models.LockTypeStake, height+10),
+			newLock(hashes[2], models.LockTypeCoin, height+20),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	saveLocks()
+	// a replayed block doesn't duplicate locks
+	saveLocks()
+	count, err := tx.Model((*models.Lock)(nil)).Where("transaction_hash IN (?)", pg.In(hashes)).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(hashes) {
+		t.Fatalf("saved %d locks, want %d", count, len(hashes))
+	}
+
+	tests := []struct {
+		height      uint64
+		released    []string
+		locked      []string
+		stakeLocked bool
+	}{
+		{height + 4, nil, hashes, true},
+		{height + 5, hashes[:1], hashes[1:], true},
+		// released locks are not released again
+		{height + 5, nil, hashes[1:], true},
+		{height + 10, hashes[1:2], hashes[2:], false},
+		// a skipped height releases everything due by then
+		{height + 30, hashes[2:], nil, false},
+	}
+	for _, tt := range tests {
+		released, err := r.Release(tt.height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := releasedHashes(released, hashes); !equalHashes(got, tt.released) {
+			t.Errorf("released %v at %d, want %v", got, tt.height, tt.released)
+		}
+		var locked []string
+		err = tx.Model((*models.Lock)(nil)).Column("transaction_hash").
+			Where("transaction_hash IN (?)", pg.In(hashes)).
+			Where("status = ?", models.LockStatusLocked).
+			Order("due_block_id").
+			Select(&locked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalHashes(locked, tt.locked) {
+			t.Errorf("locked %v after %d, want %v", locked, tt.height, tt.locked)
+		}
+		stake := &models.Stake{ID: stakeId}
+		err = tx.Model(stake).WherePK().Select()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stake.IsLocked != tt.stakeLocked || (stake.LockedUntilBlockID == nil) == tt.stakeLocked {
+			t.Errorf("stake is locked %v until %v after %d, want locked %v", stake.IsLocked, stake.LockedUntilBlockID, tt.height, tt.stakeLocked)
+		}
+	}
+}
+
+// releasedHashes Return hashes of the released locks which are in hashes
+func releasedHashes(released []*models.Lock, hashes []string) []string {
+	var list []string
+	for _, l := range released {
+		for _, hash := range hashes {
+			if l.TransactionHash == hash {
+				list = append(list, hash)
+			}
+		}
+	}
+	return list
+}
+
+func equalHashes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package lock
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/address"
+	"github.com/MinterTeam/minter-explorer-extender/v2/env"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-extender/v2/validator"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/sirupsen/logrus"
+)
+
+type Service struct {
+	env               *env.ExtenderEnvironment
+	repository        *Repository
+	addressRepository *address.Repository
+	updateBalances    chan<- []string
+	logger            *logrus.Entry
+}
+
+func NewService(env *env.ExtenderEnvironment, repository *Repository, addressRepository *address.Repository,
+	updateBalances chan<- []string, logger *logrus.Entry) *Service {
+	return &Service{
+		env:               env,
+		repository:        repository,
+		addressRepository: addressRepository,
+		updateBalances:    updateBalances,
+		logger: logger.WithFields(logrus.Fields{
+			"service": "Locks",
+		}),
+	}
+}
+
+// HandleBlock Save locks made by transactions of the block and release locks which are due at the block
+func (s *Service) HandleBlock(b *api_pb.BlockResponse) error {
+	locks, err := s.parseLocks(b)
+	if err != nil {
+		return err
+	}
+	if len(locks) > 0 {
+		err = s.repository.SaveLocks(locks)
+		if err != nil {
+			return err
+		}
+	}
+
+	released, err := s.repository.Release(b.Height)
+	if err != nil {
+		return err
+	}
+	var ids []uint
+	for _, l := range released {
+		if l.Type == models.LockTypeCoin {
+			ids = append(ids, l.OwnerAddressID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	// locked values are returned to the balances of owners
+	addresses, err := s.addressRepository.FindAddressesByIds(ids)
+	if err != nil {
+		return err
+	}
+	list := make([]string, 0, len(addresses))
+	for _, adr := range addresses {
+		list = append(list, adr)
+	}
+	s.updateBalances <- list
+	return nil
+}
+
+// parseLocks Return locks made by successful Lock and LockStake transactions of the block
+func (s *Service) parseLocks(b *api_pb.BlockResponse) ([]*models.Lock, error) {
+	locks, addresses, err := newLocks(b, validator.UnbondPeriod(s.env.BaseCoin))
+	if err != nil || len(locks) == 0 {
+		return nil, err
+	}
+
+	addressIds, err := s.addressRepository.FindIdsOrCreate(addresses)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range locks {
+		l.OwnerAddressID = addressIds[addresses[i]]
+	}
+	return locks, nil
+}
+
+// newLocks Return locks of the block without owners and addresses of their owners
+func newLocks(b *api_pb.BlockResponse, unbondPeriod uint64) ([]*models.Lock, []string, error) {
+	var locks []*models.Lock
+	var addresses []string
+	for _, tx := range b.Transactions {
+		if tx.Log != "" {
+			continue
+		}
+		l := &models.Lock{
+			BlockID:         b.Height,
+			TransactionHash: helpers.RemovePrefix(tx.Hash),
+			Status:          models.LockStatusLocked,
+		}
+		switch transaction.Type(tx.Type) {
+		case transaction.TypeLock:
+			txData := new(api_pb.LockData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return nil, nil, err
+			}
+			coinId := txData.Coin.Id
+			value := txData.Value
+			l.Type = models.LockTypeCoin
+			l.CoinID = &coinId
+			l.Value = &value
+			l.DueBlockID = txData.DueBlock
+		case transaction.TypeLockStake:
+			// the node doesn't return the unlock height, stakes are locked for the unbond period of the network
+			l.Type = models.LockTypeStake
+			l.DueBlockID = b.Height + unbondPeriod
+		default:
+			continue
+		}
+		locks = append(locks, l)
+		addresses = append(addresses, helpers.RemovePrefix(tx.From))
+	}
+	return locks, addresses, nil
+}
@@ -0,0 +1,74 @@
+package lock
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"testing"
+)
+
+const testAddress = "Mx7633980c000139dd3bd24a3f54e06474fa941e16"
+
+func TestNewLocks(t *testing.T) {
+	anyData := func(m proto.Message) *anypb.Any {
+		data, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	const unbondPeriod = 518400
+	b := &api_pb.BlockResponse{
+		Height: 100,
+		Transactions: []*api_pb.TransactionResponse{
+			{Hash: "Mt01", Type: uint64(transaction.TypeLock), From: testAddress, Data: anyData(&api_pb.LockData{DueBlock: 150, Coin: &api_pb.Coin{Id: 1}, Value: "1000"})},
+			{Hash: "Mt02", Type: uint64(transaction.TypeLockStake), From: testAddress, Data: anyData(&api_pb.LockStakeData{})},
+			// failed locks and other transactions don't lock anything
+			{Hash: "Mt03", Type: uint64(transaction.TypeLock), From: testAddress, Data: anyData(&api_pb.LockData{DueBlock: 150, Coin: &api_pb.Coin{Id: 1}, Value: "5000"}), Log: "Insufficient funds"},
+			{Hash: "Mt04", Type: uint64(transaction.TypeSend), From: testAddress, Data: anyData(&api_pb.SendData{Coin: &api_pb.Coin{Id: 0}, Value: "1"})},
+		},
+	}
+
+	locks, addresses, err := newLocks(b, unbondPeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locks) != 2 || len(addresses) != 2 {
+		t.Fatalf("parsed %d locks of %d owners, want 2 and 2", len(locks), len(addresses))
+	}
+
+	tests := []struct {
+		hash       string
+		lockType   string
+		coinId     *uint64
+		value      *string
+		dueBlockId uint64
+	}{
+		{"01", models.LockTypeCoin, uint64Ptr(1), stringPtr("1000"), 150},
+		{"02", models.LockTypeStake, nil, nil, 100 + unbondPeriod},
+	}
+	for i, tt := range tests {
+		l := locks[i]
+		if l.TransactionHash != tt.hash || l.Type != tt.lockType || l.DueBlockID != tt.dueBlockId {
+			t.Errorf("lock %d is %s %s due at %d, want %s %s due at %d",
+				i, l.TransactionHash, l.Type, l.DueBlockID, tt.hash, tt.lockType, tt.dueBlockId)
+		}
+		if l.BlockID != b.Height || l.Status != models.LockStatusLocked {
+			t.Errorf("lock %d is %s at block %d, want %s at block %d", i, l.Status, l.BlockID, models.LockStatusLocked, b.Height)
+		}
+		if !equalUint64(l.CoinID, tt.coinId) || !equalString(l.Value, tt.value) {
+			t.Errorf("lock %d has coin %v and value %v, want %v and %v", i, l.CoinID, l.Value, tt.coinId, tt.value)
+		}
+		if addresses[i] != testAddress[2:] {
+			t.Errorf("lock %d owner is %s, want %s", i, addresses[i], testAddress[2:])
+		}
+	}
+}
+
+func uint64Ptr(v uint64) *uint64 { return &v }
+func stringPtr(v string) *string { return &v }
+
+func equalUint64(a, b *uint64) bool { return a == nil && b == nil || a != nil && b != nil && *a == *b }
+func equalString(a, b *string) bool { return a == nil && b == nil || a != nil && b != nil && *a == *b }
@@ -0,0 +1,21 @@
+package models
+
+type AddressStats struct {
+	tableName           struct{} `pg:"address_stats"`
+	AddressID           uint64   `json:"address_id"           pg:",pk"`
+	FirstBlockID        uint64   `json:"first_block_id"`
+	LastBlockID         uint64   `json:"last_block_id"`
+	SentCount           uint64   `json:"sent_count"           pg:",use_zero"`
+	ReceivedCount       uint64   `json:"received_count"       pg:",use_zero"`
+	CounterpartiesCount uint64   `json:"counterparties_count" pg:",use_zero"`
+	Address             *Address `json:"address"              pg:"rel:has-one"` //Relation has one to Addresses
+}
+
+type AddressFee struct {
+	tableName struct{} `pg:"address_fees"`
+	AddressID uint64   `json:"address_id" pg:",pk"`
+	CoinID    uint     `json:"coin_id"    pg:",pk,use_zero"`
+	Value     string   `json:"value"      pg:"type:numeric(70)"`
+	Address   *Address `pg:"rel:has-one"`            //Relation has one to Address
+	Coin      *Coin    `pg:"rel:has-one,fk:coin_id"` //Relation has one to Coin
+}
@@ -2,6 +2,27 @@ package models
 
 import "time"
 
+const (
+	RewardPeriodHour  = "hour"
+	RewardPeriodDay   = "day"
+	RewardPeriodMonth = "month"
+)
+
+// RewardPeriodStart Return start of the period which contains t, ok is false for unknown periods
+func RewardPeriodStart(period string, t time.Time) (start time.Time, ok bool) {
+	t = t.UTC()
+	switch period {
+	case RewardPeriodHour:
+		return t.Truncate(time.Hour), true
+	case RewardPeriodDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), true
+	case RewardPeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), true
+	}
+	return time.Time{}, false
+}
+
+// AggregatedReward Daily rewards, FromBlockID and ToBlockID are the first and the last block of the day with a reward
 type AggregatedReward struct {
 	FromBlockID uint64     `json:"from_block_id" pg:",pk"`
 	ToBlockID   uint64     `json:"to_block_id"`
@@ -15,3 +36,17 @@ type AggregatedReward struct {
 	Address     *Address   `pg:"rel:has-one"`                  //Relation has one to Addresses
 	Validator   *Validator `pg:"rel:has-one"`                  //Relation has one to Validators
 }
+
+// AggregatedRewardRollup Rewards of an hour or a month, TimeID is the start of the period
+type AggregatedRewardRollup struct {
+	Period      string     `json:"period"        pg:",pk"`
+	TimeID      time.Time  `json:"time_id"       pg:",pk"`
+	AddressID   uint64     `json:"address_id"    pg:",pk"`
+	ValidatorID uint64     `json:"validator_id"  pg:",pk"`
+	Role        string     `json:"role"          pg:",pk"`
+	FromBlockID uint64     `json:"from_block_id"`
+	ToBlockID   uint64     `json:"to_block_id"`
+	Amount      string     `json:"amount"        pg:"type:numeric(70)"`
+	Address     *Address   `pg:"rel:has-one"`
+	Validator   *Validator `pg:"rel:has-one"`
+}
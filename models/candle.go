@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	CandlePeriodMinute     = "1m"
+	CandlePeriodFiveMinute = "5m"
+	CandlePeriodHour       = "1h"
+	CandlePeriodDay        = "1d"
+)
+
+// CandlePeriods Duration of each candle period
+var CandlePeriods = map[string]time.Duration{
+	CandlePeriodMinute:     time.Minute,
+	CandlePeriodFiveMinute: 5 * time.Minute,
+	CandlePeriodHour:       time.Hour,
+	CandlePeriodDay:        24 * time.Hour,
+}
+
+// PoolCandle Price of the first pool coin in the second one
+type PoolCandle struct {
+	LiquidityPoolId uint64         `json:"liquidity_pool_id" pg:",pk"`
+	Period          string         `json:"period"            pg:",pk"`
+	StartAt         time.Time      `json:"start_at"          pg:",pk"`
+	Open            string         `json:"open"              pg:"type:numeric(100,18)"`
+	High            string         `json:"high"              pg:"type:numeric(100,18)"`
+	Low             string         `json:"low"               pg:"type:numeric(100,18)"`
+	Close           string         `json:"close"             pg:"type:numeric(100,18)"`
+	FirstVolume     string         `json:"first_volume"      pg:"type:numeric(100)"`
+	SecondVolume    string         `json:"second_volume"     pg:"type:numeric(100)"`
+	TradesCount     uint64         `json:"trades_count"`
+	LastBlockId     uint64         `json:"last_block_id"`
+	LiquidityPool   *LiquidityPool `json:"liquidity_pool"    pg:"rel:has-one,fk:liquidity_pool_id"`
+}
+
+// CoinCandle Price of a bonded coin in base coin
+type CoinCandle struct {
+	CoinId      uint64    `json:"coin_id"       pg:",pk"`
+	Period      string    `json:"period"        pg:",pk"`
+	StartAt     time.Time `json:"start_at"      pg:",pk"`
+	Open        string    `json:"open"          pg:"type:numeric(100,18)"`
+	High        string    `json:"high"          pg:"type:numeric(100,18)"`
+	Low         string    `json:"low"           pg:"type:numeric(100,18)"`
+	Close       string    `json:"close"         pg:"type:numeric(100,18)"`
+	Volume      string    `json:"volume"        pg:"type:numeric(70)"`
+	VolumeBip   string    `json:"volume_bip"    pg:"type:numeric(70)"`
+	TradesCount uint64    `json:"trades_count"`
+	LastBlockId uint64    `json:"last_block_id"`
+	Coin        *Coin     `json:"coin"          pg:"rel:has-one,fk:coin_id"`
+}
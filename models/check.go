@@ -1,11 +1,42 @@
 package models
 
+const (
+	CheckStatusIssued   = "issued"
+	CheckStatusRedeemed = "redeemed"
+	CheckStatusExpired  = "expired"
+)
+
+// Check Check seen in a RedeemCheck transaction. Checks seen only in failed redeem attempts are issued until DueBlock,
+// then expired. TransactionID, ToAddressId, BlockID and GasAmount (paid by the issuer in GasCoinID) are set on redemption
 type Check struct {
+	ID            uint64       `json:"id"              pg:",pk"`
 	TransactionID uint64       `json:"transaction_id"`
 	Data          string       `json:"data"`
 	FromAddressId uint         `json:"from_address_id"`
 	ToAddressId   uint         `json:"to_address_id"`
-	FromAddress   Address      `json:"from_address" pg:"rel:has-one,fk:from_address_id"`
-	ToAddress     Address      `json:"to_address"   pg:"rel:has-one,fk:to_address_id"`
-	Transaction   *Transaction `json:"transaction"  pg:"rel:has-one,fk:transaction_id"`
+	Nonce         string       `json:"nonce"`
+	ChainID       uint8        `json:"chain_id"`
+	DueBlock      uint64       `json:"due_block"`
+	CoinID        uint64       `json:"coin_id"         pg:",use_zero"`
+	Value         string       `json:"value"           pg:"type:numeric(70)"`
+	GasCoinID     uint64       `json:"gas_coin_id"     pg:",use_zero"`
+	GasAmount     string       `json:"gas_amount"      pg:"type:numeric(70)"`
+	BlockID       uint64       `json:"block_id"`
+	Status        string       `json:"status"`
+	FromAddress   Address      `json:"from_address"    pg:"rel:has-one,fk:from_address_id"`
+	ToAddress     Address      `json:"to_address"      pg:"rel:has-one,fk:to_address_id"`
+	Transaction   *Transaction `json:"transaction"     pg:"rel:has-one,fk:transaction_id"`
+}
+
+// CheckRedeemAttempt Failed RedeemCheck transaction, AlreadyRedeemed is set when the check had been used before
+type CheckRedeemAttempt struct {
+	ID                uint64 `json:"id"                  pg:",pk"`
+	CheckID           uint64 `json:"check_id"`
+	BlockID           uint64 `json:"block_id"`
+	TransactionHash   string `json:"transaction_hash"`
+	RedeemerAddressID uint   `json:"redeemer_address_id"`
+	Code              uint32 `json:"code"`
+	Log               string `json:"log"`
+	AlreadyRedeemed   bool   `json:"already_redeemed"    pg:",use_zero"`
+	Check             *Check `json:"check"               pg:"rel:has-one,fk:check_id"`
 }
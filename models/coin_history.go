@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const CoinHistoryCauseNodeRefresh = "node refresh"
+
+type CoinHistory struct {
+	tableName  struct{}  `pg:"coin_history"`
+	CoinID     uint64    `json:"coin_id"     pg:",use_zero"`
+	BlockID    uint64    `json:"block_id"`
+	Cause      string    `json:"cause"`
+	OldVolume  string    `json:"old_volume"  pg:"type:numeric(70)"`
+	NewVolume  string    `json:"new_volume"  pg:"type:numeric(70)"`
+	OldReserve string    `json:"old_reserve" pg:"type:numeric(70)"`
+	NewReserve string    `json:"new_reserve" pg:"type:numeric(70)"`
+	Price      string    `json:"price"       pg:"type:numeric(100,18)"`
+	CreatedAt  time.Time `json:"created_at"`
+	Coin       *Coin     `json:"coin"        pg:"rel:has-one,fk:coin_id"` //Relation has one to Coins
+}
+
+type CoinHistoryDaily struct {
+	tableName   struct{}  `pg:"coin_history_daily"`
+	CoinID      uint64    `json:"coin_id"       pg:",pk,use_zero"`
+	Date        time.Time `json:"date"          pg:",pk"`
+	Volume      string    `json:"volume"        pg:"type:numeric(70)"`
+	Reserve     string    `json:"reserve"       pg:"type:numeric(70)"`
+	Price       string    `json:"price"         pg:"type:numeric(100,18)"`
+	MinPrice    string    `json:"min_price"     pg:"type:numeric(100,18)"`
+	MaxPrice    string    `json:"max_price"     pg:"type:numeric(100,18)"`
+	Changes     uint64    `json:"changes"`
+	LastBlockID uint64    `json:"last_block_id"`
+	Coin        *Coin     `json:"coin"          pg:"rel:has-one,fk:coin_id"` //Relation has one to Coins
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	CoinPriceSourceBase   = "base"
+	CoinPriceSourceBancor = "bancor"
+	CoinPriceSourcePool   = "pool"
+)
+
+type CoinPrice struct {
+	BlockID   uint64    `json:"block_id"   pg:",pk"`
+	CoinID    uint64    `json:"coin_id"    pg:",pk,use_zero"`
+	PriceBip  string    `json:"price_bip"  pg:"type:numeric(100,18)"`
+	PriceUsd  string    `json:"price_usd"  pg:"type:numeric(100,18)"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+	Coin      *Coin     `json:"coin"       pg:"rel:has-one,fk:coin_id"` //Relation has one to Coins
+}
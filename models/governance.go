@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CommissionVote Vote of a validator for the commission price list which comes into force at Height.
+// Stake is the total stake of the validator at StakeBlockID, the vote block
+type CommissionVote struct {
+	ID              uint64          `json:"id"               pg:",pk"`
+	Height          uint64          `json:"height"`
+	ValidatorID     uint            `json:"validator_id"`
+	AddressID       uint            `json:"address_id"`
+	CoinID          uint64          `json:"coin_id"          pg:",use_zero"`
+	Prices          json.RawMessage `json:"prices"           pg:"type:jsonb"`
+	Stake           string          `json:"stake"            pg:"type:numeric(70)"`
+	StakeBlockID    *uint64         `json:"stake_block_id"`
+	BlockID         uint64          `json:"block_id"`
+	TransactionHash string          `json:"transaction_hash"`
+}
+
+// UpdateVote Vote of a validator for the network update to Version at Height
+type UpdateVote struct {
+	ID              uint64  `json:"id"               pg:",pk"`
+	Height          uint64  `json:"height"`
+	ValidatorID     uint    `json:"validator_id"`
+	AddressID       uint    `json:"address_id"`
+	Version         string  `json:"version"`
+	Stake           string  `json:"stake"            pg:"type:numeric(70)"`
+	StakeBlockID    *uint64 `json:"stake_block_id"`
+	BlockID         uint64  `json:"block_id"`
+	TransactionHash string  `json:"transaction_hash"`
+}
+
+// HaltBlockVote Vote of a validator for halting the network at Height
+type HaltBlockVote struct {
+	ID              uint64  `json:"id"               pg:",pk"`
+	Height          uint64  `json:"height"`
+	ValidatorID     uint    `json:"validator_id"`
+	AddressID       uint    `json:"address_id"`
+	Stake           string  `json:"stake"            pg:"type:numeric(70)"`
+	StakeBlockID    *uint64 `json:"stake_block_id"`
+	BlockID         uint64  `json:"block_id"`
+	TransactionHash string  `json:"transaction_hash"`
+}
+
+// CommissionHistory Commission price list set at BlockID, it is in force for transactions of the next blocks until the next list
+type CommissionHistory struct {
+	tableName struct{}        `pg:"commission_history"`
+	BlockID   uint64          `json:"block_id"   pg:",pk"`
+	CoinID    uint64          `json:"coin_id"    pg:",use_zero"`
+	Prices    json.RawMessage `json:"prices"     pg:"type:jsonb"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TransactionFee Commission of a transaction by the price list set at CommissionBlockID:
+// (BaseCommission + PayloadCommission) * GasPrice in the price coin, converted to the base coin
+// and paid in the gas coin through the Conversion route (pool or bancor, empty without conversion)
+type TransactionFee struct {
+	BlockID               uint64  `json:"block_id"                 pg:",pk"`
+	TransactionHash       string  `json:"transaction_hash"         pg:",pk"`
+	Type                  uint8   `json:"type"`
+	Failed                bool    `json:"failed"                   pg:",use_zero"`
+	CommissionBlockID     uint64  `json:"commission_block_id"`
+	PriceCoinID           uint64  `json:"price_coin_id"            pg:",use_zero"`
+	BaseCommission        string  `json:"base_commission"          pg:"type:numeric(70)"`
+	PayloadCommission     string  `json:"payload_commission"       pg:"type:numeric(70)"`
+	GasPrice              uint64  `json:"gas_price"`
+	CommissionInPriceCoin string  `json:"commission_in_price_coin" pg:"type:numeric(70)"`
+	CommissionInBaseCoin  string  `json:"commission_in_base_coin"  pg:"type:numeric(70)"`
+	GasCoinID             uint64  `json:"gas_coin_id"              pg:",use_zero"`
+	GasCoinAmount         string  `json:"gas_coin_amount"          pg:"type:numeric(70)"`
+	Conversion            string  `json:"conversion"               pg:",use_zero"`
+	PoolID                *uint64 `json:"pool_id"`
+}
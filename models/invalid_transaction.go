@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type InvalidTransaction struct {
 	ID                  uint64            `json:"id" pg:",pk"`
@@ -20,6 +23,12 @@ type InvalidTransaction struct {
 	Tags                map[string]string `json:"tags"`
 	Payload             []byte            `json:"payload"`
 	RawTx               []byte            `json:"raw_tx"`
+	Code                uint32            `json:"code"`
+	ErrorMessage        string            `json:"error_message"`
+	ErrorInfo           json.RawMessage   `json:"error_info"               pg:"type:jsonb"`
+	ErrorCoinID         *uint64           `json:"error_coin_id"`
+	ErrorRequiredValue  string            `json:"error_required_value"     pg:"type:numeric(70)"`
+	ErrorAvailableValue string            `json:"error_available_value"    pg:"type:numeric(70)"`
 	Block               *Block            `pg:"rel:has-one"`                    //Relation has one to Blocks
 	FromAddress         *Address          `pg:"rel:has-one,fk:from_address_id"` //Relation has one to Addresses
 	GasCoin             *Coin             `json:"gas_coin"                 pg:"rel:has-one,fk:gas_coin_id"`
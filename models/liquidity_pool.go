@@ -15,7 +15,7 @@ type LiquidityPool struct {
 	FirstCoinVolume  string `json:"first_coin_volume"  pg:"type:numeric(100)"`
 	SecondCoinVolume string `json:"second_coin_volume" pg:"type:numeric(100)"`
 	Liquidity        string `json:"liquidity"`
-	LiquidityBip     string `json:"liquidity_bip"`
+	LiquidityBip     string `json:"liquidity_bip"      pg:"type:numeric(100)"`
 	UpdatedAtBlockId uint64 `json:"updated_at_block_id"`
 	FirstCoin        *Coin  `json:"first_coin"  pg:"rel:has-one,fk:first_coin_id"`
 	SecondCoin       *Coin  `json:"second_coin" pg:"rel:has-one,fk:second_coin_id"`
@@ -0,0 +1,25 @@
+package models
+
+const (
+	LockTypeCoin  = "coin"
+	LockTypeStake = "stake"
+
+	LockStatusLocked   = "locked"
+	LockStatusReleased = "released"
+)
+
+// Lock Value locked by a Lock transaction or all stakes of the owner locked by a LockStake transaction until DueBlockID.
+// Coin and value are empty for stake locks
+type Lock struct {
+	ID              uint64   `json:"id"               pg:",pk"`
+	Type            string   `json:"type"`
+	OwnerAddressID  uint     `json:"owner_address_id"`
+	CoinID          *uint64  `json:"coin_id"`
+	Value           *string  `json:"value"            pg:"type:numeric(70)"`
+	DueBlockID      uint64   `json:"due_block_id"`
+	BlockID         uint64   `json:"block_id"`
+	TransactionHash string   `json:"transaction_hash"`
+	Status          string   `json:"status"`
+	Coin            *Coin    `json:"coin"             pg:"rel:has-one,fk:coin_id"`
+	OwnerAddress    *Address `json:"owner_address"    pg:"rel:has-one,fk:owner_address_id"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// MultisigWallet Configuration of a multisig address set by a CreateMultisig or EditMultisig transaction,
+// valid from FromBlockID until ToBlockID, the current one has no ToBlockID
+type MultisigWallet struct {
+	AddressID       uint             `json:"address_id"       pg:",pk"`
+	Threshold       uint64           `json:"threshold"`
+	FromBlockID     uint64           `json:"from_block_id"    pg:",pk"`
+	ToBlockID       *uint64          `json:"to_block_id"`
+	TransactionHash string           `json:"transaction_hash"`
+	Address         *Address         `json:"address"          pg:"rel:has-one,fk:address_id"`
+	Owners          []*MultisigOwner `json:"owners"           pg:"-"`
+}
+
+// MultisigOwner Owner of a multisig configuration and its weight
+type MultisigOwner struct {
+	MultisigAddressID uint     `json:"multisig_address_id" pg:",pk"`
+	FromBlockID       uint64   `json:"from_block_id"       pg:",pk"`
+	OwnerAddressID    uint     `json:"owner_address_id"    pg:",pk"`
+	Weight            uint64   `json:"weight"`
+	OwnerAddress      *Address `json:"owner_address"       pg:"rel:has-one,fk:owner_address_id"`
+}
+
+// MultisigSigner Owner which signed a transaction of a multisig address. Weight is empty when the configuration
+// of the address was set before the first indexed block
+type MultisigSigner struct {
+	BlockID           uint64  `json:"block_id"`
+	TransactionHash   string  `json:"transaction_hash"    pg:",pk"`
+	MultisigAddressID uint    `json:"multisig_address_id"`
+	SignerAddressID   uint    `json:"signer_address_id"   pg:",pk"`
+	Weight            *uint64 `json:"weight"`
+}
@@ -6,16 +6,19 @@ import (
 )
 
 type Stake struct {
-	ID             uint       `json:"id"               pg:",pk"`
-	OwnerAddressID uint       `json:"owner_address_id"`
-	ValidatorID    uint       `json:"validator_id"`
-	CoinID         uint       `json:"coin_id"          pg:",use_zero"`
-	Value          string     `json:"value"            pg:"type:numeric(70)"`
-	BipValue       string     `json:"bip_value"        pg:"type:numeric(70)"`
-	IsKicked       bool       `json:"is_kicked"`
-	Coin           *Coin      `json:"coins"            pg:"rel:has-one"`                     //Relation has one to Coins
-	OwnerAddress   *Address   `json:"owner_address"    pg:"rel:has-one,fk:owner_address_id"` //Relation has one to Addresses
-	Validator      *Validator `json:"validator"        pg:"rel:has-one"`                     //Relation has one to Validators
+	ID                 uint       `json:"id"               pg:",pk"`
+	OwnerAddressID     uint       `json:"owner_address_id"`
+	ValidatorID        uint       `json:"validator_id"`
+	CoinID             uint       `json:"coin_id"          pg:",use_zero"`
+	Value              string     `json:"value"            pg:"type:numeric(70)"`
+	BipValue           string     `json:"bip_value"        pg:"type:numeric(70)"`
+	IsKicked           bool       `json:"is_kicked"`
+	BlockID            uint64     `json:"block_id"`
+	IsLocked           bool       `json:"is_locked"        pg:",use_zero"`
+	LockedUntilBlockID *uint64    `json:"locked_until_block_id"`
+	Coin               *Coin      `json:"coins"            pg:"rel:has-one"`                     //Relation has one to Coins
+	OwnerAddress       *Address   `json:"owner_address"    pg:"rel:has-one,fk:owner_address_id"` //Relation has one to Addresses
+	Validator          *Validator `json:"validator"        pg:"rel:has-one"`                     //Relation has one to Validators
 }
 
 func (s Stake) String() string {
@@ -27,15 +30,29 @@ func (s Stake) String() string {
 	return string(bytes)
 }
 
+// MovedStake Stake which reaches the destination validator at BlockId
 type MovedStake struct {
+	ID              uint64     `json:"id"                pg:",pk"`
 	BlockId         uint64     `json:"block_id"`
 	AddressId       uint64     `json:"address_id"`
-	CoinId          uint64     `json:"coin_id" pg:",use_zero"`
+	CoinId          uint64     `json:"coin_id"           pg:",use_zero"`
 	FromValidatorId uint64     `json:"from_validator_id"`
 	ToValidatorId   uint64     `json:"to_validator_id"`
 	Value           string     `json:"value"`
-	Coin            *Coin      `json:"coin"           pg:"rel:has-one,fk:coin_id"`
-	Address         *Address   `json:"address"        pg:"rel:has-one,fk:address_id"`
-	FromValidator   *Validator `json:"from_validator" pg:"rel:has-one,fk:from_validator_id"`
-	ToValidator     *Validator `json:"to_validator"   pg:"rel:has-one,fk:to_validator_id"`
+	Status          string     `json:"status"`
+	TransactionHash string     `json:"transaction_hash"`
+	Coin            *Coin      `json:"coin"              pg:"rel:has-one,fk:coin_id"`
+	Address         *Address   `json:"address"           pg:"rel:has-one,fk:address_id"`
+	FromValidator   *Validator `json:"from_validator"    pg:"rel:has-one,fk:from_validator_id"`
+	ToValidator     *Validator `json:"to_validator"      pg:"rel:has-one,fk:to_validator_id"`
+}
+
+// WaitListUpdate New value of an address stake in the wait list of a validator, zero value means the stake has left the wait list
+type WaitListUpdate struct {
+	Address     string `json:"address"`
+	PublicKey   string `json:"public_key"`
+	ValidatorID uint   `json:"validator_id"`
+	CoinID      uint   `json:"coin_id"`
+	Value       string `json:"value"`
+	BlockID     uint64 `json:"block_id"`
 }
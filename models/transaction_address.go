@@ -0,0 +1,22 @@
+package models
+
+// Roles of an address in a transaction
+const (
+	TxRoleSender         = "sender"
+	TxRoleRecipient      = "recipient"
+	TxRoleCheckIssuer    = "check_issuer"
+	TxRoleOrderSeller    = "order_seller"
+	TxRoleMultisigOwner  = "multisig_owner"
+	TxRoleMultisigSigner = "multisig_signer"
+	TxRoleCoinOwner      = "coin_owner"
+	TxRoleValidator      = "validator" // owner, control or reward address of the validator of the transaction
+)
+
+// TransactionAddress Address involved in a transaction with all its roles in the transaction
+type TransactionAddress struct {
+	tableName     struct{} `pg:"index_transaction_by_address"`
+	BlockID       uint64   `json:"block_id"`
+	AddressID     uint64   `json:"address_id"`
+	TransactionID uint64   `json:"transaction_id"`
+	Roles         []string `json:"roles"          pg:",array"`
+}
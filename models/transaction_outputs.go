@@ -3,6 +3,7 @@ package models
 type TransactionOutput struct {
 	ID            uint64       `json:"id"`
 	TransactionID uint64       `json:"transaction_id"`
+	BlockID       uint64       `json:"block_id"`
 	ToAddressID   uint64       `json:"to_address_id"`
 	CoinID        uint         `json:"coin_id"     pg:",use_zero"`
 	Value         string       `json:"value"       pg:"type:numeric(70)"`
@@ -1,12 +1,21 @@
 package models
 
+const (
+	StakeOperationPending   = "pending"
+	StakeOperationCompleted = "completed"
+)
+
+// Unbond Unbonded value which returns to the owner balance at BlockId
 type Unbond struct {
-	BlockId     uint       `json:"block_id"`
-	AddressId   uint       `json:"address_id"`
-	CoinId      uint       `json:"coin_id" pg:",use_zero"`
-	ValidatorId uint       `json:"validator_id"`
-	Value       string     `json:"value"`
-	Coin        *Coin      `json:"coin"      pg:"rel:has-one,fk:coin_id"`
-	Address     *Address   `json:"address"   pg:"rel:has-one,fk:address_id"`
-	Validator   *Validator `json:"validator" pg:"rel:has-one,fk:validator_id"`
+	ID              uint64     `json:"id"               pg:",pk"`
+	BlockId         uint       `json:"block_id"`
+	AddressId       uint       `json:"address_id"`
+	CoinId          uint       `json:"coin_id"          pg:",use_zero"`
+	ValidatorId     uint       `json:"validator_id"`
+	Value           string     `json:"value"`
+	Status          string     `json:"status"`
+	TransactionHash string     `json:"transaction_hash"`
+	Coin            *Coin      `json:"coin"             pg:"rel:has-one,fk:coin_id"`
+	Address         *Address   `json:"address"          pg:"rel:has-one,fk:address_id"`
+	Validator       *Validator `json:"validator"        pg:"rel:has-one,fk:validator_id"`
 }
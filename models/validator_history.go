@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	ValidatorHistorySourceTx      = "tx"
+	ValidatorHistorySourceRefresh = "refresh"
+)
+
+// ValidatorHistory State of a validator after a change. Changes lists the changed columns,
+// TransactionHash is set when the change was made by a transaction and empty for periodic refreshes from node
+type ValidatorHistory struct {
+	tableName        struct{}   `pg:"validator_history"`
+	ID               uint64     `json:"id"                 pg:",pk"`
+	ValidatorID      uint       `json:"validator_id"`
+	BlockID          uint64     `json:"block_id"`
+	Source           string     `json:"source"`
+	TransactionHash  string     `json:"transaction_hash"`
+	Changes          []string   `json:"changes"            pg:",array"`
+	PublicKey        string     `json:"public_key"         pg:"type:varchar(64)"`
+	Status           *uint8     `json:"status"`
+	Commission       *uint64    `json:"commission"`
+	TotalStake       *string    `json:"total_stake"        pg:"type:numeric(70)"`
+	OwnerAddressID   *uint      `json:"owner_address_id"`
+	ControlAddressID *uint      `json:"control_address_id"`
+	RewardAddressID  *uint      `json:"reward_address_id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	Validator        *Validator `json:"validator"          pg:"rel:has-one"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	ValidatorAlertMissStreak = "miss_streak"
+	ValidatorAlertRecovered  = "recovered"
+)
+
+// ValidatorStats Signing counters of a validator since the first indexed block.
+// MissedInWindow is the number of missed blocks among the last APP_VALIDATOR_STATS_WINDOW blocks
+type ValidatorStats struct {
+	tableName          struct{}   `pg:"validator_stats"`
+	ValidatorID        uint64     `json:"validator_id"          pg:",pk"`
+	SignedBlocks       uint64     `json:"signed_blocks"`
+	MissedBlocks       uint64     `json:"missed_blocks"`
+	ProposedBlocks     uint64     `json:"proposed_blocks"`
+	MissedInWindow     uint64     `json:"missed_in_window"`
+	MissStreak         uint64     `json:"miss_streak"`
+	MaxMissStreak      uint64     `json:"max_miss_streak"`
+	JailsCount         uint64     `json:"jails_count"`
+	LastJailedBlockID  *uint64    `json:"last_jailed_block_id"`
+	JailedUntilBlockID *uint64    `json:"jailed_until_block_id"`
+	LastBlockID        uint64     `json:"last_block_id"`
+	Validator          *Validator `json:"validator"             pg:"rel:has-one"`
+}
+
+// ValidatorDailyUptime Signed and missed blocks of a validator per UTC day, Uptime is a percentage of signed blocks
+type ValidatorDailyUptime struct {
+	tableName      struct{}   `pg:"validator_daily_uptime"`
+	ValidatorID    uint64     `json:"validator_id"    pg:",pk"`
+	Date           time.Time  `json:"date"            pg:"type:date,pk"`
+	SignedBlocks   uint64     `json:"signed_blocks"`
+	MissedBlocks   uint64     `json:"missed_blocks"`
+	ProposedBlocks uint64     `json:"proposed_blocks"`
+	Uptime         string     `json:"uptime"          pg:"type:numeric(5,2)"`
+	LastBlockID    uint64     `json:"last_block_id"`
+	Validator      *Validator `json:"validator"       pg:"rel:has-one"`
+}
+
+// ValidatorAlert Sent when a miss streak of a validator reaches the threshold and when the validator signs again
+type ValidatorAlert struct {
+	Type        string `json:"type"`
+	ValidatorID uint64 `json:"validator_id"`
+	PublicKey   string `json:"public_key"`
+	MissStreak  uint64 `json:"miss_streak"`
+	BlockID     uint64 `json:"block_id"`
+}
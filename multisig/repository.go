@@ -0,0 +1,88 @@
+package multisig
+
+import (
+	"context"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+)
+
+type Repository struct {
+	db *pg.DB
+}
+
+func NewRepository(db *pg.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// SaveBlock Save multisig configurations set by the block, closing the previous ones, and signers of its transactions.
+// Rows are unique by address and block or by transaction, so a block can be safely handled twice
+func (r *Repository) SaveBlock(height uint64, wallets []*models.MultisigWallet, signers []*models.MultisigSigner) error {
+	return r.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		for _, w := range wallets {
+			_, err := tx.Model((*models.MultisigWallet)(nil)).
+				Set("to_block_id = ?", height).
+				Where("address_id = ?", w.AddressID).
+				Where("to_block_id IS NULL").
+				Where("from_block_id < ?", height).
+				Update()
+			if err != nil {
+				return err
+			}
+			_, err = tx.Model(w).OnConflict("DO NOTHING").Insert()
+			if err != nil {
+				return err
+			}
+			_, err = tx.Model(&w.Owners).OnConflict("DO NOTHING").Insert()
+			if err != nil {
+				return err
+			}
+		}
+		if len(signers) > 0 {
+			_, err := tx.Model(&signers).OnConflict("DO NOTHING").Insert()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetOwners Return owners of current configurations of multisig addresses
+func (r *Repository) GetOwners(addressIds []uint) ([]*models.MultisigOwner, error) {
+	var list []*models.MultisigOwner
+	if len(addressIds) == 0 {
+		return list, nil
+	}
+	err := r.db.Model(&list).
+		Join("JOIN multisig_wallets w ON w.address_id = multisig_owner.multisig_address_id AND w.from_block_id = multisig_owner.from_block_id").
+		Where("w.to_block_id IS NULL").
+		Where("multisig_owner.multisig_address_id IN (?)", pg.In(addressIds)).
+		Select()
+	return list, err
+}
+
+// BlockOwner Owner of a multisig address in the configuration active at the block
+type BlockOwner struct {
+	MultisigAddressID uint
+	BlockID           uint64
+	OwnerAddressID    uint
+}
+
+// GetOwnersAtBlocks Return owners of multisig addresses in configurations active at the blocks,
+// addressIds and blockIds are pairs
+func (r *Repository) GetOwnersAtBlocks(addressIds []uint, blockIds []uint64) ([]*BlockOwner, error) {
+	var list []*BlockOwner
+	if len(addressIds) == 0 {
+		return list, nil
+	}
+	_, err := r.db.Query(&list, `
+		SELECT DISTINCT l.multisig_address_id, l.block_id, o.owner_address_id
+		FROM unnest(?::bigint[], ?::bigint[]) AS l (multisig_address_id, block_id)
+		JOIN multisig_wallets w ON w.address_id = l.multisig_address_id AND w.from_block_id <= l.block_id
+			AND (w.to_block_id IS NULL OR w.to_block_id > l.block_id)
+		JOIN multisig_owners o ON o.multisig_address_id = w.address_id AND o.from_block_id = w.from_block_id;
+	`, pg.Array(addressIds), pg.Array(blockIds))
+	return list, err
+}
@@ -0,0 +1,201 @@
+package multisig
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/address"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// config Multisig configuration set by a transaction of the block
+type config struct {
+	address   string
+	threshold uint64
+	owners    []string
+	weights   []uint64
+	txHash    string
+}
+
+// signature Owners which signed a transaction of a multisig address
+type signature struct {
+	multisig string
+	signers  []string
+	txHash   string
+	index    int // number of configurations of the block set before the transaction
+}
+
+type Service struct {
+	repository        *Repository
+	addressRepository *address.Repository
+	logger            *logrus.Entry
+}
+
+func NewService(repository *Repository, addressRepository *address.Repository, logger *logrus.Entry) *Service {
+	return &Service{
+		repository:        repository,
+		addressRepository: addressRepository,
+		logger: logger.WithFields(logrus.Fields{
+			"service": "Multisig",
+		}),
+	}
+}
+
+// HandleBlock Save multisig configurations and signers of transactions of the block.
+// Configurations replace each other, so blocks must be handled in order
+func (s *Service) HandleBlock(b *api_pb.BlockResponse) error {
+	var configs []*config
+	var signatures []*signature
+	for _, tx := range b.Transactions {
+		signers, err := address.MultisigSigners(tx.RawTx)
+		if err != nil {
+			s.logger.WithField("tx", tx.Hash).Error(err)
+		} else if len(signers) > 0 {
+			signatures = append(signatures, &signature{
+				multisig: helpers.RemovePrefix(tx.From),
+				signers:  signers,
+				txHash:   helpers.RemovePrefix(tx.Hash),
+				index:    len(configs),
+			})
+		}
+
+		if tx.Log != "" {
+			continue
+		}
+		switch transaction.Type(tx.Type) {
+		case transaction.TypeCreateMultisig:
+			txData := new(api_pb.CreateMultisigData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return err
+			}
+			configs = append(configs, newConfig(tx.GetTags()["tx.created_multisig"], tx.Hash, txData.Threshold, txData.Addresses, txData.Weights))
+		case transaction.TypeEditMultisig:
+			txData := new(api_pb.EditMultisigData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return err
+			}
+			configs = append(configs, newConfig(tx.From, tx.Hash, txData.Threshold, txData.Addresses, txData.Weights))
+		}
+	}
+	if len(configs) == 0 && len(signatures) == 0 {
+		return nil
+	}
+
+	addressIds, err := s.resolveAddresses(configs, signatures)
+	if err != nil {
+		return err
+	}
+
+	// only the last configuration of an address set by the block is kept
+	var wallets []*models.MultisigWallet
+	walletIndex := make(map[uint]int)
+	for _, c := range configs {
+		w := &models.MultisigWallet{
+			AddressID:       addressIds[c.address],
+			Threshold:       c.threshold,
+			FromBlockID:     b.Height,
+			TransactionHash: c.txHash,
+		}
+		for i, owner := range c.owners {
+			w.Owners = append(w.Owners, &models.MultisigOwner{
+				MultisigAddressID: w.AddressID,
+				FromBlockID:       b.Height,
+				OwnerAddressID:    addressIds[owner],
+				Weight:            c.weights[i],
+			})
+		}
+		if i, ok := walletIndex[w.AddressID]; ok {
+			wallets[i] = w
+			continue
+		}
+		walletIndex[w.AddressID] = len(wallets)
+		wallets = append(wallets, w)
+	}
+
+	signers, err := s.weighSigners(configs, signatures, addressIds, b.Height)
+	if err != nil {
+		return err
+	}
+	return s.repository.SaveBlock(b.Height, wallets, signers)
+}
+
+// weighSigners Return signers of transactions with weights of the configuration in force at the transaction
+func (s *Service) weighSigners(configs []*config, signatures []*signature, addressIds map[string]uint, height uint64) ([]*models.MultisigSigner, error) {
+	if len(signatures) == 0 {
+		return nil, nil
+	}
+	var multisigIds []uint
+	for _, sig := range signatures {
+		multisigIds = append(multisigIds, addressIds[sig.multisig])
+	}
+	owners, err := s.repository.GetOwners(multisigIds)
+	if err != nil {
+		return nil, err
+	}
+	weights := make(map[uint]map[uint]uint64)
+	for _, o := range owners {
+		if weights[o.MultisigAddressID] == nil {
+			weights[o.MultisigAddressID] = make(map[uint]uint64)
+		}
+		weights[o.MultisigAddressID][o.OwnerAddressID] = o.Weight
+	}
+
+	var signers []*models.MultisigSigner
+	for _, sig := range signatures {
+		multisigId := addressIds[sig.multisig]
+		current := weights[multisigId]
+		// a configuration set earlier in the same block replaces the stored one
+		for _, c := range configs[:sig.index] {
+			if c.address != sig.multisig {
+				continue
+			}
+			current = make(map[uint]uint64)
+			for i, owner := range c.owners {
+				current[addressIds[owner]] = c.weights[i]
+			}
+		}
+		for _, signer := range sig.signers {
+			signerId := addressIds[signer]
+			var weight *uint64
+			if w, ok := current[signerId]; ok {
+				weight = &w
+			}
+			signers = append(signers, &models.MultisigSigner{
+				BlockID:           height,
+				TransactionHash:   sig.txHash,
+				MultisigAddressID: multisigId,
+				SignerAddressID:   signerId,
+				Weight:            weight,
+			})
+		}
+	}
+	return signers, nil
+}
+
+func (s *Service) resolveAddresses(configs []*config, signatures []*signature) (map[string]uint, error) {
+	var addresses []string
+	for _, c := range configs {
+		addresses = append(addresses, c.address)
+		addresses = append(addresses, c.owners...)
+	}
+	for _, sig := range signatures {
+		addresses = append(addresses, sig.multisig)
+		addresses = append(addresses, sig.signers...)
+	}
+	return s.addressRepository.FindIdsOrCreate(addresses)
+}
+
+func newConfig(multisig, txHash string, threshold uint64, owners []string, weights []uint64) *config {
+	c := &config{
+		address:   strings.TrimPrefix(multisig, "Mx"),
+		threshold: threshold,
+		weights:   weights,
+		txHash:    helpers.RemovePrefix(txHash),
+	}
+	for _, owner := range owners {
+		c.owners = append(c.owners, helpers.RemovePrefix(owner))
+	}
+	return c
+}
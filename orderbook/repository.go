@@ -3,10 +3,11 @@ package orderbook
 import (
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 )
 
 type Repository struct {
-	db *pg.DB
+	db orm.DB
 }
 
 func (r *Repository) SaveAll(list []*models.Order) error {
@@ -65,3 +66,10 @@ func NewRepository(db *pg.DB) *Repository {
 		db: db,
 	}
 }
+
+// WithTx Return repository which runs queries in the transaction
+func (r *Repository) WithTx(tx *pg.Tx) *Repository {
+	repository := *r
+	repository.db = tx
+	return &repository
+}
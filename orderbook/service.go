@@ -88,11 +88,14 @@ func (s *Service) GetOrderDataFromTx(tx *api_pb.TransactionResponse) (*models.Or
 
 func (s *Service) UpdateOrderBookWorker(data <-chan []models.TxTagDetailsOrder) {
 	for orders := range data {
-		s.updateOrders(orders)
+		err := s.updateOrders(orders)
+		if err != nil {
+			s.logger.Error(err)
+		}
 	}
 }
 
-func (s *Service) updateOrders(orders []models.TxTagDetailsOrder) {
+func (s *Service) updateOrders(orders []models.TxTagDetailsOrder) error {
 	mapId := make(map[uint64]struct{})
 	for _, o := range orders {
 		mapId[o.Id] = struct{}{}
@@ -105,8 +108,7 @@ func (s *Service) updateOrders(orders []models.TxTagDetailsOrder) {
 
 	orderList, err := s.Storage.GetAllById(listId)
 	if err != nil {
-		s.logger.Error(err)
-		return
+		return err
 	}
 	mapOrders := make(map[uint64]models.Order)
 	for _, o := range orderList {
@@ -200,76 +202,103 @@ func (s *Service) updateOrders(orders []models.TxTagDetailsOrder) {
 		list = append(list, o)
 	}
 
-	err = s.Storage.UpdateOrders(&list)
-	if err != nil {
-		s.logger.Error(err)
-	}
+	return s.Storage.UpdateOrders(&list)
 }
 
 func (s *Service) OrderBookWorker(data <-chan *api_pb.BlockResponse) {
 	for b := range data {
-		if len(b.Transactions) < 1 {
+		err := s.HandleBlock(b)
+		if err != nil {
+			s.logger.WithField("block", b.Height).Error(err)
+		}
+	}
+}
+
+// HandleBlock Save new orders of the block and apply cancellations and fills from its transactions
+func (s *Service) HandleBlock(b *api_pb.BlockResponse) error {
+	if len(b.Transactions) < 1 {
+		return nil
+	}
+	var orderMap sync.Map
+	var deleteOrderMap sync.Map
+	var updateOrderMap sync.Map
+	var wg sync.WaitGroup
+
+	for _, tx := range b.Transactions {
+		if tx.Log != "" {
 			continue
 		}
-		var orderMap sync.Map
-		var deleteOrderMap sync.Map
-		var updateOrderMap sync.Map
-		var wg sync.WaitGroup
-
-		for _, tx := range b.Transactions {
-			if tx.Log != "" {
-				continue
-			}
-			wg.Add(1)
-			go func(tx *api_pb.TransactionResponse) {
-				switch transaction.Type(tx.Type) {
-				case transaction.TypeAddLimitOrder:
-					o, err := s.GetOrderDataFromTx(tx)
+		wg.Add(1)
+		go func(tx *api_pb.TransactionResponse) {
+			switch transaction.Type(tx.Type) {
+			case transaction.TypeAddLimitOrder:
+				o, err := s.GetOrderDataFromTx(tx)
+				if err != nil {
+					s.logger.Error(err)
+				} else {
+					orderMap.Store(o.Id, o)
+				}
+				tags := tx.GetTags()
+				if tags["tx.commission_conversion"] == "pool" {
+					jsonString := strings.Replace(tags["tx.commission_details"], `\`, "", -1)
+					var tag models.TagCommissionDetails
+					err = json.Unmarshal([]byte(jsonString), &tag)
 					if err != nil {
 						s.logger.Error(err)
 					} else {
-						orderMap.Store(o.Id, o)
-					}
-					tags := tx.GetTags()
-					if tags["tx.commission_conversion"] == "pool" {
-						jsonString := strings.Replace(tags["tx.commission_details"], `\`, "", -1)
-						var tag models.TagCommissionDetails
-						err = json.Unmarshal([]byte(jsonString), &tag)
-						if err != nil {
-							s.logger.Error(err)
-						} else {
-							for _, orderDetail := range tag.Details.Orders {
-								updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), orderDetail)
-							}
+						for _, orderDetail := range tag.Details.Orders {
+							updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), orderDetail)
 						}
 					}
-				case transaction.TypeRemoveLimitOrder:
-					txData := new(api_pb.RemoveLimitOrderData)
-					if err := tx.GetData().UnmarshalTo(txData); err != nil {
+				}
+			case transaction.TypeRemoveLimitOrder:
+				txData := new(api_pb.RemoveLimitOrderData)
+				if err := tx.GetData().UnmarshalTo(txData); err != nil {
+					s.logger.Error(err)
+				} else {
+					deleteOrderMap.Store(txData.Id, txData)
+				}
+				tags := tx.GetTags()
+				if tags["tx.commission_conversion"] == "pool" {
+					jsonString := strings.Replace(tags["tx.commission_details"], `\`, "", -1)
+					var tag models.TagCommissionDetails
+					err := json.Unmarshal([]byte(jsonString), &tag)
+					if err != nil {
 						s.logger.Error(err)
 					} else {
-						deleteOrderMap.Store(txData.Id, txData)
+						for _, orderDetail := range tag.Details.Orders {
+							updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), orderDetail)
+						}
 					}
-					tags := tx.GetTags()
-					if tags["tx.commission_conversion"] == "pool" {
-						jsonString := strings.Replace(tags["tx.commission_details"], `\`, "", -1)
-						var tag models.TagCommissionDetails
-						err := json.Unmarshal([]byte(jsonString), &tag)
-						if err != nil {
-							s.logger.Error(err)
-						} else {
-							for _, orderDetail := range tag.Details.Orders {
-								updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), orderDetail)
-							}
+				}
+			case transaction.TypeBuySwapPool,
+				transaction.TypeSellSwapPool,
+				transaction.TypeSellAllSwapPool:
+				tags := tx.GetTags()
+				jsonString := strings.Replace(tags["tx.pools"], `\`, "", -1)
+				var tagPools []models.BuySwapPoolTag
+				err := json.Unmarshal([]byte(jsonString), &tagPools)
+				if err != nil {
+					s.logger.WithFields(logrus.Fields{
+						"json":  jsonString,
+						"tx":    tx.Hash,
+						"block": tx.Height,
+					}).Error(err)
+				} else {
+					for _, p := range tagPools {
+						for _, tagOrderDetail := range p.Details.Orders {
+							updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), tagOrderDetail)
 						}
 					}
-				case transaction.TypeBuySwapPool,
-					transaction.TypeSellSwapPool,
-					transaction.TypeSellAllSwapPool:
-					tags := tx.GetTags()
-					jsonString := strings.Replace(tags["tx.pools"], `\`, "", -1)
-					var tagPools []models.BuySwapPoolTag
-					err := json.Unmarshal([]byte(jsonString), &tagPools)
+				}
+				if tags["tx.commission_conversion"] == "pool" {
+					jsonString = strings.Replace(tags["tx.commission_details"], `\`, "", -1)
+					var tag models.TagCommissionDetails
+					if jsonString == "" {
+						wg.Done()
+						return
+					}
+					err = json.Unmarshal([]byte(jsonString), &tag)
 					if err != nil {
 						s.logger.WithFields(logrus.Fields{
 							"json":  jsonString,
@@ -277,114 +306,93 @@ func (s *Service) OrderBookWorker(data <-chan *api_pb.BlockResponse) {
 							"block": tx.Height,
 						}).Error(err)
 					} else {
-						for _, p := range tagPools {
-							for _, tagOrderDetail := range p.Details.Orders {
-								updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), tagOrderDetail)
-							}
+						for _, orderDetail := range tag.Details.Orders {
+							updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), orderDetail)
 						}
 					}
-					if tags["tx.commission_conversion"] == "pool" {
-						jsonString = strings.Replace(tags["tx.commission_details"], `\`, "", -1)
-						var tag models.TagCommissionDetails
-						if jsonString == "" {
-							wg.Done()
-							return
-						}
-						err = json.Unmarshal([]byte(jsonString), &tag)
-						if err != nil {
-							s.logger.WithFields(logrus.Fields{
-								"json":  jsonString,
-								"tx":    tx.Hash,
-								"block": tx.Height,
-							}).Error(err)
-						} else {
-							for _, orderDetail := range tag.Details.Orders {
-								updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), orderDetail)
-							}
-						}
+				}
+			default:
+				tags := tx.GetTags()
+				if tags["tx.commission_conversion"] == "pool" {
+					jsonString := strings.Replace(tags["tx.commission_details"], `\`, "", -1)
+					if jsonString == "" {
+						wg.Done()
+						return
 					}
-				default:
-					tags := tx.GetTags()
-					if tags["tx.commission_conversion"] == "pool" {
-						jsonString := strings.Replace(tags["tx.commission_details"], `\`, "", -1)
-						if jsonString == "" {
-							wg.Done()
-							return
-						}
-						var tag models.TagCommissionDetails
-						err := json.Unmarshal([]byte(jsonString), &tag)
-						if err != nil {
-							s.logger.WithFields(logrus.Fields{
-								"json":  jsonString,
-								"tx":    tx.Hash,
-								"block": tx.Height,
-							}).Error(err)
-						} else {
-							for _, orderDetail := range tag.Details.Orders {
-								updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), orderDetail)
-							}
+					var tag models.TagCommissionDetails
+					err := json.Unmarshal([]byte(jsonString), &tag)
+					if err != nil {
+						s.logger.WithFields(logrus.Fields{
+							"json":  jsonString,
+							"tx":    tx.Hash,
+							"block": tx.Height,
+						}).Error(err)
+					} else {
+						for _, orderDetail := range tag.Details.Orders {
+							updateOrderMap.Store(fmt.Sprintf("%s", uuid.New()), orderDetail)
 						}
 					}
 				}
-				wg.Done()
-			}(tx)
-		}
-		wg.Wait()
-
-		//var list []*models.Order
-		newOrdersMap := make(map[int][]*models.Order)
-		index := 0
-		orderMap.Range(func(k, v interface{}) bool {
-			if newOrdersMap[index] == nil {
-				newOrdersMap[index] = []*models.Order{}
-			}
-			newOrdersMap[index] = append(newOrdersMap[index], v.(*models.Order))
-			if len(newOrdersMap[index]) > 50000 {
-				index++
-			}
-			return true
-		})
-		if len(newOrdersMap) > 0 {
-			for _, orders := range newOrdersMap {
-				err := s.Storage.SaveAll(orders)
-				if err != nil {
-					s.logger.Error(err)
-				}
 			}
+			wg.Done()
+		}(tx)
+	}
+	wg.Wait()
+
+	//var list []*models.Order
+	newOrdersMap := make(map[int][]*models.Order)
+	index := 0
+	orderMap.Range(func(k, v interface{}) bool {
+		if newOrdersMap[index] == nil {
+			newOrdersMap[index] = []*models.Order{}
 		}
-
-		var idForDelete []uint64
-		deleteOrderMap.Range(func(k, v interface{}) bool {
-			idForDelete = append(idForDelete, k.(uint64))
-			return true
-		})
-
-		uom := make(map[int][]models.TxTagDetailsOrder)
-		index = 0
-		updateOrderMap.Range(func(k, v interface{}) bool {
-			if uom[index] == nil {
-				uom[index] = []models.TxTagDetailsOrder{}
-			}
-			uom[index] = append(uom[index], v.(models.TxTagDetailsOrder))
-			if len(uom[index]) > 50000 {
-				index++
+		newOrdersMap[index] = append(newOrdersMap[index], v.(*models.Order))
+		if len(newOrdersMap[index]) > 50000 {
+			index++
+		}
+		return true
+	})
+	if len(newOrdersMap) > 0 {
+		for _, orders := range newOrdersMap {
+			err := s.handleError(s.Storage.SaveAll(orders))
+			if err != nil {
+				return err
 			}
-			return true
-		})
+		}
+	}
 
-		if len(uom) > 0 {
-			for _, orders := range uom {
-				s.updateOrders(orders)
-			}
+	var idForDelete []uint64
+	deleteOrderMap.Range(func(k, v interface{}) bool {
+		idForDelete = append(idForDelete, k.(uint64))
+		return true
+	})
+
+	uom := make(map[int][]models.TxTagDetailsOrder)
+	index = 0
+	updateOrderMap.Range(func(k, v interface{}) bool {
+		if uom[index] == nil {
+			uom[index] = []models.TxTagDetailsOrder{}
+		}
+		uom[index] = append(uom[index], v.(models.TxTagDetailsOrder))
+		if len(uom[index]) > 50000 {
+			index++
 		}
+		return true
+	})
 
-		if len(idForDelete) > 0 {
-			err := s.Storage.CancelByIdList(idForDelete, models.OrderTypeCanceled)
+	if len(uom) > 0 {
+		for _, orders := range uom {
+			err := s.handleError(s.updateOrders(orders))
 			if err != nil {
-				s.logger.Error(err)
+				return err
 			}
 		}
 	}
+
+	if len(idForDelete) > 0 {
+		return s.handleError(s.Storage.CancelByIdList(idForDelete, models.OrderTypeCanceled))
+	}
+	return nil
 }
 
 type Service struct {
@@ -393,6 +401,24 @@ type Service struct {
 	addressRepository  *address.Repository
 	liquidityPool      *liquidity_pool.Repository
 	updateOrderChannel chan []models.TxTagDetailsOrder
+	strict             bool // errors stop the block, set for the database transaction of strict block mode
+}
+
+// WithTx Return service which writes orders in the transaction
+func (s *Service) WithTx(tx *pg.Tx) *Service {
+	service := *s
+	service.Storage = s.Storage.WithTx(tx)
+	service.strict = true
+	return &service
+}
+
+// handleError Return the error in strict block mode, otherwise log it and go on with the block
+func (s *Service) handleError(err error) error {
+	if err == nil || s.strict {
+		return err
+	}
+	s.logger.Error(err)
+	return nil
 }
 
 func (s *Service) UpdateOrderChannel() chan []models.TxTagDetailsOrder {
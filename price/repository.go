@@ -0,0 +1,30 @@
+package price
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+)
+
+type Repository struct {
+	db *pg.DB
+}
+
+func NewRepository(db *pg.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// GetLastPrices Return the latest known price of each coin
+func (r *Repository) GetLastPrices() ([]models.CoinPrice, error) {
+	var list []models.CoinPrice
+	_, err := r.db.Query(&list, `
+		SELECT DISTINCT ON (coin_id) * FROM coin_prices ORDER BY coin_id, block_id DESC;
+	`)
+	return list, err
+}
+
+func (r *Repository) SavePrices(list []models.CoinPrice) error {
+	_, err := r.db.Model(&list).OnConflict("(coin_id, block_id) DO UPDATE").Insert()
+	return err
+}
@@ -0,0 +1,369 @@
+package price
+
+import (
+	"container/heap"
+	"github.com/MinterTeam/minter-explorer-extender/v2/coin"
+	"github.com/MinterTeam/minter-explorer-extender/v2/env"
+	"github.com/MinterTeam/minter-explorer-extender/v2/liquidity_pool"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/sirupsen/logrus"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const precision = 256
+
+const (
+	// fullRefreshBlocks Reload all coins and pools with this interval to catch changes missed by touched-only loads
+	fullRefreshBlocks = 720
+	// touchedOverlapBlocks Coins and pools are written by async workers, so touched rows are searched a bit behind the last load
+	touchedOverlapBlocks = 10
+)
+
+type Service struct {
+	env                     *env.ExtenderEnvironment
+	repository              *Repository
+	coinRepository          *coin.Repository
+	liquidityPoolRepository *liquidity_pool.Repository
+	logger                  *logrus.Entry
+	jobUpdatePrices         chan uint64
+	priceChanges            chan<- []models.CoinPrice
+	prices                  map[uint64]models.CoinPrice
+	mx                      sync.RWMutex
+	coins                   map[uint]*models.Coin
+	pools                   map[uint64]models.LiquidityPool
+	loadedHeight            uint64
+	refreshedHeight         uint64
+}
+
+func NewService(env *env.ExtenderEnvironment, repository *Repository, coinRepository *coin.Repository,
+	liquidityPoolRepository *liquidity_pool.Repository, priceChanges chan<- []models.CoinPrice, logger *logrus.Entry) *Service {
+	return &Service{
+		env:                     env,
+		repository:              repository,
+		coinRepository:          coinRepository,
+		liquidityPoolRepository: liquidityPoolRepository,
+		priceChanges:            priceChanges,
+		logger:                  logger,
+		jobUpdatePrices:         make(chan uint64, 100),
+		prices:                  make(map[uint64]models.CoinPrice),
+		coins:                   make(map[uint]*models.Coin),
+		pools:                   make(map[uint64]models.LiquidityPool),
+	}
+}
+
+func (s *Service) GetUpdatePricesJobChannel() chan uint64 {
+	return s.jobUpdatePrices
+}
+
+// UpdatePricesWorker Recalculate prices after each block.
+// Prices are taken from the current state, so when the worker falls behind only the latest height is handled
+func (s *Service) UpdatePricesWorker(jobs <-chan uint64) {
+	list, err := s.repository.GetLastPrices()
+	if err != nil {
+		s.logger.Error(err)
+	}
+	s.mx.Lock()
+	for _, p := range list {
+		s.prices[p.CoinID] = p
+	}
+	s.mx.Unlock()
+
+	for height := range jobs {
+		for len(jobs) > 0 {
+			height = <-jobs
+		}
+		err = s.UpdatePrices(height)
+		if err != nil {
+			s.logger.Error(err)
+		}
+	}
+}
+
+// GetPrice Return the last calculated price of coin in base coin
+func (s *Service) GetPrice(coinId uint64) (*big.Float, bool) {
+	s.mx.RLock()
+	p, ok := s.prices[coinId]
+	s.mx.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return newFloat(p.PriceBip)
+}
+
+// EstimateInBip Return value of coin amount in base coin pips, zero if the coin has no price yet
+func (s *Service) EstimateInBip(coinId uint64, value string) *big.Int {
+	result := big.NewInt(0)
+	price, ok := s.GetPrice(coinId)
+	if !ok {
+		return result
+	}
+	amount, ok := newFloat(value)
+	if !ok {
+		return result
+	}
+	amount.Mul(amount, price).Int(result)
+	return result
+}
+
+// UpdatePrices Recalculate prices of all coins at height.
+// Only coins and pools touched since the previous call are loaded, the full state is reloaded every fullRefreshBlocks
+func (s *Service) UpdatePrices(height uint64) error {
+	err := s.loadState(height)
+	if err != nil {
+		return err
+	}
+
+	coins := make([]*models.Coin, 0, len(s.coins))
+	for _, c := range s.coins {
+		coins = append(coins, c)
+	}
+	pools := make([]models.LiquidityPool, 0, len(s.pools))
+	for _, lp := range s.pools {
+		pools = append(pools, lp)
+	}
+
+	calculated := calculatePrices(coins, pools)
+
+	var usdRate *big.Float
+	if s.env.UsdCoinId > 0 {
+		if p, ok := calculated[s.env.UsdCoinId]; ok && p.value.Sign() > 0 {
+			usdRate = p.value
+		}
+	}
+
+	now := time.Now()
+	var changed []models.CoinPrice
+	s.mx.RLock()
+	for coinId, p := range calculated {
+		cp := models.CoinPrice{
+			BlockID:   height,
+			CoinID:    coinId,
+			PriceBip:  p.value.Text('f', 18),
+			Source:    p.source,
+			CreatedAt: now,
+		}
+		if usdRate != nil {
+			cp.PriceUsd = new(big.Float).SetPrec(precision).Quo(p.value, usdRate).Text('f', 18)
+		}
+		last, ok := s.prices[coinId]
+		if ok && last.PriceBip == cp.PriceBip && last.PriceUsd == cp.PriceUsd && last.Source == cp.Source {
+			continue
+		}
+		changed = append(changed, cp)
+	}
+	s.mx.RUnlock()
+
+	if len(changed) > 0 {
+		err = s.repository.SavePrices(changed)
+		if err != nil {
+			return err
+		}
+		s.mx.Lock()
+		for _, cp := range changed {
+			s.prices[cp.CoinID] = cp
+		}
+		s.mx.Unlock()
+
+		s.priceChanges <- changed
+	}
+
+	return s.updatePoolsLiquidityBip(pools, calculated)
+}
+
+// loadState Keep coins and pools used by the price calculation in memory
+func (s *Service) loadState(height uint64) error {
+	if len(s.coins) == 0 || height < s.loadedHeight || height >= s.refreshedHeight+fullRefreshBlocks {
+		coins, err := s.coinRepository.GetAllCoins()
+		if err != nil {
+			return err
+		}
+		pools, err := s.liquidityPoolRepository.GetAll()
+		if err != nil {
+			return err
+		}
+		s.coins = make(map[uint]*models.Coin, len(coins))
+		for _, c := range coins {
+			s.coins[c.ID] = c
+		}
+		s.pools = make(map[uint64]models.LiquidityPool, len(pools))
+		for _, lp := range pools {
+			s.pools[lp.Id] = lp
+		}
+		s.loadedHeight = height
+		s.refreshedHeight = height
+		return nil
+	}
+
+	fromHeight := uint64(0)
+	if s.loadedHeight > touchedOverlapBlocks {
+		fromHeight = s.loadedHeight - touchedOverlapBlocks
+	}
+	coins, err := s.coinRepository.GetChangedSince(fromHeight)
+	if err != nil {
+		return err
+	}
+	pools, err := s.liquidityPoolRepository.GetUpdatedSince(fromHeight)
+	if err != nil {
+		return err
+	}
+	for _, c := range coins {
+		if c.DeletedAt != nil {
+			delete(s.coins, c.ID)
+			continue
+		}
+		s.coins[c.ID] = c
+	}
+	for _, lp := range pools {
+		s.pools[lp.Id] = lp
+	}
+	s.loadedHeight = height
+	return nil
+}
+
+func (s *Service) updatePoolsLiquidityBip(pools []models.LiquidityPool, prices map[uint64]*coinPrice) error {
+	var list []models.LiquidityPool
+	for _, lp := range pools {
+		first, firstOk := prices[lp.FirstCoinId]
+		second, secondOk := prices[lp.SecondCoinId]
+		if !firstOk || !secondOk {
+			continue
+		}
+		firstVolume, ok := newFloat(lp.FirstCoinVolume)
+		if !ok {
+			continue
+		}
+		secondVolume, ok := newFloat(lp.SecondCoinVolume)
+		if !ok {
+			continue
+		}
+		liquidityBip := firstVolume.Mul(firstVolume, first.value)
+		liquidityBip.Add(liquidityBip, secondVolume.Mul(secondVolume, second.value))
+		value, _ := liquidityBip.Int(nil)
+		if value.String() == lp.LiquidityBip {
+			continue
+		}
+		list = append(list, models.LiquidityPool{
+			Id:           lp.Id,
+			LiquidityBip: value.String(),
+		})
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	err := s.liquidityPoolRepository.UpdateLiquidityBip(list)
+	if err != nil {
+		return err
+	}
+	for _, item := range list {
+		lp := s.pools[item.Id]
+		lp.LiquidityBip = item.LiquidityBip
+		s.pools[item.Id] = lp
+	}
+	return nil
+}
+
+type coinPrice struct {
+	value  *big.Float
+	source string
+}
+
+// calculatePrices Price base coin as 1, bonded coins by the bonding curve
+// and all other coins by the pool route with the best liquidity
+func calculatePrices(coins []*models.Coin, pools []models.LiquidityPool) map[uint64]*coinPrice {
+	prices := map[uint64]*coinPrice{
+		0: {value: new(big.Float).SetPrec(precision).SetInt64(1), source: models.CoinPriceSourceBase},
+	}
+
+	for _, c := range coins {
+		if c.ID == 0 || c.Crr == 0 {
+			continue
+		}
+		p, ok := newFloat(coin.CalculatePrice(c.Volume, c.Reserve, c.Crr))
+		if !ok || p.Sign() <= 0 {
+			continue
+		}
+		prices[uint64(c.ID)] = &coinPrice{value: p, source: models.CoinPriceSourceBancor}
+	}
+
+	coinPools := make(map[uint64][]*poolEdge)
+	for _, lp := range pools {
+		firstVolume, ok := newFloat(lp.FirstCoinVolume)
+		if !ok || firstVolume.Sign() <= 0 {
+			continue
+		}
+		secondVolume, ok := newFloat(lp.SecondCoinVolume)
+		if !ok || secondVolume.Sign() <= 0 {
+			continue
+		}
+		coinPools[lp.FirstCoinId] = append(coinPools[lp.FirstCoinId], &poolEdge{
+			from: lp.FirstCoinId, to: lp.SecondCoinId, fromVolume: firstVolume, toVolume: secondVolume,
+		})
+		coinPools[lp.SecondCoinId] = append(coinPools[lp.SecondCoinId], &poolEdge{
+			from: lp.SecondCoinId, to: lp.FirstCoinId, fromVolume: secondVolume, toVolume: firstVolume,
+		})
+	}
+
+	// Widest path: always extend prices through the pool with the largest liquidity in base coin
+	queue := new(edgeQueue)
+	push := func(coinId uint64) {
+		for _, e := range coinPools[coinId] {
+			if _, ok := prices[e.to]; ok {
+				continue
+			}
+			heap.Push(queue, &queueItem{
+				edge:      e,
+				liquidity: new(big.Float).SetPrec(precision).Mul(e.fromVolume, prices[coinId].value),
+			})
+		}
+	}
+	for coinId := range prices {
+		push(coinId)
+	}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(*queueItem)
+		if _, ok := prices[item.edge.to]; ok {
+			continue
+		}
+		p := new(big.Float).SetPrec(precision).Mul(prices[item.edge.from].value, item.edge.fromVolume)
+		p.Quo(p, item.edge.toVolume)
+		prices[item.edge.to] = &coinPrice{value: p, source: models.CoinPriceSourcePool}
+		push(item.edge.to)
+	}
+
+	return prices
+}
+
+type poolEdge struct {
+	from       uint64
+	to         uint64
+	fromVolume *big.Float
+	toVolume   *big.Float
+}
+
+type queueItem struct {
+	edge      *poolEdge
+	liquidity *big.Float
+}
+
+type edgeQueue []*queueItem
+
+func (q edgeQueue) Len() int            { return len(q) }
+func (q edgeQueue) Less(i, j int) bool  { return q[i].liquidity.Cmp(q[j].liquidity) > 0 }
+func (q edgeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *edgeQueue) Push(x interface{}) { *q = append(*q, x.(*queueItem)) }
+func (q *edgeQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+func newFloat(value string) (*big.Float, bool) {
+	if value == "" {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(precision).SetString(value)
+}
@@ -0,0 +1,101 @@
+package price
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"testing"
+)
+
+func TestCalculatePrices(t *testing.T) {
+	type want struct {
+		price  string
+		source string
+	}
+	pool := func(first, second uint64, firstVolume, secondVolume string) models.LiquidityPool {
+		return models.LiquidityPool{FirstCoinId: first, SecondCoinId: second, FirstCoinVolume: firstVolume, SecondCoinVolume: secondVolume}
+	}
+
+	tests := []struct {
+		name  string
+		coins []*models.Coin
+		pools []models.LiquidityPool
+		want  map[uint64]want
+	}{
+		{
+			name: "base coin only",
+			want: map[uint64]want{0: {"1", models.CoinPriceSourceBase}},
+		},
+		{
+			name:  "bonding curve",
+			coins: []*models.Coin{{ID: 1, Volume: "1000", Reserve: "500", Crr: 50}, {ID: 2, Volume: "1000", Crr: 0}},
+			want: map[uint64]want{
+				0: {"1", models.CoinPriceSourceBase},
+				1: {"1", models.CoinPriceSourceBancor},
+			},
+		},
+		{
+			name:  "pool with base coin",
+			pools: []models.LiquidityPool{pool(0, 2, "100", "400")},
+			want: map[uint64]want{
+				0: {"1", models.CoinPriceSourceBase},
+				2: {"0.25", models.CoinPriceSourcePool},
+			},
+		},
+		{
+			name:  "route of two pools",
+			pools: []models.LiquidityPool{pool(0, 2, "100", "400"), pool(3, 2, "100", "400")},
+			want: map[uint64]want{
+				0: {"1", models.CoinPriceSourceBase},
+				2: {"0.25", models.CoinPriceSourcePool},
+				3: {"1", models.CoinPriceSourcePool},
+			},
+		},
+		{
+			name:  "route with the best liquidity",
+			pools: []models.LiquidityPool{pool(0, 2, "100", "400"), pool(0, 4, "10", "10"), pool(2, 4, "4000", "500")},
+			want: map[uint64]want{
+				0: {"1", models.CoinPriceSourceBase},
+				2: {"0.25", models.CoinPriceSourcePool},
+				4: {"2", models.CoinPriceSourcePool},
+			},
+		},
+		{
+			name:  "bonding curve is kept over pool",
+			coins: []*models.Coin{{ID: 1, Volume: "1000", Reserve: "500", Crr: 50}},
+			pools: []models.LiquidityPool{pool(0, 1, "100", "50"), pool(1, 5, "10", "20")},
+			want: map[uint64]want{
+				0: {"1", models.CoinPriceSourceBase},
+				1: {"1", models.CoinPriceSourceBancor},
+				5: {"0.5", models.CoinPriceSourcePool},
+			},
+		},
+		{
+			name:  "empty pool",
+			pools: []models.LiquidityPool{pool(0, 6, "0", "0"), pool(0, 7, "", "100")},
+			want:  map[uint64]want{0: {"1", models.CoinPriceSourceBase}},
+		},
+		{
+			name:  "pool without priced coins",
+			pools: []models.LiquidityPool{pool(8, 9, "100", "100")},
+			want:  map[uint64]want{0: {"1", models.CoinPriceSourceBase}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculatePrices(tt.coins, tt.pools)
+			if len(got) != len(tt.want) {
+				t.Errorf("calculatePrices() priced %d coins, want %d", len(got), len(tt.want))
+			}
+			for coinId, w := range tt.want {
+				p, ok := got[coinId]
+				if !ok {
+					t.Errorf("coin %d has no price", coinId)
+					continue
+				}
+				expected, _ := newFloat(w.price)
+				if p.value.Text('f', 18) != expected.Text('f', 18) || p.source != w.source {
+					t.Errorf("coin %d price = %s %s, want %s %s", coinId, p.value.Text('f', 18), p.source, w.price, w.source)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package stake
+
+import (
+	"context"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-extender/v2/validator"
+	"github.com/go-pg/pg/v10"
+)
+
+type Repository struct {
+	db *pg.DB
+}
+
+func NewRepository(db *pg.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// BlockChanges Stake changes of a block which are written in one database transaction
+type BlockChanges struct {
+	Height        uint64
+	Stakes        []*models.Stake // value changes of active stakes
+	Kicked        []*models.Stake
+	WaitList      []*models.Stake // new values of wait list stakes
+	MovedStakeIds []uint64        // moved stakes which have reached the destination validator
+	UnbondIds     []uint64        // unbonds which have returned to the balance
+	// owners whose active stakes are locked by LockStake transactions of the block until LockedUntil
+	LockedOwnerIds []uint
+	LockedUntil    uint64
+}
+
+// ApplyBlock Add value changes of the block to active stakes, remove kicked stakes and stakes which have nothing left,
+// set wait list stakes changed by the block, lock stakes of LockStake senders, complete matured moved stakes and unbonds
+// and recalculate total stake of validators whose stakes are changed.
+// Rows already changed by the same or a later block are left untouched, so a block can be safely applied twice.
+// Return removed active stakes
+func (r *Repository) ApplyBlock(c *BlockChanges) ([]*models.Stake, error) {
+	var removed []*models.Stake
+	err := r.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		if len(c.Stakes) > 0 {
+			_, err := tx.Model(&c.Stakes).
+				OnConflict("(owner_address_id, validator_id, coin_id, is_kicked) DO UPDATE").
+				Set("value = stake.value + EXCLUDED.value").
+				Set("block_id = EXCLUDED.block_id").
+				Where("stake.block_id < EXCLUDED.block_id").
+				Insert()
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, k := range c.Kicked {
+			_, err := tx.Model((*models.Stake)(nil)).
+				Where("owner_address_id = ?", k.OwnerAddressID).
+				Where("validator_id = ?", k.ValidatorID).
+				Where("coin_id = ?", k.CoinID).
+				Where("is_kicked = false").
+				Where("block_id < ?", c.Height).
+				Delete()
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Model(&removed).
+			Where("block_id = ?", c.Height).
+			Where("is_kicked = false").
+			Where("value <= 0").
+			Returning("owner_address_id, validator_id, coin_id").
+			Delete()
+		if err != nil {
+			return err
+		}
+
+		err = saveWaitList(tx, c.Height, c.WaitList, false)
+		if err != nil {
+			return err
+		}
+
+		if len(c.LockedOwnerIds) > 0 {
+			_, err = tx.Model((*models.Stake)(nil)).
+				Set("is_locked = true").
+				Set("locked_until_block_id = greatest(locked_until_block_id, ?)", c.LockedUntil).
+				Where("owner_address_id IN (?)", pg.In(c.LockedOwnerIds)).
+				Where("is_kicked = false").
+				Update()
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(c.MovedStakeIds) > 0 {
+			_, err = tx.Model((*models.MovedStake)(nil)).
+				Set("status = ?", models.StakeOperationCompleted).
+				Where("id IN (?)", pg.In(c.MovedStakeIds)).
+				Update()
+			if err != nil {
+				return err
+			}
+		}
+		if len(c.UnbondIds) > 0 {
+			_, err = tx.Model((*models.Unbond)(nil)).
+				Set("status = ?", models.StakeOperationCompleted).
+				Where("id IN (?)", pg.In(c.UnbondIds)).
+				Update()
+			if err != nil {
+				return err
+			}
+		}
+
+		err = updateLockFlags(tx, c.Height)
+		if err != nil {
+			return err
+		}
+		err = updateBipValue(tx, c.Height)
+		if err != nil {
+			return err
+		}
+
+		// total stake of validators whose stakes are changed, kicked or removed by the block
+		var validatorIds []uint
+		err = tx.Model((*models.Stake)(nil)).
+			ColumnExpr("DISTINCT validator_id").
+			Where("block_id = ?", c.Height).
+			Where("is_kicked = false").
+			Select(&validatorIds)
+		if err != nil {
+			return err
+		}
+		for _, stk := range append(c.Kicked, removed...) {
+			validatorIds = append(validatorIds, stk.ValidatorID)
+		}
+		return validator.UpdateTotalStake(tx, validatorIds)
+	})
+	return removed, err
+}
+
+// GetMaturedMovedStakes Return pending moved stakes due at the height or earlier
+func (r *Repository) GetMaturedMovedStakes(height uint64) ([]*models.MovedStake, error) {
+	var list []*models.MovedStake
+	err := r.db.Model(&list).
+		Relation("Address").
+		Where("moved_stake.block_id <= ?", height).
+		Where("moved_stake.status = ?", models.StakeOperationPending).
+		Select()
+	return list, err
+}
+
+// GetMaturedUnbonds Return pending unbonds due at the height or earlier
+func (r *Repository) GetMaturedUnbonds(height uint64) ([]*models.Unbond, error) {
+	var list []*models.Unbond
+	err := r.db.Model(&list).
+		Relation("Address").
+		Where("unbond.block_id <= ?", height).
+		Where("unbond.status = ?", models.StakeOperationPending).
+		Select()
+	return list, err
+}
+
+// GetPendingMovedStakes Return pending moved stakes of addresses, the oldest first
+func (r *Repository) GetPendingMovedStakes(addressIds []uint) ([]*models.MovedStake, error) {
+	var list []*models.MovedStake
+	err := r.db.Model(&list).
+		Where("status = ?", models.StakeOperationPending).
+		Where("address_id IN (?)", pg.In(addressIds)).
+		Order("id").
+		Select()
+	return list, err
+}
+
+// GetPendingUnbonds Return pending unbonds of addresses, the oldest first
+func (r *Repository) GetPendingUnbonds(addressIds []uint) ([]*models.Unbond, error) {
+	var list []*models.Unbond
+	err := r.db.Model(&list).
+		Where("status = ?", models.StakeOperationPending).
+		Where("address_id IN (?)", pg.In(addressIds)).
+		Order("id").
+		Select()
+	return list, err
+}
+
+// GetWaitList Return wait list stakes of addresses
+func (r *Repository) GetWaitList(addressIds []uint) ([]*models.Stake, error) {
+	var list []*models.Stake
+	if len(addressIds) == 0 {
+		return list, nil
+	}
+	err := r.db.Model(&list).
+		Column("id", "owner_address_id", "validator_id", "coin_id", "value", "block_id").
+		Where("is_kicked = true").
+		Where("owner_address_id IN (?)", pg.In(addressIds)).
+		Select()
+	return list, err
+}
+
+// GetWaitListAddresses Return all addresses which have stakes in the wait list
+func (r *Repository) GetWaitListAddresses() ([]string, error) {
+	var list []string
+	_, err := r.db.Query(&list, `
+		SELECT DISTINCT addresses.address FROM stakes
+		JOIN addresses ON addresses.id = stakes.owner_address_id
+		WHERE stakes.is_kicked = true;
+	`)
+	return list, err
+}
+
+// ReconcileWaitList Set wait list stakes by the node state at the height
+func (r *Repository) ReconcileWaitList(height uint64, waitList []*models.Stake) error {
+	return r.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		err := saveWaitList(tx, height, waitList, true)
+		if err != nil {
+			return err
+		}
+		return updateBipValue(tx, height)
+	})
+}
+
+// GetActiveStakes Return all stakes except the wait list
+func (r *Repository) GetActiveStakes() ([]*models.Stake, error) {
+	var list []*models.Stake
+	err := r.db.Model(&list).
+		Column("id", "owner_address_id", "validator_id", "coin_id", "value", "block_id").
+		Where("is_kicked = false").
+		Select()
+	return list, err
+}
+
+// Reconcile Overwrite and remove active stakes by the node state at the height.
+// Stakes changed after the height are skipped, they will be checked by the next reconciliation
+func (r *Repository) Reconcile(height uint64, stakes []*models.Stake, removeIds []uint, chunkSize int) error {
+	return r.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		for start := 0; start < len(stakes); start += chunkSize {
+			end := start + chunkSize
+			if end > len(stakes) {
+				end = len(stakes)
+			}
+			chunk := stakes[start:end]
+			_, err := tx.Model(&chunk).
+				OnConflict("(owner_address_id, validator_id, coin_id, is_kicked) DO UPDATE").
+				Set("value = EXCLUDED.value").
+				Set("bip_value = EXCLUDED.bip_value").
+				Set("block_id = EXCLUDED.block_id").
+				Where("stake.block_id <= EXCLUDED.block_id").
+				Insert()
+			if err != nil {
+				return err
+			}
+		}
+
+		for start := 0; start < len(removeIds); start += chunkSize {
+			end := start + chunkSize
+			if end > len(removeIds) {
+				end = len(removeIds)
+			}
+			_, err := tx.Model((*models.Stake)(nil)).
+				Where("id IN (?)", pg.In(removeIds[start:end])).
+				Where("is_kicked = false").
+				Where("block_id <= ?", height).
+				Delete()
+			if err != nil {
+				return err
+			}
+		}
+		return updateLockFlags(tx, height)
+	})
+}
+
+// saveWaitList Set values of wait list stakes, a zero value removes the stake.
+// Rows changed by a later block are left untouched, rows changed by the same block are overwritten only by reconciliation
+func saveWaitList(tx *pg.Tx, height uint64, list []*models.Stake, reconcile bool) error {
+	guard := "<"
+	if reconcile {
+		guard = "<="
+	}
+
+	var keep []*models.Stake
+	for _, stk := range list {
+		if stk.Value != "0" {
+			keep = append(keep, stk)
+			continue
+		}
+		_, err := tx.Model((*models.Stake)(nil)).
+			Where("owner_address_id = ?", stk.OwnerAddressID).
+			Where("validator_id = ?", stk.ValidatorID).
+			Where("coin_id = ?", stk.CoinID).
+			Where("is_kicked = true").
+			Where("block_id "+guard+" ?", height).
+			Delete()
+		if err != nil {
+			return err
+		}
+	}
+	if len(keep) == 0 {
+		return nil
+	}
+	_, err := tx.Model(&keep).
+		OnConflict("(owner_address_id, validator_id, coin_id, is_kicked) DO UPDATE").
+		Set("value = EXCLUDED.value").
+		Set("block_id = EXCLUDED.block_id").
+		Where("stake.block_id " + guard + " EXCLUDED.block_id").
+		Insert()
+	return err
+}
+
+// updateLockFlags Lock active stakes changed by the block whose owners have stakes locked by a LockStake transaction
+func updateLockFlags(tx *pg.Tx, height uint64) error {
+	_, err := tx.Exec(`
+		UPDATE stakes SET is_locked = true, locked_until_block_id = l.due_block_id
+		FROM (
+			SELECT owner_address_id, max(due_block_id) AS due_block_id FROM locks
+			WHERE type = ? AND status = ? AND due_block_id > ?
+			GROUP BY owner_address_id
+		) l
+		WHERE stakes.owner_address_id = l.owner_address_id AND stakes.block_id = ? AND stakes.is_kicked = false
+		  AND stakes.is_locked = false;
+	`, models.LockTypeStake, models.LockStatusLocked, height, height)
+	return err
+}
+
+// updateBipValue Revalue stakes changed by the block with the last known coin prices
+func updateBipValue(tx *pg.Tx, height uint64) error {
+	_, err := tx.Exec(`
+		UPDATE stakes SET bip_value = CASE WHEN stakes.coin_id = 0 THEN stakes.value ELSE coalesce(trunc(stakes.value * p.price_bip), 0) END
+		FROM stakes s
+		LEFT JOIN LATERAL (
+			SELECT price_bip FROM coin_prices WHERE coin_prices.coin_id = s.coin_id ORDER BY block_id DESC LIMIT 1
+		) p ON true
+		WHERE stakes.id = s.id AND s.block_id = ?;
+	`, height)
+	return err
+}
@@ -0,0 +1,740 @@
+package stake
+
+import (
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/address"
+	"github.com/MinterTeam/minter-explorer-extender/v2/broadcast"
+	"github.com/MinterTeam/minter-explorer-extender/v2/env"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-extender/v2/validator"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"github.com/MinterTeam/minter-go-sdk/v2/api/grpc_client"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/sirupsen/logrus"
+	"math/big"
+	"time"
+)
+
+const (
+	opDelegate = iota
+	opUnbond
+	opMoveStake
+	opSlash
+	opKick
+	opMovedStakeArrived
+	opLockStake
+	opUnbondReturned
+)
+
+// maturityFallbackBlocks Pending moved stakes and unbonds are completed by node events,
+// a row without the event is completed and credited this number of blocks after its computed due block
+const maturityFallbackBlocks = 1000
+
+// operation Stake change made by a transaction or an event of the block
+type operation struct {
+	kind      int
+	address   string
+	publicKey string
+	coinId    uint64
+	value     *big.Int
+	waitList  bool // a moved stake has arrived to the wait list of the destination validator
+}
+
+type stakeKey struct {
+	addressId   uint
+	validatorId uint
+	coinId      uint
+}
+
+// snapshot Active stakes of all candidates at the height
+type snapshot struct {
+	height uint64
+	stakes []*models.Stake
+}
+
+type Service struct {
+	env                 *env.ExtenderEnvironment
+	nodeApi             *grpc_client.Client
+	repository          *Repository
+	addressRepository   *address.Repository
+	validatorRepository *validator.Repository
+	broadcastService    *broadcast.Service
+	updateBalances      chan<- []string
+	jobReconcile        chan uint64
+	snapshots           chan *snapshot
+	reconciled          bool   // a reconciliation has been requested since start
+	reconcileHeight     uint64 // height of the pending reconciliation
+	removedSince        map[stakeKey]struct{}
+	logger              *logrus.Entry
+}
+
+func NewService(env *env.ExtenderEnvironment, nodeApi *grpc_client.Client, repository *Repository,
+	addressRepository *address.Repository, validatorRepository *validator.Repository, broadcastService *broadcast.Service,
+	updateBalances chan<- []string, logger *logrus.Entry) *Service {
+	return &Service{
+		env:                 env,
+		nodeApi:             nodeApi,
+		repository:          repository,
+		addressRepository:   addressRepository,
+		validatorRepository: validatorRepository,
+		broadcastService:    broadcastService,
+		updateBalances:      updateBalances,
+		jobReconcile:        make(chan uint64, 1),
+		snapshots:           make(chan *snapshot, 1),
+		logger: logger.WithFields(logrus.Fields{
+			"service": "Stake ledger",
+		}),
+	}
+}
+
+// HandleLedgerBlock Apply a reconciliation prepared by ReconcileWorker if it is ready, then stake changes of the block.
+// Blocks must be handled in order
+func (s *Service) HandleLedgerBlock(b *api_pb.BlockResponse) error {
+	select {
+	case snap := <-s.snapshots:
+		err := s.reconcile(snap)
+		if err != nil {
+			s.logger.WithField("block", snap.height).Error(err)
+		}
+		s.reconcileHeight = 0
+		s.removedSince = nil
+	default:
+	}
+
+	err := s.HandleBlock(b)
+	s.requestReconcile(b.Height)
+	return err
+}
+
+// ReconcileWorker Load stakes of all candidates from node at the requested height
+// and pass them to HandleLedgerBlock, the request doesn't stop handling of blocks
+func (s *Service) ReconcileWorker() {
+	for height := range s.jobReconcile {
+		start := time.Now()
+		snap, err := s.loadSnapshot(height)
+		if err != nil {
+			s.logger.WithField("block", height).Error(err)
+			snap = &snapshot{height: height}
+		}
+		s.logger.Info(fmt.Sprintf("Block: %d Stakes getting time: %s", height, time.Since(start)))
+		s.snapshots <- snap
+	}
+}
+
+// HandleBlock Apply delegations, unbonds, moved stakes, locks, kicks and slashes of the block to stakes and the wait list,
+// complete moved stakes and unbonds the node reports as matured at the block.
+// A moved stake without the node event is credited to the destination validator and an unbond without it
+// refreshes the owner's balance when the fallback due block is reached
+func (s *Service) HandleBlock(b *api_pb.BlockResponse) error {
+	txOps, eventOps, err := parseOperations(b)
+	if err != nil {
+		return err
+	}
+
+	var moved []*models.MovedStake
+	var unbonds []*models.Unbond
+	if b.Height > maturityFallbackBlocks {
+		moved, err = s.repository.GetMaturedMovedStakes(b.Height - maturityFallbackBlocks)
+		if err != nil {
+			return err
+		}
+		unbonds, err = s.repository.GetMaturedUnbonds(b.Height - maturityFallbackBlocks)
+		if err != nil {
+			return err
+		}
+		for _, u := range unbonds {
+			s.logger.WithFields(logrus.Fields{"block": b.Height, "tx": u.TransactionHash}).Warning("unbond is returned to the balance without the node event")
+		}
+	}
+	ops := append(txOps, eventOps...)
+	if len(ops) == 0 && len(unbonds) == 0 && len(moved) == 0 {
+		return nil
+	}
+
+	addresses := make([]string, len(ops))
+	for i, op := range ops {
+		addresses[i] = helpers.RemovePrefix(op.address)
+	}
+	addressIds, err := s.addressRepository.FindIdsOrCreate(addresses)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]stakeKey, len(ops))
+	var ownerIds []uint
+	for i, op := range ops {
+		// LockStake locks all stakes of the address
+		if op.kind == opLockStake {
+			keys[i] = stakeKey{addressId: addressIds[addresses[i]]}
+			continue
+		}
+		validatorId, err := s.validatorRepository.FindIdByPk(helpers.RemovePrefix(op.publicKey))
+		if err != nil {
+			return err
+		}
+		keys[i] = stakeKey{addressId: addressIds[addresses[i]], validatorId: validatorId, coinId: uint(op.coinId)}
+		ownerIds = append(ownerIds, keys[i].addressId)
+	}
+
+	eventMoved, eventUnbonds, err := s.matchPending(ops, keys)
+	if err != nil {
+		return err
+	}
+	// a row completed by the event of this block is credited by the event
+	matched := make(map[uint64]struct{}, len(eventMoved))
+	for _, ms := range eventMoved {
+		matched[ms.ID] = struct{}{}
+	}
+	var due []*models.MovedStake
+	for _, ms := range moved {
+		if _, ok := matched[ms.ID]; ok {
+			continue
+		}
+		s.logger.WithFields(logrus.Fields{"block": b.Height, "tx": ms.TransactionHash}).Warning("moved stake is credited to the destination validator without the node event")
+		due = append(due, ms)
+	}
+	moved = due
+	// the next reconciliation corrects the destination stake if the moved stake went to the wait list
+	dueOps, dueKeys, err := dueMovedStakeOperations(moved)
+	if err != nil {
+		return err
+	}
+	ops = append(ops, dueOps...)
+	keys = append(keys, dueKeys...)
+
+	list, err := s.repository.GetWaitList(ownerIds)
+	if err != nil {
+		return err
+	}
+	waitList := make(map[stakeKey]*big.Int)
+	for _, stk := range list {
+		value, ok := big.NewInt(0).SetString(stk.Value, 10)
+		if !ok {
+			return fmt.Errorf("can't convert %s to big.Int", stk.Value)
+		}
+		waitList[stakeKey{addressId: stk.OwnerAddressID, validatorId: stk.ValidatorID, coinId: stk.CoinID}] = value
+	}
+
+	changed := applyOperations(ops, keys, waitList)
+
+	var changes, kickedList, waitListRows []*models.Stake
+	for key, delta := range changed.deltas {
+		if _, ok := changed.kicked[key]; ok || delta.Sign() == 0 {
+			continue
+		}
+		changes = append(changes, &models.Stake{
+			OwnerAddressID: key.addressId,
+			ValidatorID:    key.validatorId,
+			CoinID:         key.coinId,
+			Value:          delta.String(),
+			BipValue:       "0",
+			BlockID:        b.Height,
+		})
+	}
+	for key := range changed.kicked {
+		kickedList = append(kickedList, &models.Stake{
+			OwnerAddressID: key.addressId,
+			ValidatorID:    key.validatorId,
+			CoinID:         key.coinId,
+		})
+	}
+	var updates []models.WaitListUpdate
+	for key, op := range changed.waitListChanged {
+		waitListRows = append(waitListRows, &models.Stake{
+			OwnerAddressID: key.addressId,
+			ValidatorID:    key.validatorId,
+			CoinID:         key.coinId,
+			Value:          waitList[key].String(),
+			BipValue:       "0",
+			IsKicked:       true,
+			BlockID:        b.Height,
+		})
+		updates = append(updates, models.WaitListUpdate{
+			Address:     op.address,
+			PublicKey:   op.publicKey,
+			ValidatorID: key.validatorId,
+			CoinID:      key.coinId,
+			Value:       waitList[key].String(),
+			BlockID:     b.Height,
+		})
+	}
+	blockChanges := &BlockChanges{
+		Height:   b.Height,
+		Stakes:   changes,
+		Kicked:   kickedList,
+		WaitList: waitListRows,
+	}
+	if len(changed.lockedOwners) > 0 {
+		blockChanges.LockedUntil = b.Height + validator.UnbondPeriod(s.env.BaseCoin)
+		for id := range changed.lockedOwners {
+			blockChanges.LockedOwnerIds = append(blockChanges.LockedOwnerIds, id)
+		}
+	}
+	var unbondAddresses []string
+	for _, u := range unbonds {
+		unbondAddresses = append(unbondAddresses, u.Address.Address)
+	}
+	for _, op := range ops {
+		if op.kind == opUnbondReturned {
+			unbondAddresses = append(unbondAddresses, helpers.RemovePrefix(op.address))
+		}
+	}
+	moved = append(moved, eventMoved...)
+	unbonds = append(unbonds, eventUnbonds...)
+	for _, ms := range moved {
+		blockChanges.MovedStakeIds = append(blockChanges.MovedStakeIds, ms.ID)
+	}
+	for _, u := range unbonds {
+		blockChanges.UnbondIds = append(blockChanges.UnbondIds, u.ID)
+	}
+	if len(changes) == 0 && len(kickedList) == 0 && len(waitListRows) == 0 && len(unbonds) == 0 && len(moved) == 0 &&
+		len(changed.lockedOwners) == 0 && len(unbondAddresses) == 0 {
+		return nil
+	}
+
+	removed, err := s.repository.ApplyBlock(blockChanges)
+	if err != nil {
+		return err
+	}
+
+	if len(updates) > 0 {
+		s.broadcastService.WaitListChannel() <- updates
+	}
+	// unbonded values are returned to the balances of owners
+	if len(unbondAddresses) > 0 {
+		s.updateBalances <- unbondAddresses
+	}
+
+	// a pending reconciliation must not restore stakes removed after its height
+	if s.removedSince != nil {
+		for key := range changed.kicked {
+			s.removedSince[key] = struct{}{}
+		}
+		for _, stk := range removed {
+			s.removedSince[stakeKey{addressId: stk.OwnerAddressID, validatorId: stk.ValidatorID, coinId: stk.CoinID}] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// dueMovedStakeOperations Return arrivals of moved stakes completed without the node event with their stake keys
+func dueMovedStakeOperations(moved []*models.MovedStake) ([]*operation, []stakeKey, error) {
+	ops := make([]*operation, len(moved))
+	keys := make([]stakeKey, len(moved))
+	for i, ms := range moved {
+		value, ok := big.NewInt(0).SetString(ms.Value, 10)
+		if !ok {
+			return nil, nil, fmt.Errorf("can't convert %s to big.Int", ms.Value)
+		}
+		op := &operation{kind: opMovedStakeArrived, coinId: ms.CoinId, value: value}
+		if ms.Address != nil {
+			op.address = "Mx" + ms.Address.Address
+		}
+		ops[i] = op
+		keys[i] = stakeKey{addressId: uint(ms.AddressId), validatorId: uint(ms.ToValidatorId), coinId: uint(ms.CoinId)}
+	}
+	return ops, keys, nil
+}
+
+// ledgerChanges Changes of stakes made by operations of a block
+type ledgerChanges struct {
+	deltas          map[stakeKey]*big.Int // value changes of active stakes
+	kicked          map[stakeKey]struct{} // active stakes moved to the wait list
+	waitListChanged map[stakeKey]*operation
+	lockedOwners    map[uint]struct{}
+}
+
+// applyOperations Apply operations in their order to value changes of active stakes and to the wait list.
+// keys are stakes of the operations, waitList has current wait list values and gets the new ones
+func applyOperations(ops []*operation, keys []stakeKey, waitList map[stakeKey]*big.Int) *ledgerChanges {
+	changed := &ledgerChanges{
+		deltas:          make(map[stakeKey]*big.Int),
+		kicked:          make(map[stakeKey]struct{}),
+		waitListChanged: make(map[stakeKey]*operation),
+		lockedOwners:    make(map[uint]struct{}),
+	}
+	for i, op := range ops {
+		key := keys[i]
+		if op.kind == opLockStake {
+			changed.lockedOwners[key.addressId] = struct{}{}
+			continue
+		}
+		// the unbonded value was taken from the stake by the Unbond transaction
+		if op.kind == opUnbondReturned {
+			continue
+		}
+		if _, ok := changed.deltas[key]; !ok {
+			changed.deltas[key] = big.NewInt(0)
+		}
+		delta := changed.deltas[key]
+		wait, inWaitList := waitList[key]
+		inWaitList = inWaitList && wait.Sign() > 0
+
+		switch op.kind {
+		case opDelegate:
+			// the wait list stake returns to the validator with the new delegation
+			if inWaitList {
+				delta.Add(delta, wait)
+				waitList[key] = big.NewInt(0)
+				changed.waitListChanged[key] = ops[i]
+			}
+			delta.Add(delta, op.value)
+		case opUnbond:
+			// the wait list stake is unbonded first
+			if inWaitList {
+				rest := big.NewInt(0).Sub(op.value, wait)
+				if rest.Sign() < 0 {
+					waitList[key] = rest.Neg(rest)
+				} else {
+					waitList[key] = big.NewInt(0)
+					delta.Sub(delta, rest)
+				}
+				changed.waitListChanged[key] = ops[i]
+			} else {
+				delta.Sub(delta, op.value)
+			}
+		case opMoveStake, opSlash:
+			delta.Sub(delta, op.value)
+		case opMovedStakeArrived:
+			if !op.waitList {
+				delta.Add(delta, op.value)
+				break
+			}
+			// the destination validator has no room for the stake
+			if !inWaitList {
+				wait = big.NewInt(0)
+			}
+			waitList[key] = big.NewInt(0).Add(wait, op.value)
+			changed.waitListChanged[key] = ops[i]
+		case opKick:
+			// the whole stake is moved to the wait list
+			changed.kicked[key] = struct{}{}
+			delta.SetInt64(0)
+			if !inWaitList {
+				wait = big.NewInt(0)
+			}
+			waitList[key] = big.NewInt(0).Add(wait, op.value)
+			changed.waitListChanged[key] = ops[i]
+		}
+	}
+	return changed
+}
+
+// matchPending Return pending moved stakes and unbonds completed by StakeMoveEvent and UnbondEvent events,
+// the oldest row with the same address, validator, coin and value is taken for each event
+func (s *Service) matchPending(ops []*operation, keys []stakeKey) ([]*models.MovedStake, []*models.Unbond, error) {
+	var addressIds []uint
+	for i, op := range ops {
+		if op.kind == opMovedStakeArrived || op.kind == opUnbondReturned {
+			addressIds = append(addressIds, keys[i].addressId)
+		}
+	}
+	if len(addressIds) == 0 {
+		return nil, nil, nil
+	}
+	pendingMoved, err := s.repository.GetPendingMovedStakes(addressIds)
+	if err != nil {
+		return nil, nil, err
+	}
+	pendingUnbonds, err := s.repository.GetPendingUnbonds(addressIds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		moved   []*models.MovedStake
+		unbonds []*models.Unbond
+		used    = make(map[uint64]struct{})
+	)
+	for i, op := range ops {
+		key, value := keys[i], op.value.String()
+		switch op.kind {
+		case opMovedStakeArrived:
+			for _, ms := range pendingMoved {
+				if _, ok := used[ms.ID]; ok || uint(ms.AddressId) != key.addressId || uint(ms.ToValidatorId) != key.validatorId ||
+					uint(ms.CoinId) != key.coinId || ms.Value != value {
+					continue
+				}
+				used[ms.ID] = struct{}{}
+				moved = append(moved, ms)
+				break
+			}
+		case opUnbondReturned:
+			for _, u := range pendingUnbonds {
+				if _, ok := used[u.ID]; ok || u.AddressId != key.addressId || u.ValidatorId != key.validatorId ||
+					u.CoinId != key.coinId || u.Value != value {
+					continue
+				}
+				used[u.ID] = struct{}{}
+				unbonds = append(unbonds, u)
+				break
+			}
+		}
+	}
+	return moved, unbonds, nil
+}
+
+// parseOperations Return stake changes of successful transactions and of events of the block in the order they are applied by node
+func parseOperations(b *api_pb.BlockResponse) ([]*operation, []*operation, error) {
+	var txOps, eventOps []*operation
+	ops := &txOps
+	add := func(kind int, address, publicKey string, coinId uint64, value string) error {
+		v, ok := big.NewInt(0).SetString(value, 10)
+		if !ok {
+			return fmt.Errorf("can't convert %s to big.Int", value)
+		}
+		*ops = append(*ops, &operation{kind: kind, address: address, publicKey: publicKey, coinId: coinId, value: v})
+		return nil
+	}
+
+	for _, tx := range b.Transactions {
+		// failed transactions don't change stakes
+		if tx.Log != "" {
+			continue
+		}
+		var err error
+		switch transaction.Type(tx.Type) {
+		case transaction.TypeDeclareCandidacy:
+			txData := new(api_pb.DeclareCandidacyData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return nil, nil, err
+			}
+			err = add(opDelegate, tx.From, txData.PubKey, txData.Coin.Id, txData.Stake)
+		case transaction.TypeDelegate:
+			txData := new(api_pb.DelegateData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return nil, nil, err
+			}
+			err = add(opDelegate, tx.From, txData.PubKey, txData.Coin.Id, txData.Value)
+		case transaction.TypeUnbond:
+			txData := new(api_pb.UnbondData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return nil, nil, err
+			}
+			err = add(opUnbond, tx.From, txData.PubKey, txData.Coin.Id, txData.Value)
+		case transaction.TypeMoveStake:
+			// the stake reaches the destination validator in MoveStakeBlockCount blocks
+			txData := new(api_pb.MoveStakeData)
+			if err := tx.Data.UnmarshalTo(txData); err != nil {
+				return nil, nil, err
+			}
+			err = add(opMoveStake, tx.From, txData.FromPubKey, txData.Coin.Id, txData.Value)
+		case transaction.TypeLockStake:
+			// values of stakes don't change, they can't be unbonded or moved until the lock is due
+			err = add(opLockStake, tx.From, "", 0, "0")
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// moved stakes, slashes and kicks happen at the end of the block, after transactions
+	ops = &eventOps
+	for _, event := range b.Events {
+		var err error
+		switch {
+		case event.MessageIs(&api_pb.StakeMoveEvent{}):
+			// the stake moved by a MoveStake transaction reaches the destination validator
+			e := new(api_pb.StakeMoveEvent)
+			if err := event.UnmarshalTo(e); err != nil {
+				return nil, nil, err
+			}
+			err = add(opMovedStakeArrived, e.Address, e.ValidatorPubKey, e.Coin, e.Amount)
+			if err == nil {
+				eventOps[len(eventOps)-1].waitList = e.Waitlist
+			}
+		case event.MessageIs(&api_pb.UnbondEvent{}):
+			// the unbonded value returns to the balance of the owner
+			e := new(api_pb.UnbondEvent)
+			if err := event.UnmarshalTo(e); err != nil {
+				return nil, nil, err
+			}
+			err = add(opUnbondReturned, e.Address, e.ValidatorPubKey, e.Coin, e.Amount)
+		case event.MessageIs(&api_pb.SlashEvent{}):
+			e := new(api_pb.SlashEvent)
+			if err := event.UnmarshalTo(e); err != nil {
+				return nil, nil, err
+			}
+			err = add(opSlash, e.Address, e.ValidatorPubKey, e.Coin, e.Amount)
+		case event.MessageIs(&api_pb.StakeKickEvent{}):
+			e := new(api_pb.StakeKickEvent)
+			if err := event.UnmarshalTo(e); err != nil {
+				return nil, nil, err
+			}
+			err = add(opKick, e.Address, e.ValidatorPubKey, e.Coin, e.Amount)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return txOps, eventOps, nil
+}
+
+// requestReconcile Start the reconciliation on the first block and every APP_STAKES_RECONCILE_BLOCKS blocks
+func (s *Service) requestReconcile(height uint64) {
+	if s.env.StakesReconcileBlocks == 0 || s.reconcileHeight != 0 {
+		return
+	}
+	if s.reconciled && height%s.env.StakesReconcileBlocks != 0 {
+		return
+	}
+	s.reconciled = true
+	s.reconcileHeight = height
+	s.removedSince = make(map[stakeKey]struct{})
+	s.jobReconcile <- height
+}
+
+func (s *Service) loadSnapshot(height uint64) (*snapshot, error) {
+	resp, err := s.nodeApi.CandidatesExtended(true, false, "", height)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, c := range resp.Candidates {
+		for _, stk := range c.Stakes {
+			addresses = append(addresses, helpers.RemovePrefix(stk.Owner))
+		}
+	}
+	addressIds, err := s.addressRepository.FindIdsOrCreate(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &snapshot{height: height}
+	for _, c := range resp.Candidates {
+		validatorId, err := s.validatorRepository.FindIdByPkOrCreate(helpers.RemovePrefix(c.PublicKey))
+		if err != nil {
+			return nil, err
+		}
+		for _, stk := range c.Stakes {
+			snap.stakes = append(snap.stakes, &models.Stake{
+				OwnerAddressID: addressIds[helpers.RemovePrefix(stk.Owner)],
+				ValidatorID:    validatorId,
+				CoinID:         uint(stk.Coin.Id),
+				Value:          stk.Value,
+				BipValue:       stk.BipValue,
+				BlockID:        height,
+			})
+		}
+	}
+	return snap, nil
+}
+
+// reconcile Write differences between the node state and stakes which haven't been changed since the snapshot height
+func (s *Service) reconcile(snap *snapshot) error {
+	if snap.stakes == nil {
+		return nil
+	}
+	start := time.Now()
+
+	list, err := s.repository.GetActiveStakes()
+	if err != nil {
+		return err
+	}
+	current := make(map[stakeKey]*models.Stake, len(list))
+	for _, stk := range list {
+		current[stakeKey{addressId: stk.OwnerAddressID, validatorId: stk.ValidatorID, coinId: stk.CoinID}] = stk
+	}
+
+	var updates []*models.Stake
+	for _, stk := range snap.stakes {
+		key := stakeKey{addressId: stk.OwnerAddressID, validatorId: stk.ValidatorID, coinId: stk.CoinID}
+		if _, ok := s.removedSince[key]; ok {
+			continue
+		}
+		c, ok := current[key]
+		delete(current, key)
+		if ok && (c.BlockID > snap.height || c.Value == stk.Value) {
+			continue
+		}
+		updates = append(updates, stk)
+	}
+
+	var removeIds []uint
+	for _, c := range current {
+		if c.BlockID <= snap.height {
+			removeIds = append(removeIds, c.ID)
+		}
+	}
+
+	if len(updates) > 0 || len(removeIds) > 0 {
+		err = s.repository.Reconcile(snap.height, updates, removeIds, s.env.StakeChunkSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"block":   snap.height,
+		"updated": len(updates),
+		"removed": len(removeIds),
+	}).Info(fmt.Sprintf("Stakes have been reconciled. Processing time: %s", time.Since(start)))
+	return nil
+}
+
+// ReconcileWaitList Overwrite wait list stakes of addresses by the node state at the height,
+// all addresses which have stakes in the wait list are checked when none are given. Return changed stakes
+func (s *Service) ReconcileWaitList(height uint64, addresses []string) ([]models.WaitListUpdate, error) {
+	var err error
+	if len(addresses) == 0 {
+		addresses, err = s.repository.GetWaitListAddresses()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var updates []models.WaitListUpdate
+	for _, adr := range addresses {
+		adr = helpers.RemovePrefix(adr)
+		addressId, err := s.addressRepository.FindIdOrCreate(adr)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.nodeApi.WaitList("", "Mx"+adr, height)
+		if err != nil {
+			return nil, err
+		}
+		list, err := s.repository.GetWaitList([]uint{addressId})
+		if err != nil {
+			return nil, err
+		}
+		current := make(map[stakeKey]*models.Stake, len(list))
+		for _, stk := range list {
+			current[stakeKey{addressId: stk.OwnerAddressID, validatorId: stk.ValidatorID, coinId: stk.CoinID}] = stk
+		}
+
+		var rows []*models.Stake
+		for _, item := range resp.List {
+			validatorId, err := s.validatorRepository.FindIdByPkOrCreate(helpers.RemovePrefix(item.PublicKey))
+			if err != nil {
+				return nil, err
+			}
+			key := stakeKey{addressId: addressId, validatorId: validatorId, coinId: uint(item.Coin.Id)}
+			c, ok := current[key]
+			delete(current, key)
+			if ok && c.Value == item.Value {
+				continue
+			}
+			rows = append(rows, &models.Stake{OwnerAddressID: addressId, ValidatorID: validatorId, CoinID: key.coinId, Value: item.Value, BipValue: "0", IsKicked: true, BlockID: height})
+			updates = append(updates, models.WaitListUpdate{Address: "Mx" + adr, PublicKey: item.PublicKey, ValidatorID: validatorId, CoinID: key.coinId, Value: item.Value, BlockID: height})
+		}
+		for key := range current {
+			v, err := s.validatorRepository.GetById(key.validatorId)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, &models.Stake{OwnerAddressID: addressId, ValidatorID: key.validatorId, CoinID: key.coinId, Value: "0", IsKicked: true, BlockID: height})
+			updates = append(updates, models.WaitListUpdate{Address: "Mx" + adr, PublicKey: v.GetPublicKey(), ValidatorID: key.validatorId, CoinID: key.coinId, Value: "0", BlockID: height})
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		err = s.repository.ReconcileWaitList(height, rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return updates, nil
+}
@@ -0,0 +1,225 @@
+package stake
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"math/big"
+	"testing"
+)
+
+const (
+	testAddress = "Mx7633980c000139dd3bd24a3f54e06474fa941e16"
+	testFrom    = "Mp01cc99ae5a349ecaeef187dcbb12816bf2b3d8eae80f654034b21213aa445b2c"
+	testTo      = "Mpeee9614b63a7ed6370ccd1fa227222fa30d6106770145c55bd4b482b88888888"
+)
+
+// testKeys Return stake keys of the operations, the address has id 1, validators have ids 1 and 2
+func testKeys(ops []*operation) []stakeKey {
+	validatorIds := map[string]uint{testFrom: 1, testTo: 2}
+	keys := make([]stakeKey, len(ops))
+	for i, op := range ops {
+		if op.kind == opLockStake {
+			keys[i] = stakeKey{addressId: 1}
+			continue
+		}
+		keys[i] = stakeKey{addressId: 1, validatorId: validatorIds[op.publicKey], coinId: uint(op.coinId)}
+	}
+	return keys
+}
+
+func TestReplayBlock(t *testing.T) {
+	anyData := func(m proto.Message) *anypb.Any {
+		data, err := anypb.New(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	coin := &api_pb.Coin{Id: 0}
+	b := &api_pb.BlockResponse{
+		Height: 100,
+		Transactions: []*api_pb.TransactionResponse{
+			{Type: uint64(transaction.TypeDelegate), From: testAddress, Data: anyData(&api_pb.DelegateData{PubKey: testFrom, Coin: coin, Value: "1000"})},
+			{Type: uint64(transaction.TypeUnbond), From: testAddress, Data: anyData(&api_pb.UnbondData{PubKey: testFrom, Coin: coin, Value: "300"})},
+			{Type: uint64(transaction.TypeMoveStake), From: testAddress, Data: anyData(&api_pb.MoveStakeData{FromPubKey: testFrom, ToPubKey: testTo, Coin: coin, Value: "200"})},
+			// failed transactions don't change stakes
+			{Type: uint64(transaction.TypeDelegate), From: testAddress, Data: anyData(&api_pb.DelegateData{PubKey: testTo, Coin: coin, Value: "5000"}), Log: "Insufficient funds"},
+		},
+		Events: []*anypb.Any{
+			anyData(&api_pb.UnbondEvent{Address: testAddress, ValidatorPubKey: testTo, Coin: 0, Amount: "70"}),
+		},
+	}
+
+	txOps, eventOps, err := parseOperations(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txOps) != 3 || len(eventOps) != 1 {
+		t.Fatalf("parsed %d transaction and %d event operations, want 3 and 1", len(txOps), len(eventOps))
+	}
+	ops := append(txOps, eventOps...)
+	changed := applyOperations(ops, testKeys(ops), make(map[stakeKey]*big.Int))
+
+	want := map[stakeKey]string{
+		{addressId: 1, validatorId: 1}: "500", // 1000 delegated, 300 unbonded, 200 moved away
+	}
+	if len(changed.deltas) != len(want) {
+		t.Fatalf("changed %d stakes, want %d", len(changed.deltas), len(want))
+	}
+	for key, value := range want {
+		if changed.deltas[key] == nil || changed.deltas[key].String() != value {
+			t.Errorf("stake %v changed by %v, want %s", key, changed.deltas[key], value)
+		}
+	}
+	if len(changed.kicked) != 0 || len(changed.waitListChanged) != 0 || len(changed.lockedOwners) != 0 {
+		t.Errorf("unexpected kicks, wait list or lock changes")
+	}
+}
+
+func TestApplyOperations(t *testing.T) {
+	from := stakeKey{addressId: 1, validatorId: 1}
+	to := stakeKey{addressId: 1, validatorId: 2}
+	op := func(kind int, publicKey string, value int64) *operation {
+		return &operation{kind: kind, address: testAddress, publicKey: publicKey, value: big.NewInt(value)}
+	}
+
+	tests := []struct {
+		name         string
+		ops          []*operation
+		waitList     map[stakeKey]int64
+		wantDeltas   map[stakeKey]int64
+		wantWaitList map[stakeKey]int64 // changed wait list values
+		wantKicked   []stakeKey
+		wantLocked   bool
+	}{
+		{
+			name:       "delegate and unbond",
+			ops:        []*operation{op(opDelegate, testFrom, 100), op(opUnbond, testFrom, 30)},
+			wantDeltas: map[stakeKey]int64{from: 70},
+		},
+		{
+			name:       "moved stake leaves the source and arrives later",
+			ops:        []*operation{op(opMoveStake, testFrom, 50), op(opMovedStakeArrived, testTo, 20)},
+			wantDeltas: map[stakeKey]int64{from: -50, to: 20},
+		},
+		{
+			name:         "moved stake arrives to the wait list",
+			ops:          []*operation{{kind: opMovedStakeArrived, address: testAddress, publicKey: testTo, value: big.NewInt(20), waitList: true}},
+			waitList:     map[stakeKey]int64{to: 5},
+			wantDeltas:   map[stakeKey]int64{to: 0},
+			wantWaitList: map[stakeKey]int64{to: 25},
+		},
+		{
+			name:         "delegation returns the wait list stake",
+			ops:          []*operation{op(opDelegate, testFrom, 100)},
+			waitList:     map[stakeKey]int64{from: 40},
+			wantDeltas:   map[stakeKey]int64{from: 140},
+			wantWaitList: map[stakeKey]int64{from: 0},
+		},
+		{
+			name:         "unbond takes the wait list stake first",
+			ops:          []*operation{op(opUnbond, testFrom, 30)},
+			waitList:     map[stakeKey]int64{from: 40},
+			wantDeltas:   map[stakeKey]int64{from: 0},
+			wantWaitList: map[stakeKey]int64{from: 10},
+		},
+		{
+			name:         "unbond more than the wait list stake",
+			ops:          []*operation{op(opUnbond, testFrom, 50)},
+			waitList:     map[stakeKey]int64{from: 40},
+			wantDeltas:   map[stakeKey]int64{from: -10},
+			wantWaitList: map[stakeKey]int64{from: 0},
+		},
+		{
+			name:         "kick moves the stake to the wait list",
+			ops:          []*operation{op(opDelegate, testFrom, 100), op(opKick, testFrom, 600)},
+			wantDeltas:   map[stakeKey]int64{from: 0},
+			wantWaitList: map[stakeKey]int64{from: 600},
+			wantKicked:   []stakeKey{from},
+		},
+		{
+			name:       "slash",
+			ops:        []*operation{op(opSlash, testFrom, 7)},
+			wantDeltas: map[stakeKey]int64{from: -7},
+		},
+		{
+			name:       "returned unbond and lock don't change values",
+			ops:        []*operation{op(opUnbondReturned, testFrom, 30), op(opLockStake, "", 0)},
+			wantDeltas: map[stakeKey]int64{},
+			wantLocked: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waitList := make(map[stakeKey]*big.Int)
+			for key, value := range tt.waitList {
+				waitList[key] = big.NewInt(value)
+			}
+			changed := applyOperations(tt.ops, testKeys(tt.ops), waitList)
+
+			if len(changed.deltas) != len(tt.wantDeltas) {
+				t.Errorf("changed %d stakes, want %d", len(changed.deltas), len(tt.wantDeltas))
+			}
+			for key, value := range tt.wantDeltas {
+				if changed.deltas[key] == nil || changed.deltas[key].Int64() != value {
+					t.Errorf("stake %v changed by %v, want %d", key, changed.deltas[key], value)
+				}
+			}
+			if len(changed.waitListChanged) != len(tt.wantWaitList) {
+				t.Errorf("changed %d wait list stakes, want %d", len(changed.waitListChanged), len(tt.wantWaitList))
+			}
+			for key, value := range tt.wantWaitList {
+				if _, ok := changed.waitListChanged[key]; !ok || waitList[key].Int64() != value {
+					t.Errorf("wait list stake %v = %v, want %d", key, waitList[key], value)
+				}
+			}
+			if len(changed.kicked) != len(tt.wantKicked) {
+				t.Errorf("kicked %d stakes, want %d", len(changed.kicked), len(tt.wantKicked))
+			}
+			for _, key := range tt.wantKicked {
+				if _, ok := changed.kicked[key]; !ok {
+					t.Errorf("stake %v isn't kicked", key)
+				}
+			}
+			if _, ok := changed.lockedOwners[1]; ok != tt.wantLocked {
+				t.Errorf("owner locked = %v, want %v", ok, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func TestDueMovedStakeOperations(t *testing.T) {
+	moved := []*models.MovedStake{
+		{ID: 1, AddressId: 1, FromValidatorId: 1, ToValidatorId: 2, CoinId: 0, Value: "300"},
+		{ID: 2, AddressId: 1, FromValidatorId: 1, ToValidatorId: 2, CoinId: 5, Value: "40"},
+	}
+	ops, keys, err := dueMovedStakeOperations(moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a delegation to the destination validator in the same block
+	ops = append(ops, &operation{kind: opDelegate, address: testAddress, publicKey: testTo, value: big.NewInt(10)})
+	keys = append(keys, stakeKey{addressId: 1, validatorId: 2})
+
+	changed := applyOperations(ops, keys, make(map[stakeKey]*big.Int))
+	want := map[stakeKey]int64{
+		{addressId: 1, validatorId: 2}:            310,
+		{addressId: 1, validatorId: 2, coinId: 5}: 40,
+	}
+	if len(changed.deltas) != len(want) {
+		t.Fatalf("changed %d stakes, want %d", len(changed.deltas), len(want))
+	}
+	for key, value := range want {
+		if changed.deltas[key] == nil || changed.deltas[key].Int64() != value {
+			t.Errorf("stake %v changed by %v, want %d", key, changed.deltas[key], value)
+		}
+	}
+
+	_, _, err = dueMovedStakeOperations([]*models.MovedStake{{Value: "x"}})
+	if err == nil {
+		t.Error("invalid value is accepted")
+	}
+}
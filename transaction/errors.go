@@ -0,0 +1,138 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// Keys of the node error info with the required and the available value
+var (
+	errorRequiredKeys  = []string{"needed_value", "required_value", "required_bip_value", "minimum_value_to_buy", "needed_spend_value"}
+	errorAvailableKeys = []string{"has_value", "available_value", "has_bip_value", "coin_value_to_buy", "maximum_value_to_sell"}
+)
+
+// errorLogPatterns Plain text logs of failed transactions in block responses with the info keys of their values
+var errorLogPatterns = []struct {
+	pattern *regexp.Regexp
+	keys    []string
+}{
+	{
+		regexp.MustCompile(`^Insufficient funds for (?:sender|check issuer) account: (Mx[0-9a-fA-F]{40})[^.]*\. Wanted (\d+) (\S+)$`),
+		[]string{"sender", "needed_value", "coin_symbol"},
+	},
+	{
+		regexp.MustCompile(`^Coin reserve balance is not sufficient for transaction\. Has: (\d+), required (\d+)$`),
+		[]string{"has_bip_value", "required_bip_value"},
+	},
+	{
+		regexp.MustCompile(`^You wanted to get minimum (\d+), but currently you will get (\d+)$`),
+		[]string{"minimum_value_to_buy", "coin_value_to_buy"},
+	},
+	{
+		regexp.MustCompile(`^You wanted to spend maximum (\d+), but currently you need to spend (\d+) to complete tx$`),
+		[]string{"maximum_value_to_sell", "needed_spend_value"},
+	},
+	{
+		regexp.MustCompile(`^Unexpected nonce\. Expected: (\d+), got (\d+)\.?$`),
+		[]string{"expected_nonce", "got_nonce"},
+	},
+	{
+		regexp.MustCompile(`^Coin (\d+) not exists$`),
+		[]string{"coin_id"},
+	},
+}
+
+// setTxError Fill error fields of the invalid transaction from the node log.
+// Block responses have plain text logs, a JSON object with the message and the error info is parsed as well.
+// findCoinId resolves the coin symbol of the log when the coin id isn't given
+func setTxError(tx *models.InvalidTransaction, log string, findCoinId func(symbol string) (uint64, error)) error {
+	tx.ErrorMessage = log
+
+	info, err := parseJsonLog(tx, log)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		info = parseTextLog(log)
+	}
+	if len(info) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	tx.ErrorInfo = data
+
+	if coinId, err := strconv.ParseUint(info["coin_id"], 10, 64); err == nil {
+		tx.ErrorCoinID = &coinId
+	} else if info["coin_symbol"] != "" && findCoinId != nil {
+		if coinId, err := findCoinId(info["coin_symbol"]); err == nil {
+			tx.ErrorCoinID = &coinId
+		}
+	}
+	tx.ErrorRequiredValue = errorValue(info, errorRequiredKeys)
+	tx.ErrorAvailableValue = errorValue(info, errorAvailableKeys)
+	return nil
+}
+
+// parseJsonLog Return the error info of a JSON log and set its message, nil if the log isn't JSON
+func parseJsonLog(tx *models.InvalidTransaction, log string) (map[string]string, error) {
+	var errorLog map[string]json.RawMessage
+	if json.Unmarshal([]byte(log), &errorLog) != nil {
+		return nil, nil
+	}
+	for _, key := range []string{"message", "log"} {
+		var message string
+		if json.Unmarshal(errorLog[key], &message) == nil && message != "" {
+			tx.ErrorMessage = message
+			break
+		}
+	}
+
+	// numbers are kept as written, values in pip don't fit into float64
+	var rawInfo map[string]interface{}
+	for _, key := range []string{"data", "info"} {
+		decoder := json.NewDecoder(bytes.NewReader(errorLog[key]))
+		decoder.UseNumber()
+		if decoder.Decode(&rawInfo) == nil && len(rawInfo) > 0 {
+			break
+		}
+	}
+	info := make(map[string]string, len(rawInfo))
+	for key, value := range rawInfo {
+		info[key] = fmt.Sprint(value)
+	}
+	return info, nil
+}
+
+// parseTextLog Return values of a known plain text log, nil if the log has another format
+func parseTextLog(log string) map[string]string {
+	for _, p := range errorLogPatterns {
+		match := p.pattern.FindStringSubmatch(log)
+		if match == nil {
+			continue
+		}
+		info := make(map[string]string, len(p.keys))
+		for i, key := range p.keys {
+			info[key] = match[i+1]
+		}
+		return info
+	}
+	return nil
+}
+
+// errorValue Return the first integer value of the keys
+func errorValue(info map[string]string, keys []string) string {
+	for _, key := range keys {
+		if _, ok := big.NewInt(0).SetString(info[key], 10); ok {
+			return info[key]
+		}
+	}
+	return ""
+}
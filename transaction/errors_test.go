@@ -0,0 +1,141 @@
+package transaction
+
+import (
+	"errors"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"testing"
+)
+
+func TestSetTxError(t *testing.T) {
+	coinIds := map[string]uint64{"BIP": 0, "HUB": 1902, "ABC-1": 15}
+	findCoinId := func(symbol string) (uint64, error) {
+		id, ok := coinIds[symbol]
+		if !ok {
+			return 0, errors.New("coin not found")
+		}
+		return id, nil
+	}
+	coinId := func(id uint64) *uint64 { return &id }
+
+	tests := []struct {
+		name      string
+		log       string
+		message   string
+		info      string
+		coinId    *uint64
+		required  string
+		available string
+	}{
+		{
+			name:     "insufficient funds",
+			log:      "Insufficient funds for sender account: Mx7633980c000139dd3bd24a3f54e06474fa941e16. Wanted 1000000000000000000 BIP",
+			message:  "Insufficient funds for sender account: Mx7633980c000139dd3bd24a3f54e06474fa941e16. Wanted 1000000000000000000 BIP",
+			info:     `{"coin_symbol":"BIP","needed_value":"1000000000000000000","sender":"Mx7633980c000139dd3bd24a3f54e06474fa941e16"}`,
+			coinId:   coinId(0),
+			required: "1000000000000000000",
+		},
+		{
+			name:     "insufficient funds in custom coin",
+			log:      "Insufficient funds for sender account: Mxee81347211c72524338f9680072af90744333146. Wanted 2500000000000000000000 HUB",
+			message:  "Insufficient funds for sender account: Mxee81347211c72524338f9680072af90744333146. Wanted 2500000000000000000000 HUB",
+			info:     `{"coin_symbol":"HUB","needed_value":"2500000000000000000000","sender":"Mxee81347211c72524338f9680072af90744333146"}`,
+			coinId:   coinId(1902),
+			required: "2500000000000000000000",
+		},
+		{
+			name:     "insufficient funds in archived coin",
+			log:      "Insufficient funds for sender account: Mxee81347211c72524338f9680072af90744333146. Wanted 10 ABC-1",
+			message:  "Insufficient funds for sender account: Mxee81347211c72524338f9680072af90744333146. Wanted 10 ABC-1",
+			info:     `{"coin_symbol":"ABC-1","needed_value":"10","sender":"Mxee81347211c72524338f9680072af90744333146"}`,
+			coinId:   coinId(15),
+			required: "10",
+		},
+		{
+			name:     "insufficient funds in unknown coin",
+			log:      "Insufficient funds for sender account: Mxee81347211c72524338f9680072af90744333146. Wanted 10 XYZ",
+			message:  "Insufficient funds for sender account: Mxee81347211c72524338f9680072af90744333146. Wanted 10 XYZ",
+			info:     `{"coin_symbol":"XYZ","needed_value":"10","sender":"Mxee81347211c72524338f9680072af90744333146"}`,
+			required: "10",
+		},
+		{
+			name:      "coin reserve",
+			log:       "Coin reserve balance is not sufficient for transaction. Has: 9999000000000000000000, required 10000000000000000000000",
+			message:   "Coin reserve balance is not sufficient for transaction. Has: 9999000000000000000000, required 10000000000000000000000",
+			info:      `{"has_bip_value":"9999000000000000000000","required_bip_value":"10000000000000000000000"}`,
+			required:  "10000000000000000000000",
+			available: "9999000000000000000000",
+		},
+		{
+			name:      "minimum value to buy",
+			log:       "You wanted to get minimum 5000000000000000000, but currently you will get 4987312000000000000",
+			message:   "You wanted to get minimum 5000000000000000000, but currently you will get 4987312000000000000",
+			info:      `{"coin_value_to_buy":"4987312000000000000","minimum_value_to_buy":"5000000000000000000"}`,
+			required:  "5000000000000000000",
+			available: "4987312000000000000",
+		},
+		{
+			name:      "maximum value to sell",
+			log:       "You wanted to spend maximum 1000000000000000000, but currently you need to spend 1003000000000000000 to complete tx",
+			message:   "You wanted to spend maximum 1000000000000000000, but currently you need to spend 1003000000000000000 to complete tx",
+			info:      `{"maximum_value_to_sell":"1000000000000000000","needed_spend_value":"1003000000000000000"}`,
+			required:  "1003000000000000000",
+			available: "1000000000000000000",
+		},
+		{
+			name:    "unexpected nonce",
+			log:     "Unexpected nonce. Expected: 12, got 11.",
+			message: "Unexpected nonce. Expected: 12, got 11.",
+			info:    `{"expected_nonce":"12","got_nonce":"11"}`,
+		},
+		{
+			name:    "coin not exists",
+			log:     "Coin 4021 not exists",
+			message: "Coin 4021 not exists",
+			info:    `{"coin_id":"4021"}`,
+			coinId:  coinId(4021),
+		},
+		{
+			name:    "unknown message",
+			log:     "Check expired",
+			message: "Check expired",
+		},
+		{
+			name:      "json log",
+			log:       `{"code":107,"message":"Insufficient funds for sender account","data":{"sender":"Mx7633980c000139dd3bd24a3f54e06474fa941e16","needed_value":"1000000000000000000","coin_symbol":"HUB","coin_id":"1902"}}`,
+			message:   "Insufficient funds for sender account",
+			info:      `{"coin_id":"1902","coin_symbol":"HUB","needed_value":"1000000000000000000","sender":"Mx7633980c000139dd3bd24a3f54e06474fa941e16"}`,
+			coinId:    coinId(1902),
+			required:  "1000000000000000000",
+			available: "",
+		},
+		{
+			name:    "json log without info",
+			log:     `{"code":114,"log":"Check expired"}`,
+			message: "Check expired",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := new(models.InvalidTransaction)
+			err := setTxError(tx, tt.log, findCoinId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tx.ErrorMessage != tt.message {
+				t.Errorf("message = %q, want %q", tx.ErrorMessage, tt.message)
+			}
+			if string(tx.ErrorInfo) != tt.info {
+				t.Errorf("info = %s, want %s", tx.ErrorInfo, tt.info)
+			}
+			if (tx.ErrorCoinID == nil) != (tt.coinId == nil) || tx.ErrorCoinID != nil && *tx.ErrorCoinID != *tt.coinId {
+				t.Errorf("coin id = %v, want %v", tx.ErrorCoinID, tt.coinId)
+			}
+			if tx.ErrorRequiredValue != tt.required {
+				t.Errorf("required value = %q, want %q", tx.ErrorRequiredValue, tt.required)
+			}
+			if tx.ErrorAvailableValue != tt.available {
+				t.Errorf("available value = %q, want %q", tx.ErrorAvailableValue, tt.available)
+			}
+		})
+	}
+}
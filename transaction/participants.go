@@ -0,0 +1,146 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"github.com/MinterTeam/minter-explorer-extender/v2/address"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+type participantKey struct {
+	transactionId uint64
+	addressId     uint64
+}
+
+// participantList Rows of transaction participants, an address with several roles in a transaction has one row
+type participantList struct {
+	rows  []*models.TransactionAddress
+	index map[participantKey]*models.TransactionAddress
+}
+
+func newParticipantList() *participantList {
+	return &participantList{index: make(map[participantKey]*models.TransactionAddress)}
+}
+
+// add Add the role of the address in the transaction, a role given twice is kept once
+func (l *participantList) add(tx *models.Transaction, addressId uint64, role string) {
+	key := participantKey{transactionId: tx.ID, addressId: addressId}
+	row, ok := l.index[key]
+	if !ok {
+		row = &models.TransactionAddress{
+			BlockID:       tx.BlockID,
+			AddressID:     addressId,
+			TransactionID: tx.ID,
+		}
+		l.index[key] = row
+		l.rows = append(l.rows, row)
+	}
+	for _, r := range row.Roles {
+		if r == role {
+			return
+		}
+	}
+	row.Roles = append(row.Roles, role)
+}
+
+// getTxParticipants Return all addresses involved in transactions with their roles:
+// senders, addresses of the transaction data and tags, owners of multisig senders
+// and addresses of validators the transactions are linked with
+func (s *Service) getTxParticipants(txList []*models.Transaction) ([]*models.TransactionAddress, error) {
+	list := newParticipantList()
+
+	participants := make([][]address.Participant, len(txList))
+	var addresses []string
+	for i, tx := range txList {
+		ps, err := address.ExtractParticipants(uint64(tx.Type), tx.IData.(*anypb.Any), tx.Tags, hex.EncodeToString(tx.RawTx))
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"tx":    tx.Hash,
+				"block": tx.BlockID,
+			}).Error(err)
+		}
+		participants[i] = ps
+		for _, p := range ps {
+			addresses = append(addresses, p.Address)
+		}
+	}
+	addressIds, err := s.addressRepository.FindIdsOrCreate(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		multisigIds []uint
+		blockIds    []uint64
+	)
+	for i, tx := range txList {
+		list.add(tx, tx.FromAddressID, models.TxRoleSender)
+		isMultisig := false
+		for _, p := range participants[i] {
+			list.add(tx, uint64(addressIds[p.Address]), p.Role)
+			isMultisig = isMultisig || p.Role == models.TxRoleMultisigSigner
+		}
+		if isMultisig {
+			multisigIds = append(multisigIds, uint(tx.FromAddressID))
+			blockIds = append(blockIds, tx.BlockID)
+		}
+	}
+
+	// members of a multisig sender which did not sign the transaction, by the configuration of the transaction block
+	owners, err := s.multisigRepository.GetOwnersAtBlocks(multisigIds, blockIds)
+	if err != nil {
+		return nil, err
+	}
+	type addressAtBlock struct {
+		id      uint64
+		blockId uint64
+	}
+	members := make(map[addressAtBlock][]uint64)
+	for _, o := range owners {
+		key := addressAtBlock{id: uint64(o.MultisigAddressID), blockId: o.BlockID}
+		members[key] = append(members[key], uint64(o.OwnerAddressID))
+	}
+	for _, tx := range txList {
+		for _, ownerId := range members[addressAtBlock{id: tx.FromAddressID, blockId: tx.BlockID}] {
+			list.add(tx, ownerId, models.TxRoleMultisigOwner)
+		}
+	}
+
+	// addresses of validators the transactions are linked with, as of the transaction blocks
+	links, err := s.getLinksTxValidator(txList)
+	if err != nil {
+		return nil, err
+	}
+	txById := make(map[uint64]*models.Transaction, len(txList))
+	for _, tx := range txList {
+		txById[tx.ID] = tx
+	}
+	var validatorIds []uint
+	blockIds = nil
+	for _, link := range links {
+		validatorIds = append(validatorIds, uint(link.ValidatorID))
+		blockIds = append(blockIds, txById[link.TransactionID].BlockID)
+	}
+	validatorAddresses, err := s.validatorRepository.GetAddressesAtBlocks(validatorIds, blockIds)
+	if err != nil {
+		return nil, err
+	}
+	addressesAt := make(map[addressAtBlock][]uint64)
+	for _, v := range validatorAddresses {
+		key := addressAtBlock{id: uint64(v.ValidatorID), blockId: v.BlockID}
+		for _, id := range []*uint{v.OwnerAddressID, v.ControlAddressID, v.RewardAddressID} {
+			if id != nil {
+				addressesAt[key] = append(addressesAt[key], uint64(*id))
+			}
+		}
+	}
+	for _, link := range links {
+		tx := txById[link.TransactionID]
+		for _, id := range addressesAt[addressAtBlock{id: link.ValidatorID, blockId: tx.BlockID}] {
+			list.add(tx, id, models.TxRoleValidator)
+		}
+	}
+
+	return list.rows, nil
+}
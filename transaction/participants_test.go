@@ -0,0 +1,75 @@
+package transaction
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"reflect"
+	"testing"
+)
+
+func TestParticipantListAdd(t *testing.T) {
+	type participant struct {
+		txId      uint64
+		addressId uint64
+		role      string
+	}
+	type row struct {
+		txId      uint64
+		addressId uint64
+		roles     []string
+	}
+
+	tests := []struct {
+		name string
+		add  []participant
+		want []row
+	}{
+		{
+			name: "one role",
+			add:  []participant{{1, 10, models.TxRoleSender}},
+			want: []row{{1, 10, []string{models.TxRoleSender}}},
+		},
+		{
+			name: "roles of one address are merged",
+			add:  []participant{{1, 10, models.TxRoleSender}, {1, 10, models.TxRoleMultisigOwner}},
+			want: []row{{1, 10, []string{models.TxRoleSender, models.TxRoleMultisigOwner}}},
+		},
+		{
+			name: "same role is kept once",
+			add:  []participant{{1, 10, models.TxRoleRecipient}, {1, 10, models.TxRoleRecipient}},
+			want: []row{{1, 10, []string{models.TxRoleRecipient}}},
+		},
+		{
+			name: "sender sends to itself",
+			add:  []participant{{1, 10, models.TxRoleSender}, {1, 10, models.TxRoleRecipient}, {1, 11, models.TxRoleRecipient}},
+			want: []row{
+				{1, 10, []string{models.TxRoleSender, models.TxRoleRecipient}},
+				{1, 11, []string{models.TxRoleRecipient}},
+			},
+		},
+		{
+			name: "address in several transactions",
+			add:  []participant{{1, 10, models.TxRoleSender}, {2, 10, models.TxRoleValidator}, {1, 10, models.TxRoleValidator}},
+			want: []row{
+				{1, 10, []string{models.TxRoleSender, models.TxRoleValidator}},
+				{2, 10, []string{models.TxRoleValidator}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := newParticipantList()
+			for _, p := range tt.add {
+				list.add(&models.Transaction{ID: p.txId, BlockID: 100}, p.addressId, p.role)
+			}
+			if len(list.rows) != len(tt.want) {
+				t.Fatalf("rows = %d, want %d", len(list.rows), len(tt.want))
+			}
+			for i, w := range tt.want {
+				r := list.rows[i]
+				if r.TransactionID != w.txId || r.AddressID != w.addressId || r.BlockID != 100 || !reflect.DeepEqual(r.Roles, w.roles) {
+					t.Errorf("row %d = %d %d %v, want %d %d %v", i, r.TransactionID, r.AddressID, r.Roles, w.txId, w.addressId, w.roles)
+				}
+			}
+		})
+	}
+}
@@ -2,22 +2,33 @@ package transaction
 
 import (
 	"context"
+	"github.com/MinterTeam/minter-explorer-extender/v2/bulk"
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
 	"github.com/go-pg/pg/v10"
 	"github.com/go-pg/pg/v10/orm"
 )
 
 type Repository struct {
-	db *pg.DB
+	db         orm.DB
+	bulkWriter *bulk.Writer
 }
 
-func NewRepository(db *pg.DB) *Repository {
+func NewRepository(db *pg.DB, bulkWriter *bulk.Writer) *Repository {
 	orm.RegisterTable((*models.TransactionValidator)(nil))
 	return &Repository{
-		db: db,
+		db:         db,
+		bulkWriter: bulkWriter,
 	}
 }
 
+// WithTx Return repository which runs queries in the transaction
+func (r *Repository) WithTx(tx *pg.Tx) *Repository {
+	repository := *r
+	repository.db = tx
+	return &repository
+}
+
 func (r *Repository) Save(transaction *models.Transaction) error {
 	_, err := r.db.Model(transaction).Insert()
 	if err != nil {
@@ -27,6 +38,9 @@ func (r *Repository) Save(transaction *models.Transaction) error {
 }
 
 func (r *Repository) SaveAll(transactions []*models.Transaction) error {
+	if r.bulkWriter.IsEnabled(bulk.TableTransactions) {
+		return r.bulkWriter.SaveTransactions(r.db, transactions)
+	}
 	_, err := r.db.Model(&transactions).Insert()
 	return err
 }
@@ -37,52 +51,223 @@ func (r *Repository) SaveAllInvalid(transactions []*models.InvalidTransaction) e
 }
 
 func (r *Repository) SaveAllTxOutputs(output []*models.TransactionOutput) error {
+	if r.bulkWriter.IsEnabled(bulk.TableTransactionOutputs) {
+		return r.bulkWriter.SaveTransactionOutputs(r.db, output)
+	}
 	_, err := r.db.Model(&output).Insert()
 	return err
 }
 
+// SaveRedeemedChecks Save redeemed checks, checks already seen in failed redeem attempts become redeemed
 func (r *Repository) SaveRedeemedChecks(list []*models.Check) error {
-	_, err := r.db.Model(&list).OnConflict("DO NOTHING").Insert()
+	_, err := r.db.Model(&list).
+		OnConflict("(data) DO UPDATE").
+		Set("transaction_id = EXCLUDED.transaction_id").
+		Set("to_address_id = EXCLUDED.to_address_id").
+		Set("gas_amount = EXCLUDED.gas_amount").
+		Set("block_id = EXCLUDED.block_id").
+		Set("status = EXCLUDED.status").
+		Insert()
 	return err
 }
 
 func (r *Repository) LinkWithValidators(links []*models.TransactionValidator) error {
+	if r.bulkWriter.IsEnabled(bulk.TableTransactionValidator) {
+		return r.bulkWriter.SaveTransactionValidators(r.db, links)
+	}
 	_, err := r.db.Model(&links).Insert()
 	return err
 }
 
-func (r *Repository) IndexTxAddress(txsId []uint64) error {
-	_, err := r.db.ExecContext(context.Background(), `
-insert into index_transaction_by_address (block_id, address_id, transaction_id)
-  (select block_id, from_address_id, id
-   from transactions
-   where id in (?)
-   union
-   select t.block_id, to_address_id, transaction_id
-   from transaction_outputs
-          inner join transactions t on transaction_outputs.transaction_id = t.id
-   where t.id in (?))
-ON CONFLICT DO NOTHING;
-	`, pg.In(txsId), pg.In(txsId))
+// IndexTxAddress Save addresses involved in transactions, roles are merged with the already indexed ones
+func (r *Repository) IndexTxAddress(list []*models.TransactionAddress) error {
+	if len(list) == 0 {
+		return nil
+	}
+	_, err := r.db.Model(&list).
+		OnConflict("(address_id, transaction_id, block_id) DO UPDATE").
+		Set("roles = (SELECT array_agg(DISTINCT r) FROM unnest(transaction_address.roles || EXCLUDED.roles) AS r)").
+		Insert()
 	return err
 }
 
+// txAddressRoles Select roles of senders and output receivers of transactions matching the condition on t,
+// one row per address and transaction. Receivers of RedeemCheck outputs are check issuers
+func txAddressRoles(condition string) string {
+	return `
+select p.block_id, p.address_id, p.transaction_id,
+       array_remove(array [
+           case when bool_or(p.is_sender) then '` + models.TxRoleSender + `' end,
+           case when bool_or(not p.is_sender and p.type <> 9) then '` + models.TxRoleRecipient + `' end,
+           case when bool_or(not p.is_sender and p.type = 9) then '` + models.TxRoleCheckIssuer + `' end
+           ], null)::varchar(32)[] as roles
+from (select t.block_id, t.from_address_id as address_id, t.id as transaction_id, t.type, true as is_sender
+      from transactions t
+      where ` + condition + `
+      union all
+      select o.block_id, o.to_address_id, o.transaction_id, t.type, false
+      from transaction_outputs o
+               join transactions t on t.id = o.transaction_id and t.block_id = o.block_id
+      where ` + condition + `) p
+group by p.block_id, p.address_id, p.transaction_id`
+}
+
+// IndexLastNTxAddress Index senders and output receivers of transactions of the last blocks,
+// roles are merged with the already indexed ones
 func (r *Repository) IndexLastNTxAddress(txsNumber int) error {
 	_, err := r.db.ExecContext(context.Background(), `
-insert into index_transaction_by_address (block_id, address_id, transaction_id)
-    (select it.block_id, it.from_address_id, it.id
-     from transactions as it
-     where it.block_id > (select (id - ?) from blocks order by id desc limit 1)
-     union
-     select ot.block_id, touts.to_address_id, touts.transaction_id
-     from transaction_outputs touts
-            inner join transactions ot on touts.transaction_id = ot.id
-     where ot.block_id > (select (id - ?) from blocks order by id desc limit 1))
-ON CONFLICT DO NOTHING;
-	`, txsNumber, txsNumber)
+insert into index_transaction_by_address (block_id, address_id, transaction_id, roles)
+`+txAddressRoles(`t.block_id > (select (id - ?0) from blocks order by id desc limit 1)`)+`
+ON CONFLICT (address_id, transaction_id, block_id) DO UPDATE
+  SET roles = (SELECT array_agg(DISTINCT r) FROM unnest(index_transaction_by_address.roles || EXCLUDED.roles) AS r);
+	`, txsNumber)
+	return err
+}
+
+// FillAddressRoles Set roles of index rows of blocks in range [fromBlock, toBlock] indexed before roles were stored
+func (r *Repository) FillAddressRoles(fromBlock, toBlock uint64) error {
+	_, err := r.db.Exec(`
+update index_transaction_by_address i
+set roles = p.roles
+from (`+txAddressRoles(`t.block_id between ?0 and ?1`)+`) p
+where i.block_id between ?0 and ?1
+  and i.roles is null
+  and i.block_id = p.block_id
+  and i.address_id = p.address_id
+  and i.transaction_id = p.transaction_id;
+	`, fromBlock, toBlock)
 	return err
 }
 
+// FillOutputBlocks Set block_id of outputs of transactions of blocks in range [fromBlock, toBlock] saved before outputs were partitioned
+func (r *Repository) FillOutputBlocks(fromBlock, toBlock uint64) error {
+	_, err := r.db.Exec(`
+update transaction_outputs o
+set block_id = t.block_id
+from transactions t
+where t.block_id between ?0 and ?1
+  and o.block_id = 0
+  and o.transaction_id = t.id;
+	`, fromBlock, toBlock)
+	return err
+}
+
+// UpdateAddressStats Add transactions with given ids to the address_stats counters
+func (r *Repository) UpdateAddressStats(txsId []uint64) error {
+	return r.updateAddressStats(`t.id in (?0)`, pg.In(txsId))
+}
+
+// RebuildAddressStats Add transactions of blocks in range [fromBlock, toBlock] to the address_stats counters
+func (r *Repository) RebuildAddressStats(fromBlock, toBlock uint64) error {
+	return r.updateAddressStats(`t.block_id between ?0 and ?1`, fromBlock, toBlock)
+}
+
+func (r *Repository) ClearAddressStats() error {
+	_, err := r.db.Exec(`TRUNCATE address_stats, address_fees, address_counterparties, address_stats_transactions;`)
+	return err
+}
+
+// updateAddressStats A transaction is added once by its block and hash, so a replayed or reindexed block isn't counted twice.
+// Rows are ordered by address to keep the same lock order between concurrent workers
+func (r *Repository) updateAddressStats(condition string, params ...interface{}) error {
+	return database.RunInTransaction(r.db, func(tx *pg.Tx) error {
+		_, err := tx.Exec(`CREATE TEMP TABLE address_stats_applied (id bigint, block_id bigint) ON COMMIT DROP;`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+with applied as (
+  insert into address_stats_transactions (block_id, hash)
+    (select t.block_id, t.hash
+     from transactions t
+     where `+condition+`
+     order by t.block_id, t.hash)
+  ON CONFLICT DO NOTHING
+  returning block_id, hash)
+insert into address_stats_applied (id, block_id)
+select min(t.id), t.block_id
+from transactions t
+       inner join applied a on a.block_id = t.block_id and a.hash = t.hash
+where `+condition+`
+group by t.block_id, t.hash;
+	`, params...)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+insert into address_stats (address_id, first_block_id, last_block_id, sent_count, received_count, counterparties_count)
+  (select t.from_address_id, min(t.block_id), max(t.block_id), count(*), 0, 0
+   from address_stats_applied a
+          inner join transactions t on t.id = a.id and t.block_id = a.block_id
+   group by t.from_address_id
+   order by t.from_address_id)
+ON CONFLICT (address_id) DO UPDATE
+  SET first_block_id = least(address_stats.first_block_id, excluded.first_block_id),
+      last_block_id  = greatest(address_stats.last_block_id, excluded.last_block_id),
+      sent_count     = address_stats.sent_count + excluded.sent_count;
+	`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+insert into address_fees (address_id, coin_id, value)
+  (select t.from_address_id, t.gas_coin_id, coalesce(sum(t.commission), 0)
+   from address_stats_applied a
+          inner join transactions t on t.id = a.id and t.block_id = a.block_id
+   group by t.from_address_id, t.gas_coin_id
+   order by t.from_address_id, t.gas_coin_id)
+ON CONFLICT (address_id, coin_id) DO UPDATE
+  SET value = address_fees.value + excluded.value;
+	`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+insert into address_stats (address_id, first_block_id, last_block_id, sent_count, received_count, counterparties_count)
+  (select o.to_address_id, min(o.block_id), max(o.block_id), 0, count(distinct o.transaction_id), 0
+   from address_stats_applied a
+          inner join transaction_outputs o on o.transaction_id = a.id and o.block_id = a.block_id
+   group by o.to_address_id
+   order by o.to_address_id)
+ON CONFLICT (address_id) DO UPDATE
+  SET first_block_id = least(address_stats.first_block_id, excluded.first_block_id),
+      last_block_id  = greatest(address_stats.last_block_id, excluded.last_block_id),
+      received_count = address_stats.received_count + excluded.received_count;
+	`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+with pairs as (
+  insert into address_counterparties (address_id, counterparty_id)
+    (select p.address_id, p.counterparty_id
+     from (select t.from_address_id as address_id, o.to_address_id as counterparty_id
+           from address_stats_applied a
+                  inner join transactions t on t.id = a.id and t.block_id = a.block_id
+                  inner join transaction_outputs o on o.transaction_id = t.id and o.block_id = t.block_id
+           where t.from_address_id != o.to_address_id
+           union
+           select o.to_address_id, t.from_address_id
+           from address_stats_applied a
+                  inner join transactions t on t.id = a.id and t.block_id = a.block_id
+                  inner join transaction_outputs o on o.transaction_id = t.id and o.block_id = t.block_id
+           where t.from_address_id != o.to_address_id) p
+     order by p.address_id, p.counterparty_id)
+  ON CONFLICT DO NOTHING
+  returning address_id)
+update address_stats
+set counterparties_count = address_stats.counterparties_count + c.cnt
+from (select address_id, count(*) as cnt from pairs group by address_id) c
+where address_stats.address_id = c.address_id;
+	`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DROP TABLE address_stats_applied;`)
+		return err
+	})
+}
+
 func (r *Repository) LinkWithLiquidityPool(links []*models.TransactionLiquidityPool) error {
 	_, err := r.db.Model(&links).Insert()
 	return err
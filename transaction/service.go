@@ -1,26 +1,29 @@
 package transaction
 
 import (
-	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/MinterTeam/minter-explorer-extender/v2/address"
 	"github.com/MinterTeam/minter-explorer-extender/v2/broadcast"
+	"github.com/MinterTeam/minter-explorer-extender/v2/check"
 	"github.com/MinterTeam/minter-explorer-extender/v2/coin"
 	"github.com/MinterTeam/minter-explorer-extender/v2/env"
 	"github.com/MinterTeam/minter-explorer-extender/v2/liquidity_pool"
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-extender/v2/multisig"
 	"github.com/MinterTeam/minter-explorer-extender/v2/validator"
 	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
 	"github.com/MinterTeam/minter-go-sdk/v2/transaction"
 	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/go-pg/pg/v10"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/known/anypb"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -32,6 +35,7 @@ type Service struct {
 	coinRepository      *coin.Repository
 	coinService         *coin.Service
 	lpRepository        *liquidity_pool.Repository
+	multisigRepository  *multisig.Repository
 	broadcastService    *broadcast.Service
 	jobSaveTxs          chan []*models.Transaction
 	jobSaveTxsOutput    chan []*models.Transaction
@@ -39,6 +43,7 @@ type Service struct {
 	jobSaveInvalidTxs   chan []*models.InvalidTransaction
 	jobUnbondSaver      chan *models.Transaction
 	jobMoveStake        chan *api_pb.TransactionResponse
+	inTx                bool // jobs of other workers are queued by QueueJobs after the transaction is committed
 	logger              *logrus.Entry
 }
 
@@ -46,7 +51,7 @@ func NewService(env *env.ExtenderEnvironment, repository *Repository, addressRep
 	validatorRepository *validator.Repository, coinRepository *coin.Repository, coinService *coin.Service,
 	broadcastService *broadcast.Service, logger *logrus.Entry,
 	jobUnbondSaver chan *models.Transaction, liquidityPoolRepository *liquidity_pool.Repository,
-	jobMoveStake chan *api_pb.TransactionResponse) *Service {
+	jobMoveStake chan *api_pb.TransactionResponse, multisigRepository *multisig.Repository) *Service {
 
 	return &Service{
 		env:                 env,
@@ -56,6 +61,7 @@ func NewService(env *env.ExtenderEnvironment, repository *Repository, addressRep
 		coinService:         coinService,
 		validatorRepository: validatorRepository,
 		lpRepository:        liquidityPoolRepository,
+		multisigRepository:  multisigRepository,
 		broadcastService:    broadcastService,
 		jobSaveTxs:          make(chan []*models.Transaction, env.WrkSaveTxsCount),
 		jobSaveTxsOutput:    make(chan []*models.Transaction, env.WrkSaveTxsOutputCount),
@@ -80,10 +86,116 @@ func (s *Service) GetSaveTxValidatorJobChannel() chan []*models.TransactionValid
 	return s.jobSaveValidatorTxs
 }
 
+// WithTx Return service which saves transactions in the transaction of the database
+func (s *Service) WithTx(tx *pg.Tx) *Service {
+	service := *s
+	service.txRepository = s.txRepository.WithTx(tx)
+	service.coinService = s.coinService.WithTx(tx)
+	service.validatorRepository = s.validatorRepository.WithTx(tx)
+	service.inTx = true
+	return &service
+}
+
+// QueueJobs Pass saved transactions to stake, move stake and unbond workers.
+// In strict block mode it is called after the database transaction of the block is committed
+func (s *Service) QueueJobs(transactions []*api_pb.TransactionResponse, txList []*models.Transaction) {
+	for _, tx := range transactions {
+		if tx.Log == "" {
+			s.queueTxJobs(tx)
+		}
+	}
+	for _, tx := range txList {
+		if transaction.Type(tx.Type) == transaction.TypeUnbond {
+			s.jobUnbondSaver <- tx
+		}
+	}
+}
+
+func (s *Service) queueTxJobs(tx *api_pb.TransactionResponse) {
+	switch transaction.Type(tx.Type) {
+	case transaction.TypeDelegate,
+		transaction.TypeUnbond:
+		s.broadcastService.StakeChannel() <- tx
+	case transaction.TypeMoveStake:
+		s.jobMoveStake <- tx
+	}
+}
+
 // HandleTransactionsFromBlockResponse Handle response and save block to DB
 func (s *Service) HandleTransactionsFromBlockResponse(blockHeight uint64, blockCreatedAt time.Time,
 	transactions []*api_pb.TransactionResponse) error {
 
+	txList, invalidTxList, err := s.prepareTransactions(blockHeight, blockCreatedAt, transactions)
+	if err != nil {
+		return err
+	}
+
+	if len(txList) > 0 {
+		s.GetSaveTxJobChannel() <- txList
+		s.coinService.GetUpdateCoinsFromTxsJobChannel() <- txList
+	}
+
+	if len(invalidTxList) > 0 {
+		s.GetSaveInvalidTxsJobChannel() <- invalidTxList
+	}
+
+	return nil
+}
+
+// SaveTransactions Save transactions of the block with outputs and links synchronously.
+// Saved valid transactions are returned to be broadcast after the database transaction is committed
+func (s *Service) SaveTransactions(blockHeight uint64, blockCreatedAt time.Time,
+	transactions []*api_pb.TransactionResponse) ([]*models.Transaction, error) {
+
+	txList, invalidTxList, err := s.prepareTransactions(blockHeight, blockCreatedAt, transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(txList) > 0 {
+		err = s.txRepository.SaveAll(txList)
+		if err != nil {
+			return nil, err
+		}
+		links, err := s.getLinksTxValidator(txList)
+		if err != nil {
+			return nil, err
+		}
+		if len(links) > 0 {
+			err = s.txRepository.LinkWithValidators(links)
+			if err != nil {
+				return nil, err
+			}
+		}
+		lpLinks, err := s.getLinksLiquidityPool(txList)
+		if err != nil {
+			return nil, err
+		}
+		if len(lpLinks) > 0 {
+			err = s.txRepository.LinkWithLiquidityPool(lpLinks)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err = s.SaveAllTxOutputs(txList)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(invalidTxList) > 0 {
+		err = s.txRepository.SaveAllInvalid(invalidTxList)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return txList, nil
+}
+
+func (s *Service) prepareTransactions(blockHeight uint64, blockCreatedAt time.Time,
+	transactions []*api_pb.TransactionResponse) ([]*models.Transaction, []*models.InvalidTransaction, error) {
+
 	var txList []*models.Transaction
 	var invalidTxList []*models.InvalidTransaction
 
@@ -92,41 +204,23 @@ func (s *Service) HandleTransactionsFromBlockResponse(blockHeight uint64, blockC
 			txn, err := s.handleValidTransaction(tx, blockHeight, blockCreatedAt)
 			if err != nil {
 				s.logger.Error(err)
-				return err
+				return nil, nil, err
 			}
 			txList = append(txList, txn)
-			switch transaction.Type(tx.Type) {
-			//case transaction.TypeCreateSwapPool:
-			//	err := s.liquidityPoolService.CreateLiquidityPool(tx)
-			//	if err != nil {
-			//		return err
-			//	}
-			case transaction.TypeDelegate,
-				transaction.TypeUnbond:
-				s.broadcastService.StakeChannel() <- tx
-			case transaction.TypeMoveStake:
-				s.jobMoveStake <- tx
+			if !s.inTx {
+				s.queueTxJobs(tx)
 			}
 		} else {
 			txn, err := s.handleInvalidTransaction(tx, blockHeight, blockCreatedAt)
 			if err != nil {
 				s.logger.Error(err)
-				return err
+				return nil, nil, err
 			}
 			invalidTxList = append(invalidTxList, txn)
 		}
 	}
 
-	if len(txList) > 0 {
-		s.GetSaveTxJobChannel() <- txList
-		s.coinService.GetUpdateCoinsFromTxsJobChannel() <- txList
-	}
-
-	if len(invalidTxList) > 0 {
-		s.GetSaveInvalidTxsJobChannel() <- invalidTxList
-	}
-
-	return nil
+	return txList, invalidTxList, nil
 }
 
 func (s *Service) SaveTransactionsWorker(jobs <-chan []*models.Transaction) {
@@ -212,7 +306,36 @@ func (s *Service) SaveAllTxOutputs(txList []*models.Transaction) error {
 		idsList   []uint64
 	)
 
-	for _, tx := range txList {
+	// receivers of all send and multisend transactions are resolved with one query
+	sends := make(map[int]*api_pb.SendData)
+	multisends := make(map[int]*api_pb.MultiSendData)
+	var receivers []string
+	for i, tx := range txList {
+		switch transaction.Type(tx.Type) {
+		case transaction.TypeSend:
+			txData := new(api_pb.SendData)
+			if err := tx.IData.(*anypb.Any).UnmarshalTo(txData); err != nil {
+				return err
+			}
+			sends[i] = txData
+			receivers = append(receivers, helpers.RemovePrefix(txData.To))
+		case transaction.TypeMultisend:
+			txData := new(api_pb.MultiSendData)
+			if err := tx.IData.(*anypb.Any).UnmarshalTo(txData); err != nil {
+				return err
+			}
+			multisends[i] = txData
+			for _, receiver := range txData.List {
+				receivers = append(receivers, helpers.RemovePrefix(receiver.To))
+			}
+		}
+	}
+	receiverIds, err := s.addressRepository.FindIdsOrCreate(receivers)
+	if err != nil {
+		return err
+	}
+
+	for i, tx := range txList {
 		if tx.ID == 0 {
 			return errors.New("no transaction id")
 		}
@@ -224,40 +347,29 @@ func (s *Service) SaveAllTxOutputs(txList []*models.Transaction) error {
 			transaction.Type(tx.Type) != transaction.TypeRedeemCheck &&
 			transaction.Type(tx.Type) != transaction.TypeEditCoinOwner &&
 			transaction.Type(tx.Type) != transaction.TypeEditCandidate &&
+			transaction.Type(tx.Type) != transaction.TypeEditCommissionCandidate &&
 			transaction.Type(tx.Type) != transaction.TypeUnbond &&
 			transaction.Type(tx.Type) != transaction.TypeDelegate {
 			continue
 		}
 
 		if transaction.Type(tx.Type) == transaction.TypeSend {
-			txData := new(api_pb.SendData)
-			if err := tx.IData.(*anypb.Any).UnmarshalTo(txData); err != nil {
-				return err
-			}
-			toId, err := s.addressRepository.FindIdOrCreate(helpers.RemovePrefix(txData.To))
-			if err != nil {
-				return err
-			}
+			txData := sends[i]
 			list = append(list, &models.TransactionOutput{
 				TransactionID: tx.ID,
-				ToAddressID:   uint64(toId),
+				BlockID:       tx.BlockID,
+				ToAddressID:   uint64(receiverIds[helpers.RemovePrefix(txData.To)]),
 				CoinID:        uint(txData.Coin.Id),
 				Value:         txData.Value,
 			})
 		}
 		if transaction.Type(tx.Type) == transaction.TypeMultisend {
-			txData := new(api_pb.MultiSendData)
-			if err := tx.IData.(*anypb.Any).UnmarshalTo(txData); err != nil {
-				return err
-			}
+			txData := multisends[i]
 			for _, receiver := range txData.List {
-				toId, err := s.addressRepository.FindIdOrCreate(helpers.RemovePrefix(receiver.To))
-				if err != nil {
-					return err
-				}
 				list = append(list, &models.TransactionOutput{
 					TransactionID: tx.ID,
-					ToAddressID:   uint64(toId),
+					BlockID:       tx.BlockID,
+					ToAddressID:   uint64(receiverIds[helpers.RemovePrefix(receiver.To)]),
 					CoinID:        uint(receiver.Coin.Id),
 					Value:         receiver.Value,
 				})
@@ -269,44 +381,36 @@ func (s *Service) SaveAllTxOutputs(txList []*models.Transaction) error {
 			if err := tx.IData.(*anypb.Any).UnmarshalTo(txData); err != nil {
 				return err
 			}
-			data, err := transaction.DecodeCheckBase64(txData.RawCheck)
+			c, sender, err := check.Decode(txData.RawCheck)
 			if err != nil {
 				s.logger.WithFields(logrus.Fields{
 					"Tx": tx.Hash,
 				}).Error(err)
 				return err
 			}
-			sender, err := data.Sender()
-			if err != nil {
-				s.logger.Error(err)
-				return err
-			}
 			// We are put a creator of a check into "to" field
 			// because "from" field use for a person who created a transaction
-			toId, err := s.addressRepository.FindId(helpers.RemovePrefix(sender))
-			if err != nil {
-				s.logger.Fatal(err)
-				return err
-			}
-
-			rawCheck, err := base64.StdEncoding.DecodeString(txData.RawCheck)
+			toId, err := s.addressRepository.FindId(sender)
 			if err != nil {
 				s.logger.Fatal(err)
 				return err
 			}
 
-			checkList = append(checkList, &models.Check{
-				TransactionID: tx.ID,
-				Data:          hex.EncodeToString(rawCheck),
-				ToAddressId:   uint(tx.FromAddressID),
-				FromAddressId: toId,
-			})
+			// the gas of the redeem transaction is paid by the creator in the gas coin of the check
+			c.TransactionID = tx.ID
+			c.ToAddressId = uint(tx.FromAddressID)
+			c.FromAddressId = toId
+			c.GasAmount = tx.Tags["tx.commission_amount"]
+			c.BlockID = tx.BlockID
+			c.Status = models.CheckStatusRedeemed
+			checkList = append(checkList, c)
 
 			list = append(list, &models.TransactionOutput{
 				TransactionID: tx.ID,
+				BlockID:       tx.BlockID,
 				ToAddressID:   uint64(toId),
-				CoinID:        uint(data.Coin),
-				Value:         data.Value.String(),
+				CoinID:        uint(c.CoinID),
+				Value:         c.Value,
 			})
 		}
 
@@ -351,17 +455,45 @@ func (s *Service) SaveAllTxOutputs(txList []*models.Transaction) error {
 				return err
 			}
 
+			old := *v
 			v.OwnerAddressID = &newOwnerAddressId
 			v.ControlAddressID = &newControlAddressId
 			v.RewardAddressID = &newRewardAddressId
 
-			err = s.validatorRepository.Update(v)
+			err = s.validatorRepository.UpdateWithHistory(&old, v, tx.BlockID, tx.Hash)
 			if err != nil {
 				return err
 			}
 		}
 
-		if transaction.Type(tx.Type) == transaction.TypeUnbond {
+		if transaction.Type(tx.Type) == transaction.TypeEditCommissionCandidate {
+			txData := new(api_pb.EditCandidateCommission)
+			if err := tx.IData.(*anypb.Any).UnmarshalTo(txData); err != nil {
+				return err
+			}
+
+			vId, err := s.validatorRepository.FindIdByPk(helpers.RemovePrefix(txData.PubKey))
+			if err != nil {
+				return err
+			}
+
+			v, err := s.validatorRepository.GetById(vId)
+			if err != nil {
+				return err
+			}
+
+			old := *v
+			commission := txData.Commission
+			v.Commission = &commission
+
+			err = s.validatorRepository.UpdateWithHistory(&old, v, tx.BlockID, tx.Hash)
+			if err != nil {
+				return err
+			}
+		}
+
+		// in strict block mode unbonds are queued by QueueJobs after the transaction is committed
+		if transaction.Type(tx.Type) == transaction.TypeUnbond && !s.inTx {
 			s.jobUnbondSaver <- tx
 		}
 	}
@@ -373,7 +505,15 @@ func (s *Service) SaveAllTxOutputs(txList []*models.Transaction) error {
 		}
 	}
 	if len(idsList) > 0 {
-		err := s.txRepository.IndexTxAddress(idsList)
+		participants, err := s.getTxParticipants(txList)
+		if err != nil {
+			return err
+		}
+		err = s.txRepository.IndexTxAddress(participants)
+		if err != nil {
+			return err
+		}
+		err = s.txRepository.UpdateAddressStats(idsList)
 		if err != nil {
 			return err
 		}
@@ -446,7 +586,7 @@ func (s *Service) handleInvalidTransaction(tx *api_pb.TransactionResponse, block
 		return nil, err
 	}
 
-	return &models.InvalidTransaction{
+	invalidTx := &models.InvalidTransaction{
 		FromAddressID: uint64(fromId),
 		BlockID:       blockHeight,
 		CreatedAt:     blockCreatedAt,
@@ -463,7 +603,39 @@ func (s *Service) handleInvalidTransaction(tx *api_pb.TransactionResponse, block
 		Tags:          txTags,
 		Payload:       tx.Payload,
 		RawTx:         rawTxData[:rawTx],
-	}, nil
+		Code:          tx.Code,
+	}
+	err = setTxError(invalidTx, tx.Log, s.findCoinIdByFullSymbol)
+	if err != nil {
+		return nil, err
+	}
+	return invalidTx, nil
+}
+
+// findCoinIdByFullSymbol Find coin by the symbol the node writes in error logs, archived versions have the -N suffix
+func (s *Service) findCoinIdByFullSymbol(symbol string) (uint64, error) {
+	version := uint(0)
+	coins, err := s.coinRepository.GetCoinBySymbol(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if i := strings.LastIndex(symbol, "-"); len(coins) == 0 && i > 0 {
+		v, err := strconv.ParseUint(symbol[i+1:], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		version = uint(v)
+		coins, err = s.coinRepository.GetCoinBySymbol(symbol[:i])
+		if err != nil {
+			return 0, err
+		}
+	}
+	for _, c := range coins {
+		if c.Version == version {
+			return uint64(c.ID), nil
+		}
+	}
+	return 0, fmt.Errorf("coin %s not found", symbol)
 }
 
 func (s *Service) getLinksTxValidator(transactions []*models.Transaction) ([]*models.TransactionValidator, error) {
@@ -0,0 +1,67 @@
+package validator
+
+import (
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+)
+
+// newHistory Return the state of v with the list of fields changed since old, nil if nothing has changed.
+// Total stake is stored with the state but its change alone doesn't make a history row, it changes almost every refresh.
+// A nil old validator means the validator is new, so all of its set fields are changes
+func newHistory(old, v *models.Validator, blockId uint64, txHash string) *models.ValidatorHistory {
+	if old == nil {
+		old = new(models.Validator)
+	}
+	var changes []string
+	if old.PublicKey != v.PublicKey {
+		changes = append(changes, "public_key")
+	}
+	if !equalUint8(old.Status, v.Status) {
+		changes = append(changes, "status")
+	}
+	if !equalUint64(old.Commission, v.Commission) {
+		changes = append(changes, "commission")
+	}
+	if !equalUint(old.OwnerAddressID, v.OwnerAddressID) {
+		changes = append(changes, "owner_address_id")
+	}
+	if !equalUint(old.ControlAddressID, v.ControlAddressID) {
+		changes = append(changes, "control_address_id")
+	}
+	if !equalUint(old.RewardAddressID, v.RewardAddressID) {
+		changes = append(changes, "reward_address_id")
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	source := models.ValidatorHistorySourceTx
+	if txHash == "" {
+		source = models.ValidatorHistorySourceRefresh
+	}
+	return &models.ValidatorHistory{
+		ValidatorID:      v.ID,
+		BlockID:          blockId,
+		Source:           source,
+		TransactionHash:  txHash,
+		Changes:          changes,
+		PublicKey:        v.PublicKey,
+		Status:           v.Status,
+		Commission:       v.Commission,
+		TotalStake:       v.TotalStake,
+		OwnerAddressID:   v.OwnerAddressID,
+		ControlAddressID: v.ControlAddressID,
+		RewardAddressID:  v.RewardAddressID,
+	}
+}
+
+func equalUint(a, b *uint) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func equalUint8(a, b *uint8) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func equalUint64(a, b *uint64) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
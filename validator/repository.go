@@ -2,30 +2,53 @@ package validator
 
 import (
 	"errors"
+	"github.com/MinterTeam/minter-explorer-extender/v2/cache"
+	"github.com/MinterTeam/minter-explorer-extender/v2/database"
 	"github.com/MinterTeam/minter-explorer-extender/v2/models"
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 	"github.com/sirupsen/logrus"
-	"sync"
 )
 
 type Repository struct {
-	db      *pg.DB
-	cache   *sync.Map
-	pkCache *sync.Map
+	db      orm.DB
+	cache   *cache.Cache
+	pkCache *cache.Cache
 	log     *logrus.Entry
 }
 
-func NewRepository(db *pg.DB, logger *logrus.Entry) *Repository {
+func NewRepository(db *pg.DB, cacheConfig cache.Config, logger *logrus.Entry) *Repository {
 	return &Repository{
 		db:      db,
-		cache:   new(sync.Map), //TODO: добавить реализацию очистки
-		pkCache: new(sync.Map), //TODO: добавить реализацию очистки
+		cache:   cache.New("validator_by_pk", cacheConfig),
+		pkCache: cache.New("validator_pk", cacheConfig),
 		log: logger.WithFields(logrus.Fields{
 			"service": "Validator repository",
 		}),
 	}
 }
 
+// WithTx Return repository which runs queries in the transaction, caches are shared
+func (r *Repository) WithTx(tx *pg.Tx) *Repository {
+	repository := *r
+	repository.db = tx
+	return &repository
+}
+
+// WarmUp Load all validator public keys into the cache
+func (r *Repository) WarmUp() (int, error) {
+	var list []models.ValidatorPublicKeys
+	err := r.db.Model(&list).Select()
+	if err != nil {
+		return 0, err
+	}
+	for _, vpk := range list {
+		r.cache.Store(vpk.Key, vpk.ValidatorId)
+		r.pkCache.Store(vpk.Key, vpk.ID)
+	}
+	return len(list), nil
+}
+
 func (r *Repository) AddUnbond(unbond *models.Unbond) error {
 	_, err := r.db.Model(unbond).Insert()
 	return err
@@ -148,30 +171,36 @@ func (r *Repository) Update(validator *models.Validator) error {
 	return err
 }
 
-func (r *Repository) DeleteStakesNotInListIds(idList []uint64) error {
-	if len(idList) > 0 {
-		_, err := r.db.Query(nil, `delete from stakes where id not in (?) and is_kicked != true;`, pg.In(idList))
-		return err
-	}
-	return nil
-}
-
-func (r *Repository) DeleteStakesByValidatorIds(idList []uint64) error {
-	if len(idList) > 0 {
-		_, err := r.db.Query(nil, `delete from stakes where validator_id in (?) and is_kicked != true;`, pg.In(idList))
+// UpdateWithHistory Update the validator and record the change made by the transaction in validator_history
+func (r *Repository) UpdateWithHistory(old, v *models.Validator, blockId uint64, txHash string) error {
+	history := newHistory(old, v, blockId, txHash)
+	return database.RunInTransaction(r.db, func(tx *pg.Tx) error {
+		_, err := tx.Model(v).WherePK().Update()
+		if err != nil {
+			return err
+		}
+		if history == nil {
+			return nil
+		}
+		_, err = tx.Model(history).Insert()
 		return err
-	}
-	return nil
+	})
 }
 
-func (r *Repository) SaveAllStakes(stakes []*models.Stake) error {
-	_, err := r.db.Model(&stakes).OnConflict("(owner_address_id, validator_id, coin_id, is_kicked) DO UPDATE").Insert()
+func (r *Repository) SaveHistory(list []*models.ValidatorHistory) error {
+	_, err := r.db.Model(&list).Insert()
 	return err
 }
 
-func (r *Repository) ResetAllStatuses() error {
-	_, err := r.db.Query(nil, `update validators set status = null;`)
-	return err
+// ResetStatusesExcept Clear status of validators which are not candidates anymore and return them
+func (r *Repository) ResetStatusesExcept(ids []uint) ([]*models.Validator, error) {
+	var list []*models.Validator
+	query := r.db.Model(&list).Set("status = null").Where("status IS NOT NULL")
+	if len(ids) > 0 {
+		query = query.Where("id NOT IN (?)", pg.In(ids))
+	}
+	_, err := query.Returning("*").Update()
+	return list, err
 }
 
 func (r *Repository) FindPkId(pk string) (uint, error) {
@@ -189,28 +218,49 @@ func (r *Repository) FindPkId(pk string) (uint, error) {
 	return validator.ID, nil
 }
 
-func (r *Repository) UpdateStake(s *models.Stake) error {
-	_, err := r.db.Model(s).OnConflict("(owner_address_id, coin_id, validator_id, is_kicked) DO UPDATE").Insert()
-	return err
-}
-func (r *Repository) UpdateStakes(list []*models.Stake) error {
-	_, err := r.db.Model(&list).OnConflict("(owner_address_id, coin_id, validator_id, is_kicked) DO UPDATE").Insert()
-	return err
-}
-
-func (r *Repository) DeleteFromWaitList(addressId, validatorId uint, coins []uint64) error {
-	_, err := r.db.Model().Exec(`
-		DELETE FROM stakes
-		WHERE owner_address_id = ? AND validator_id = ? AND is_kicked = true AND coin_id NOT IN (?);
-	`, addressId, validatorId, pg.In(coins))
-	return err
+// UpdateStakesBipValue Recalculate bip value of stakes by new coin prices
+// and total stake of validators which have stakes in these coins
+func (r *Repository) UpdateStakesBipValue(prices []models.CoinPrice) error {
+	var coinIds []uint64
+	return database.RunInTransaction(r.db, func(tx *pg.Tx) error {
+		for _, p := range prices {
+			_, err := tx.Model((*models.Stake)(nil)).
+				Set("bip_value = trunc(value * ?::numeric)", p.PriceBip).
+				Where("coin_id = ?", p.CoinID).
+				Update()
+			if err != nil {
+				return err
+			}
+			coinIds = append(coinIds, p.CoinID)
+		}
+		var validatorIds []uint
+		err := tx.Model((*models.Stake)(nil)).
+			ColumnExpr("DISTINCT validator_id").
+			Where("coin_id IN (?)", pg.In(coinIds)).
+			Select(&validatorIds)
+		if err != nil {
+			return err
+		}
+		return UpdateTotalStake(tx, validatorIds)
+	})
 }
 
-func (r *Repository) RemoveFromWaitList(addressId, validatorId uint) error {
-	_, err := r.db.Model().Exec(`
-		DELETE FROM stakes
-		WHERE owner_address_id = ? AND validator_id = ? AND is_kicked = true ;
-	`, addressId, validatorId)
+// UpdateTotalStake Set total stake of validators to the sum of bip values of their active stakes.
+// The periodic refresh writes total stake from node, the sum keeps it actual between refreshes
+func UpdateTotalStake(tx *pg.Tx, validatorIds []uint) error {
+	if len(validatorIds) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(`
+		UPDATE validators SET total_stake = s.total
+		FROM (
+			SELECT v.id, coalesce(sum(stakes.bip_value), 0) AS total FROM validators v
+			LEFT JOIN stakes ON stakes.validator_id = v.id AND stakes.is_kicked = false
+			WHERE v.id IN (?)
+			GROUP BY v.id
+		) s
+		WHERE validators.id = s.id AND validators.total_stake IS DISTINCT FROM s.total;
+	`, pg.In(validatorIds))
 	return err
 }
 
@@ -219,43 +269,40 @@ func (r *Repository) SaveBan(ban *models.ValidatorBan) error {
 	return err
 }
 
-func (r *Repository) GetStake(addressId, validatorId, coinId uint64) (*models.Stake, error) {
-	stk := new(models.Stake)
-
-	err := r.db.Model(stk).
-		Where("owner_address_id = ?", addressId).
-		Where("validator_id = ?", validatorId).
-		Where("coin_id = ?", coinId).
-		Select()
-
-	return stk, err
-}
-
 func (r *Repository) MoveStake(ms *models.MovedStake) error {
 	_, err := r.db.Model(ms).Insert()
 	return err
 }
 
-func (r *Repository) DeleteOldUnbonds(height uint64) interface{} {
-	_, err := r.db.Model().Exec(`
-		DELETE FROM unbonds
-		WHERE block_id < ?;
-	`, height)
-	return err
-}
-
-func (r *Repository) DeleteOldMovedStakes(height uint64) interface{} {
-	_, err := r.db.Model().Exec(`
-		DELETE FROM moved_stakes
-		WHERE block_id < ?;
-	`, height)
-	return err
+// BlockAddresses Owner, control and reward addresses of a validator at the block
+type BlockAddresses struct {
+	ValidatorID      uint
+	BlockID          uint64
+	OwnerAddressID   *uint
+	ControlAddressID *uint
+	RewardAddressID  *uint
 }
 
-func (r *Repository) DeleteStake(addressId, validatorId, coinId uint64) error {
-	_, err := r.db.Model().Exec(`
-		DELETE FROM stakes
-		WHERE owner_address_id = ? AND validator_id = ? AND coin_id = ? ;
-	`, addressId, validatorId, coinId)
-	return err
+// GetAddressesAtBlocks Return addresses of validators at the blocks from the last validator_history row up to the block,
+// current addresses are returned for validators without history before the block. validatorIds and blockIds are pairs
+func (r *Repository) GetAddressesAtBlocks(validatorIds []uint, blockIds []uint64) ([]*BlockAddresses, error) {
+	var list []*BlockAddresses
+	if len(validatorIds) == 0 {
+		return list, nil
+	}
+	_, err := r.db.Query(&list, `
+		SELECT l.validator_id, l.block_id,
+			CASE WHEN h.id IS NULL THEN v.owner_address_id ELSE h.owner_address_id END AS owner_address_id,
+			CASE WHEN h.id IS NULL THEN v.control_address_id ELSE h.control_address_id END AS control_address_id,
+			CASE WHEN h.id IS NULL THEN v.reward_address_id ELSE h.reward_address_id END AS reward_address_id
+		FROM (SELECT DISTINCT * FROM unnest(?::integer[], ?::bigint[])) AS l (validator_id, block_id)
+		JOIN validators v ON v.id = l.validator_id
+		LEFT JOIN LATERAL (
+			SELECT id, owner_address_id, control_address_id, reward_address_id FROM validator_history
+			WHERE validator_history.validator_id = l.validator_id AND validator_history.block_id <= l.block_id
+			ORDER BY validator_history.block_id DESC, validator_history.id DESC
+			LIMIT 1
+		) h ON true;
+	`, pg.Array(validatorIds), pg.Array(blockIds))
+	return list, err
 }
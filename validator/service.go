@@ -12,7 +12,6 @@ import (
 	"github.com/MinterTeam/node-grpc-gateway/api_pb"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/types/known/anypb"
-	"math/big"
 	"sync/atomic"
 	"time"
 )
@@ -33,12 +32,10 @@ type Service struct {
 	addressRepository   *address.Repository
 	coinRepository      *coin.Repository
 	jobUpdateValidators chan uint64
-	jobClearChannel     chan uint64
 	jobUnbondSaver      chan *models.Transaction
 	jobMoveStake        chan *api_pb.TransactionResponse
+	jobUpdateBipValue   chan []models.CoinPrice
 	logger              *logrus.Entry
-	//jobUpdateStakes     chan uint64
-	//jobUpdateWaitList chan *models.Transaction
 }
 
 func NewService(env *env.ExtenderEnvironment, nodeApi *grpc_client.Client, repository *Repository, addressRepository *address.Repository, coinRepository *coin.Repository, logger *logrus.Entry) *Service {
@@ -53,28 +50,16 @@ func NewService(env *env.ExtenderEnvironment, nodeApi *grpc_client.Client, repos
 		coinRepository:      coinRepository,
 		logger:              logger,
 		jobUpdateValidators: make(chan uint64, 1),
-		jobClearChannel:     make(chan uint64, 1),
 		jobUnbondSaver:      make(chan *models.Transaction, 1),
 		jobMoveStake:        make(chan *api_pb.TransactionResponse, 1),
-		//jobUpdateWaitList: make(chan *models.Transaction, 1),
-		//jobUpdateStakes:     make(chan uint64, 1),
+		jobUpdateBipValue:   make(chan []models.CoinPrice, 100),
 	}
 }
 
-func (s *Service) GetClearJobChannel() chan uint64 {
-	return s.jobClearChannel
-}
-
 func (s *Service) GetUpdateValidatorsJobChannel() chan uint64 {
 	return s.jobUpdateValidators
 }
 
-//func (s *Service) GetUpdateStakesJobChannel() chan uint64 {
-//	return s.jobUpdateStakes
-//}
-//func (s *Service) GetUpdateWaitListJobChannel() chan *models.Transaction {
-//	return s.jobUpdateWaitList
-//}
 func (s *Service) GetUnbondSaverJobChannel() chan *models.Transaction {
 	return s.jobUnbondSaver
 }
@@ -82,19 +67,29 @@ func (s *Service) GetMoveStakeJobChannel() chan *api_pb.TransactionResponse {
 	return s.jobMoveStake
 }
 
-func (s *Service) ClearMoveStakeAndUnbondWorker(height <-chan uint64) {
-	for h := range height {
+func (s *Service) GetUpdateBipValueJobChannel() chan []models.CoinPrice {
+	return s.jobUpdateBipValue
+}
 
-		err := s.repository.DeleteOldUnbonds(h)
-		if err != nil {
-			s.logger.Error(err)
+// UpdateBipValueWorker Revalue stakes in coins which price has been changed
+// and keep validators total stake actual between refreshes from node
+func (s *Service) UpdateBipValueWorker(jobs <-chan []models.CoinPrice) {
+	for prices := range jobs {
+		var list []models.CoinPrice
+		for _, p := range prices {
+			// stakes in base coin always have bip value equal to value
+			if p.CoinID == 0 {
+				continue
+			}
+			list = append(list, p)
 		}
-
-		err = s.repository.DeleteOldMovedStakes(h)
+		if len(list) == 0 {
+			continue
+		}
+		err := s.repository.UpdateStakesBipValue(list)
 		if err != nil {
 			s.logger.Error(err)
 		}
-
 	}
 }
 
@@ -130,43 +125,14 @@ func (s *Service) MoveStakeWorker(data <-chan *api_pb.TransactionResponse) {
 			FromValidatorId: uint64(fromId),
 			ToValidatorId:   uint64(toId),
 			Value:           txData.Value,
+			Status:          models.StakeOperationPending,
+			TransactionHash: helpers.RemovePrefix(tx.Hash),
 		}
 
-		//if err = s.UpdateWaitList(tx.From, txData.FromPubKey); err != nil {
-		//	s.logger.Error(err)
-		//}
-
 		err = s.repository.MoveStake(ms)
 		if err != nil {
 			s.logger.Error(err)
 		}
-
-		stk, err := s.repository.GetStake(uint64(aId), uint64(fromId), txData.Coin.Id)
-
-		currentVal, ok := big.NewInt(0).SetString(stk.Value, 10)
-		if !ok {
-			s.logger.Error("can't convert to big.Int")
-			continue
-		}
-		moveVal, ok := big.NewInt(0).SetString(stk.Value, 10)
-		if !ok {
-			s.logger.Error("can't convert to big.Int")
-			continue
-		}
-
-		newVal := currentVal.Sub(currentVal, moveVal)
-
-		if newVal.Cmp(big.NewInt(0)) <= 0 {
-			if err = s.repository.DeleteStake(uint64(aId), uint64(fromId), txData.Coin.Id); err != nil {
-				s.logger.Error(err)
-			}
-		} else {
-			stk.Value = newVal.String()
-			if err = s.repository.UpdateStake(stk); err != nil {
-				s.logger.Error(err)
-			}
-		}
-
 	}
 }
 
@@ -185,72 +151,22 @@ func (s *Service) UnbondSaverWorker(data <-chan *models.Transaction) {
 		}
 
 		unbond := &models.Unbond{
-			BlockId:     uint(tx.BlockID + s.GetUnbondBlockCount()),
-			AddressId:   uint(tx.FromAddressID),
-			CoinId:      uint(txData.Coin.Id),
-			ValidatorId: vId,
-			Value:       txData.Value,
+			BlockId:         uint(tx.BlockID + s.GetUnbondBlockCount()),
+			AddressId:       uint(tx.FromAddressID),
+			CoinId:          uint(txData.Coin.Id),
+			ValidatorId:     vId,
+			Value:           txData.Value,
+			Status:          models.StakeOperationPending,
+			TransactionHash: tx.Hash,
 		}
 
 		err = s.repository.AddUnbond(unbond)
 		if err != nil {
 			s.logger.Error(err)
 		}
-
-		//adr, err := s.addressRepository.FindById(uint(tx.FromAddressID))
-		//if err != nil {
-		//	s.logger.Error(err)
-		//} else {
-		//	if err = s.UpdateWaitList(adr, txData.PubKey); err != nil {
-		//		s.logger.Error(err)
-		//	}
-		//}
-
 	}
 }
 
-//func (s *Service) UpdateWaitListWorker(data <-chan *models.Transaction) {
-//	for tx := range data {
-//
-//		var adr, pk string
-//
-//		adr, err := s.addressRepository.FindById(uint(tx.FromAddressID))
-//		if err != nil {
-//			s.logger.Error(err)
-//			continue
-//		}
-//
-//		if transaction.Type(tx.Type) == transaction.TypeUnbond {
-//			txData := new(api_pb.UnbondData)
-//			if err = tx.IData.(*anypb.Any).UnmarshalTo(txData); err != nil {
-//				s.logger.Error(err)
-//				continue
-//			}
-//			pk = txData.PubKey
-//		}
-//		if transaction.Type(tx.Type) == transaction.TypeDelegate {
-//			txData := new(api_pb.DelegateData)
-//			if err = tx.IData.(*anypb.Any).UnmarshalTo(txData); err != nil {
-//				s.logger.Error(err)
-//				continue
-//			}
-//			pk = txData.PubKey
-//		}
-//
-//		start := time.Now()
-//		if err = s.UpdateWaitList(adr, pk); err != nil {
-//			s.logger.Error(err)
-//		}
-//		elapsed := time.Since(start)
-//		critical := 5 * time.Second
-//		if elapsed > critical {
-//			s.logger.Error(fmt.Sprintf("WaitList updating time: %s", elapsed))
-//		} else {
-//			s.logger.Info(fmt.Sprintf("WaitList updating time: %s", elapsed))
-//		}
-//	}
-//}
-
 func (s *Service) UpdateValidatorsWorker(jobs <-chan uint64) {
 	for height := range jobs {
 		status, err := s.nodeApi.Status()
@@ -280,6 +196,8 @@ func (s *Service) UpdateValidatorsWorker(jobs <-chan uint64) {
 
 		var (
 			validators      []*models.Validator
+			validatorIds    []uint
+			history         []*models.ValidatorHistory
 			validatorsPkMap = make(map[string]struct{})
 			addressesMap    = make(map[string]struct{})
 		)
@@ -324,11 +242,12 @@ func (s *Service) UpdateValidatorsWorker(jobs <-chan uint64) {
 				s.logger.Error(err)
 				continue
 			}
-			controlAddressID, err := s.addressRepository.FindIdOrCreate(helpers.RemovePrefix(validator.OwnerAddress))
+			controlAddressID, err := s.addressRepository.FindIdOrCreate(helpers.RemovePrefix(validator.ControlAddress))
 			if err != nil {
 				s.logger.Error(err)
 				continue
 			}
+			old := *v
 			v.Status = &status
 			v.TotalStake = &totalStake
 			v.UpdateAt = &updateAt
@@ -337,147 +256,34 @@ func (s *Service) UpdateValidatorsWorker(jobs <-chan uint64) {
 			v.ControlAddressID = &controlAddressID
 			v.RewardAddressID = &rewardAddressID
 			validators = append(validators, v)
+			validatorIds = append(validatorIds, v.ID)
+			if h := newHistory(&old, v, height, ""); h != nil {
+				history = append(history, h)
+			}
 		}
-		err = s.repository.ResetAllStatuses()
+		reset, err := s.repository.ResetStatusesExcept(validatorIds)
 		if err != nil {
 			s.logger.Error(err)
 		}
+		for _, v := range reset {
+			// the previous status isn't returned, any non-null value marks the status as changed
+			previous := *v
+			previous.Status = new(uint8)
+			history = append(history, newHistory(&previous, v, height, ""))
+		}
 		err = s.repository.UpdateAll(validators)
 		if err != nil {
 			s.logger.Error(err)
 		}
+		if len(history) > 0 {
+			err = s.repository.SaveHistory(history)
+			if err != nil {
+				s.logger.Error(err)
+			}
+		}
 	}
 }
 
-//func (s *Service) UpdateStakesWorker(blockHeight <-chan uint64) {
-//	for height := range blockHeight {
-//		start := time.Now()
-//		status, err := s.nodeApi.Status()
-//		if err != nil {
-//			s.logger.Error(err)
-//			continue
-//		}
-//
-//		if status.LatestBlockHeight-height > ChasingModDiff {
-//			continue
-//		}
-//
-//		if height%UpdateTimoutInBlocks != 0 {
-//			continue
-//		}
-//
-//		s.logger.Warning("UPDATING STAKES")
-//
-//		resp, err := s.nodeApi.CandidatesExtended(true, false, "")
-//		if err != nil {
-//			s.logger.WithField("Block", height).Error(err)
-//			continue
-//		}
-//
-//		var (
-//			stakes       []*models.Stake
-//			validatorIds = make([]uint64, len(resp.Candidates))
-//			addressesMap = make(map[string]struct{})
-//		)
-//
-//		validatorsPkMap := make(map[string]struct{})
-//		// Collect all PubKey's and addresses for save it before
-//		for _, vlr := range resp.Candidates {
-//			validatorsPkMap[helpers.RemovePrefix(vlr.PublicKey)] = struct{}{}
-//			addressesMap[helpers.RemovePrefix(vlr.RewardAddress)] = struct{}{}
-//			addressesMap[helpers.RemovePrefix(vlr.OwnerAddress)] = struct{}{}
-//			for _, stake := range vlr.Stakes {
-//				addressesMap[helpers.RemovePrefix(stake.Owner)] = struct{}{}
-//			}
-//		}
-//
-//		err = s.repository.SaveAllIfNotExist(validatorsPkMap)
-//		if err != nil {
-//			s.logger.Error(err)
-//			continue
-//		}
-//
-//		err = s.addressRepository.SaveFromMapIfNotExists(addressesMap)
-//		if err != nil {
-//			s.logger.Error(err)
-//			continue
-//		}
-//
-//		for i, vlr := range resp.Candidates {
-//			id, err := s.repository.FindIdByPk(helpers.RemovePrefix(vlr.PublicKey))
-//			if err != nil {
-//				s.logger.WithField("pk", vlr.PublicKey).Error(err)
-//				continue
-//			}
-//			validatorIds[i] = uint64(id)
-//			for _, stake := range vlr.Stakes {
-//				ownerAddressID, err := s.addressRepository.FindIdOrCreate(helpers.RemovePrefix(stake.Owner))
-//				if err != nil {
-//					s.logger.Error(err)
-//					continue
-//				}
-//				stakes = append(stakes, &models.Stake{
-//					ValidatorID:    id,
-//					OwnerAddressID: ownerAddressID,
-//					CoinID:         uint(stake.Coin.Id),
-//					Value:          stake.Value,
-//					BipValue:       stake.BipValue,
-//				})
-//			}
-//		}
-//
-//		chunksCount := int(math.Ceil(float64(len(stakes)) / float64(s.env.StakeChunkSize)))
-//
-//		wg := new(sync.WaitGroup)
-//		wg.Add(chunksCount)
-//
-//		for i := 0; i < chunksCount; i++ {
-//			start := s.env.StakeChunkSize * i
-//			end := start + s.env.StakeChunkSize
-//			if end > len(stakes) {
-//				end = len(stakes)
-//			}
-//
-//			go func(stakes []*models.Stake) {
-//				err = s.repository.SaveAllStakes(stakes)
-//				if err != nil {
-//					var coinsList []string
-//					coinMap := make(map[uint]struct{})
-//					for _, s := range stakes {
-//						coinMap[s.CoinID] = struct{}{}
-//					}
-//					for id := range coinMap {
-//						coinsList = append(coinsList, fmt.Sprintf("%d", id))
-//					}
-//					s.logger.WithFields(logrus.Fields{
-//						"coins": strings.Join(coinsList, ","),
-//						"block": height,
-//					}).Fatal(err)
-//				}
-//				wg.Done()
-//			}(stakes[start:end])
-//		}
-//
-//		wg.Wait()
-//		stakesId := make([]uint64, len(stakes))
-//		for i, stake := range stakes {
-//			stakesId[i] = uint64(stake.ID)
-//			//err = s.UpdateWaitListByStake(stake)
-//			//if err != nil {
-//			//	s.logger.Error(err)
-//			//}
-//		}
-//
-//		err = s.repository.DeleteStakesNotInListIds(stakesId)
-//		if err != nil {
-//			s.logger.Error(err)
-//		}
-//
-//		elapsed := time.Since(start)
-//		s.logger.Warning(fmt.Sprintf("Stake has been updated. Block: %d Processing time: %s", height, elapsed))
-//	}
-//}
-
 // HandleBlockResponse Get validators PK from response and store it to validators table if not exist
 func (s *Service) HandleBlockResponse(response *api_pb.BlockResponse) error {
 	validatorsPkMap := make(map[string]struct{})
@@ -498,13 +304,21 @@ func (s *Service) HandleBlockResponse(response *api_pb.BlockResponse) error {
 				return err
 			}
 
-			_, err := s.repository.FindIdByPkOrCreate(helpers.RemovePrefix(txData.PubKey))
+			vId, err := s.repository.FindIdByPkOrCreate(helpers.RemovePrefix(txData.PubKey))
+			if err != nil {
+				return err
+			}
+			// failed transactions don't declare the candidate
+			if tx.Log != "" {
+				continue
+			}
+			err = s.declareCandidacy(vId, tx, txData)
 			if err != nil {
 				return err
 			}
 		}
 
-		if transaction.Type(tx.Type) == transaction.TypeEditCandidatePublicKey {
+		if transaction.Type(tx.Type) == transaction.TypeEditCandidatePublicKey && tx.Log == "" {
 			txData := new(api_pb.EditCandidatePublicKeyData)
 			if err := tx.Data.UnmarshalTo(txData); err != nil {
 				return err
@@ -525,8 +339,9 @@ func (s *Service) HandleBlockResponse(response *api_pb.BlockResponse) error {
 				return err
 			}
 
+			old := *v
 			v.PublicKey = helpers.RemovePrefix(txData.NewPubKey)
-			err = s.repository.Update(v)
+			err = s.repository.UpdateWithHistory(&old, v, response.Height, helpers.RemovePrefix(tx.Hash))
 			if err != nil {
 				return err
 			}
@@ -536,6 +351,33 @@ func (s *Service) HandleBlockResponse(response *api_pb.BlockResponse) error {
 	return nil
 }
 
+// declareCandidacy Set commission and addresses of the declared candidate
+func (s *Service) declareCandidacy(id uint, tx *api_pb.TransactionResponse, txData *api_pb.DeclareCandidacyData) error {
+	v, err := s.repository.GetById(id)
+	if err != nil {
+		return err
+	}
+	addressIds, err := s.addressRepository.FindIdsOrCreate([]string{
+		helpers.RemovePrefix(tx.From),
+		helpers.RemovePrefix(txData.Address),
+	})
+	if err != nil {
+		return err
+	}
+	ownerAddressId := addressIds[helpers.RemovePrefix(tx.From)]
+	rewardAddressId := addressIds[helpers.RemovePrefix(txData.Address)]
+	commission := txData.Commission
+	createdAt := uint(tx.Height)
+
+	old := *v
+	v.OwnerAddressID = &ownerAddressId
+	v.ControlAddressID = &ownerAddressId
+	v.RewardAddressID = &rewardAddressId
+	v.Commission = &commission
+	v.CreatedAtBlockID = &createdAt
+	return s.repository.UpdateWithHistory(&old, v, tx.Height, helpers.RemovePrefix(tx.Hash))
+}
+
 func (s *Service) HandleCandidateResponse(response *api_pb.CandidateResponse) (*models.Validator, []*models.Stake, error) {
 	validator := new(models.Validator)
 	status := uint8(response.Status)
@@ -604,122 +446,13 @@ func (s *Service) GetStakesFromCandidateResponse(response *api_pb.CandidateRespo
 	return stakes, nil
 }
 
-//func (s *Service) UpdateWaitList(adr, pk string) error {
-//	var err error
-//	var addressId uint
-//	var data *api_pb.WaitListResponse
-//
-//	strRune := []rune(adr)
-//	prefix := string(strRune[0:2])
-//
-//	if strings.ToLower(prefix) == "mx" {
-//		data, err = s.nodeApi.WaitList(pk, adr)
-//	} else {
-//		data, err = s.nodeApi.WaitList(pk, fmt.Sprintf("Mx%s", adr))
-//	}
-//	if err != nil {
-//		return err
-//	}
-//
-//	if strings.ToLower(prefix) == "mx" {
-//		addressId, err = s.addressRepository.FindIdOrCreate(helpers.RemovePrefix(adr))
-//	} else {
-//		addressId, err = s.addressRepository.FindIdOrCreate(adr)
-//	}
-//	if err != nil {
-//		return err
-//	}
-//
-//	vId, err := s.repository.FindIdByPk(helpers.RemovePrefix(pk))
-//	if err != nil {
-//		return err
-//	}
-//
-//	if len(data.List) == 0 {
-//		return s.repository.RemoveFromWaitList(addressId, vId)
-//	}
-//
-//	var existCoins []uint64
-//	var stakes []*models.Stake
-//
-//	for _, item := range data.List {
-//		existCoins = append(existCoins, item.Coin.Id)
-//
-//		bipValue := "0"
-//		if item.Coin.Id == 0 {
-//			bipValue = item.Value
-//		}
-//
-//		stakes = append(stakes, &models.Stake{
-//			OwnerAddressID: addressId,
-//			CoinID:         uint(item.Coin.Id),
-//			ValidatorID:    vId,
-//			Value:          item.Value,
-//			BipValue:       bipValue,
-//			IsKicked:       true,
-//		})
-//
-//	}
-//
-//	err = s.repository.UpdateStakes(stakes)
-//	if err != nil {
-//		s.logger.Error(err)
-//	}
-//
-//	return s.repository.DeleteFromWaitList(addressId, vId, existCoins)
-//}
-
-//func (s *Service) UpdateWaitListByStake(stake *models.Stake) error {
-//	var data *api_pb.WaitListResponse
-//
-//	adr, err := s.addressRepository.FindById(stake.OwnerAddressID)
-//	if err != nil {
-//		return err
-//	}
-//
-//	pk, err := s.repository.GetById(stake.ValidatorID)
-//	if err != nil {
-//		return err
-//	}
-//
-//	data, err = s.nodeApi.WaitList(pk.GetPublicKey(), fmt.Sprintf("Mx%s", adr))
-//	if err != nil {
-//		return err
-//	}
-//
-//	var existCoins []uint64
-//	var stakes []*models.Stake
-//
-//	for _, item := range data.List {
-//		existCoins = append(existCoins, item.Coin.Id)
-//
-//		bipValue := "0"
-//		if item.Coin.Id == 0 {
-//			bipValue = item.Value
-//		}
-//
-//		stakes = append(stakes, &models.Stake{
-//			OwnerAddressID: stake.OwnerAddressID,
-//			CoinID:         uint(item.Coin.Id),
-//			ValidatorID:    stake.ValidatorID,
-//			Value:          item.Value,
-//			BipValue:       bipValue,
-//			IsKicked:       true,
-//		})
-//	}
-//
-//	if len(stakes) > 0 {
-//		err = s.repository.UpdateStakes(stakes)
-//		if err != nil {
-//			s.logger.Error(err)
-//		}
-//		return s.repository.DeleteFromWaitList(stake.OwnerAddressID, stake.ValidatorID, existCoins)
-//	}
-//	return nil
-//}
-
 func (s *Service) GetUnbondBlockCount() uint64 {
-	if s.env.BaseCoin == "MNT" {
+	return UnbondPeriod(s.env.BaseCoin)
+}
+
+// UnbondPeriod Return the number of blocks unbonded values and stakes locked by LockStake are kept by the network
+func UnbondPeriod(baseCoin string) uint64 {
+	if baseCoin == "MNT" {
 		return UnbondBlockCountTestnet
 	}
 	return UnbondBlockCount
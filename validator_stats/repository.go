@@ -0,0 +1,207 @@
+package validator_stats
+
+import (
+	"context"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/go-pg/pg/v10"
+)
+
+type Repository struct {
+	db *pg.DB
+}
+
+func NewRepository(db *pg.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+// SaveStats Add block counters to stored ones.
+// Rows already updated by the same or a later block are left untouched, so a block can be safely handled twice.
+// Return false when the block was saved before
+func (r *Repository) SaveStats(stats []*models.ValidatorStats, daily []*models.ValidatorDailyUptime) (bool, error) {
+	applied := len(stats) == 0
+	err := r.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		if len(stats) > 0 {
+			res, err := tx.Model(&stats).
+				OnConflict("(validator_id) DO UPDATE").
+				Set("signed_blocks = validator_stats.signed_blocks + EXCLUDED.signed_blocks").
+				Set("missed_blocks = validator_stats.missed_blocks + EXCLUDED.missed_blocks").
+				Set("proposed_blocks = validator_stats.proposed_blocks + EXCLUDED.proposed_blocks").
+				Set("missed_in_window = EXCLUDED.missed_in_window").
+				Set("miss_streak = EXCLUDED.miss_streak").
+				Set("max_miss_streak = greatest(validator_stats.max_miss_streak, EXCLUDED.max_miss_streak)").
+				Set("jails_count = validator_stats.jails_count + EXCLUDED.jails_count").
+				Set("last_jailed_block_id = coalesce(EXCLUDED.last_jailed_block_id, validator_stats.last_jailed_block_id)").
+				Set("jailed_until_block_id = coalesce(EXCLUDED.jailed_until_block_id, validator_stats.jailed_until_block_id)").
+				Set("last_block_id = EXCLUDED.last_block_id").
+				Where("validator_stats.last_block_id < EXCLUDED.last_block_id").
+				Insert()
+			if err != nil {
+				return err
+			}
+			// all rows of a block are saved in one transaction, so none of them is updated on a replay
+			applied = res.RowsAffected() > 0
+		}
+		if len(daily) > 0 {
+			_, err := tx.Model(&daily).
+				OnConflict("(validator_id, date) DO UPDATE").
+				Set("signed_blocks = validator_daily_uptime.signed_blocks + EXCLUDED.signed_blocks").
+				Set("missed_blocks = validator_daily_uptime.missed_blocks + EXCLUDED.missed_blocks").
+				Set("proposed_blocks = validator_daily_uptime.proposed_blocks + EXCLUDED.proposed_blocks").
+				Set(`uptime = round(100.0 * (validator_daily_uptime.signed_blocks + EXCLUDED.signed_blocks) /
+					greatest(validator_daily_uptime.signed_blocks + EXCLUDED.signed_blocks + validator_daily_uptime.missed_blocks + EXCLUDED.missed_blocks, 1), 2)`).
+				Set("last_block_id = EXCLUDED.last_block_id").
+				Where("validator_daily_uptime.last_block_id < EXCLUDED.last_block_id").
+				Insert()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return applied, err
+}
+
+func (r *Repository) GetAllStats() ([]*models.ValidatorStats, error) {
+	var list []*models.ValidatorStats
+	err := r.db.Model(&list).Select()
+	return list, err
+}
+
+// GetMissedBlocks Return missed signatures of blocks in range [fromBlock, toBlock]
+func (r *Repository) GetMissedBlocks(fromBlock, toBlock uint64) ([]*models.BlockValidator, error) {
+	var list []*models.BlockValidator
+	err := r.db.Model(&list).
+		Column("block_id", "validator_id").
+		Where("block_id BETWEEN ? AND ?", fromBlock, toBlock).
+		Where("NOT signed").
+		Order("block_id").
+		Select()
+	return list, err
+}
+
+func (r *Repository) ClearStats() error {
+	_, err := r.db.Exec(`TRUNCATE validator_stats, validator_daily_uptime;`)
+	return err
+}
+
+// RebuildCounters Add signatures and proposals of blocks in range [fromBlock, toBlock] to the counters
+func (r *Repository) RebuildCounters(fromBlock, toBlock uint64) error {
+	return r.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		_, err := tx.Exec(`
+insert into validator_stats (validator_id, signed_blocks, missed_blocks, last_block_id)
+  (select validator_id, count(*) filter (where signed), count(*) filter (where not signed), max(block_id)
+   from block_validator
+   where block_id between ?0 and ?1
+   group by validator_id
+   order by validator_id)
+ON CONFLICT (validator_id) DO UPDATE
+  SET signed_blocks = validator_stats.signed_blocks + excluded.signed_blocks,
+      missed_blocks = validator_stats.missed_blocks + excluded.missed_blocks,
+      last_block_id = greatest(validator_stats.last_block_id, excluded.last_block_id);
+	`, fromBlock, toBlock)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+insert into validator_stats (validator_id, proposed_blocks, last_block_id)
+  (select proposer_validator_id, count(*), max(id)
+   from blocks
+   where id between ?0 and ?1
+   group by proposer_validator_id
+   order by proposer_validator_id)
+ON CONFLICT (validator_id) DO UPDATE
+  SET proposed_blocks = validator_stats.proposed_blocks + excluded.proposed_blocks,
+      last_block_id   = greatest(validator_stats.last_block_id, excluded.last_block_id);
+	`, fromBlock, toBlock)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+insert into validator_daily_uptime (validator_id, date, signed_blocks, missed_blocks, last_block_id)
+  (select bv.validator_id, (b.created_at at time zone 'UTC')::date,
+          count(*) filter (where bv.signed), count(*) filter (where not bv.signed), max(bv.block_id)
+   from block_validator bv
+          inner join blocks b on b.id = bv.block_id
+   where bv.block_id between ?0 and ?1
+   group by 1, 2
+   order by 1, 2)
+ON CONFLICT (validator_id, date) DO UPDATE
+  SET signed_blocks = validator_daily_uptime.signed_blocks + excluded.signed_blocks,
+      missed_blocks = validator_daily_uptime.missed_blocks + excluded.missed_blocks,
+      last_block_id = greatest(validator_daily_uptime.last_block_id, excluded.last_block_id);
+	`, fromBlock, toBlock)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+insert into validator_daily_uptime (validator_id, date, proposed_blocks, last_block_id)
+  (select proposer_validator_id, (created_at at time zone 'UTC')::date, count(*), max(id)
+   from blocks
+   where id between ?0 and ?1
+   group by 1, 2
+   order by 1, 2)
+ON CONFLICT (validator_id, date) DO UPDATE
+  SET proposed_blocks = validator_daily_uptime.proposed_blocks + excluded.proposed_blocks,
+      last_block_id   = greatest(validator_daily_uptime.last_block_id, excluded.last_block_id);
+	`, fromBlock, toBlock)
+		return err
+	})
+}
+
+// RebuildStreaks Set uptime, jails, current and max miss streaks and misses in the last window blocks
+// after all counters are rebuilt. Max streaks are calculated over the whole block_validator table
+func (r *Repository) RebuildStreaks(lastBlock, window uint64) error {
+	return r.db.RunInTransaction(context.Background(), func(tx *pg.Tx) error {
+		_, err := tx.Exec(`
+update validator_daily_uptime
+set uptime = round(100.0 * signed_blocks / greatest(signed_blocks + missed_blocks, 1), 2);
+	`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+update validator_stats s
+set jails_count           = b.jails_count,
+    last_jailed_block_id  = b.last_jailed_block_id,
+    jailed_until_block_id = b.jailed_until_block_id
+from (select validator_id, count(*) as jails_count, max(block_id) as last_jailed_block_id, max(to_block_id) as jailed_until_block_id
+      from validator_bans
+      group by validator_id) b
+where s.validator_id = b.validator_id;
+	`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+update validator_stats s
+set missed_in_window = (select count(*)
+                        from block_validator bv
+                        where bv.validator_id = s.validator_id and not bv.signed and bv.block_id > ?0 - ?1),
+    miss_streak      = (select count(*)
+                        from block_validator bv
+                        where bv.validator_id = s.validator_id and not bv.signed
+                          and bv.block_id > coalesce((select max(block_id) from block_validator
+                                                      where validator_id = s.validator_id and signed), 0));
+	`, lastBlock, window)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+update validator_stats s
+set max_miss_streak = m.max_miss_streak
+from (select validator_id, max(streak) as max_miss_streak
+      from (select validator_id, grp, count(*) as streak
+            from (select validator_id, signed,
+                         row_number() over (partition by validator_id order by block_id) -
+                         row_number() over (partition by validator_id, signed order by block_id) as grp
+                  from block_validator) t
+            where not signed
+            group by validator_id, grp) streaks
+      group by validator_id) m
+where s.validator_id = m.validator_id;
+	`)
+		return err
+	})
+}
@@ -0,0 +1,278 @@
+package validator_stats
+
+import (
+	"fmt"
+	"github.com/MinterTeam/minter-explorer-extender/v2/broadcast"
+	"github.com/MinterTeam/minter-explorer-extender/v2/models"
+	"github.com/MinterTeam/minter-explorer-extender/v2/validator"
+	"github.com/MinterTeam/minter-explorer-tools/v4/helpers"
+	"github.com/MinterTeam/node-grpc-gateway/api_pb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+var (
+	missStreakGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "extender_validator_miss_streak",
+		Help: "Number of blocks missed by a validator in a row",
+	}, []string{"public_key"})
+	missStreakAlerts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "extender_validator_miss_streak_alerts_total",
+		Help: "Number of times a validator miss streak reached APP_VALIDATOR_MISS_STREAK_ALERT",
+	}, []string{"public_key"})
+)
+
+const rebuildChunk = 10000
+
+// validatorState Signing state kept between blocks, only the stats worker goroutine uses it
+type validatorState struct {
+	missed     []uint64 // heights of missed blocks inside the window, ascending
+	missStreak uint64
+}
+
+type Service struct {
+	repository          *Repository
+	validatorRepository *validator.Repository
+	broadcastService    *broadcast.Service
+	window              uint64
+	alertThreshold      uint64
+	validators          map[uint64]*validatorState
+	height              uint64 // last applied block
+	logger              *logrus.Entry
+}
+
+func NewService(repository *Repository, validatorRepository *validator.Repository, broadcastService *broadcast.Service,
+	window, alertThreshold uint64, logger *logrus.Entry) *Service {
+	return &Service{
+		repository:          repository,
+		validatorRepository: validatorRepository,
+		broadcastService:    broadcastService,
+		window:              window,
+		alertThreshold:      alertThreshold,
+		logger:              logger,
+	}
+}
+
+// HandleBlock Update validator statistics with signatures, proposer and jails of the block.
+// Blocks must be handled in order, a block which isn't higher than the last applied one is skipped.
+// Signing state is changed only after the statistics of the block are saved
+func (s *Service) HandleBlock(b *api_pb.BlockResponse) error {
+	if b.Height == 1 || b.Height <= s.height {
+		return nil
+	}
+	if s.validators == nil {
+		err := s.load(b.Height)
+		if err != nil {
+			return err
+		}
+	}
+
+	blockTime, err := time.Parse("2006-01-02T15:04:05Z", b.Time)
+	if err != nil {
+		return err
+	}
+	date, _ := models.RewardPeriodStart(models.RewardPeriodDay, blockTime)
+
+	stats := make(map[uint64]*models.ValidatorStats)
+	daily := make(map[uint64]*models.ValidatorDailyUptime)
+	getStats := func(id uint64) (*models.ValidatorStats, *models.ValidatorDailyUptime) {
+		if _, ok := stats[id]; !ok {
+			stats[id] = &models.ValidatorStats{ValidatorID: id, LastBlockID: b.Height}
+			daily[id] = &models.ValidatorDailyUptime{ValidatorID: id, Date: date, Uptime: "100", LastBlockID: b.Height}
+		}
+		return stats[id], daily[id]
+	}
+
+	// new signing state of validators of the block, it replaces the current one after the save
+	states := make(map[uint64]*validatorState)
+	getState := func(id uint64) *validatorState {
+		if _, ok := states[id]; !ok {
+			states[id] = s.getState(id).copy()
+		}
+		return states[id]
+	}
+
+	var alerts []models.ValidatorAlert
+	streaks := make(map[string]uint64)
+	for _, v := range b.Validators {
+		id, err := s.validatorRepository.FindIdByPk(helpers.RemovePrefix(v.PublicKey))
+		if err != nil {
+			return err
+		}
+		st, day := getStats(uint64(id))
+		state := getState(st.ValidatorID)
+
+		if v.Signed {
+			if s.alertThreshold > 0 && state.missStreak >= s.alertThreshold {
+				alerts = append(alerts, models.ValidatorAlert{Type: models.ValidatorAlertRecovered, ValidatorID: st.ValidatorID, PublicKey: v.PublicKey, MissStreak: state.missStreak, BlockID: b.Height})
+			}
+			state.missStreak = 0
+			st.SignedBlocks++
+			day.SignedBlocks++
+		} else {
+			state.missed = append(state.missed, b.Height)
+			state.missStreak++
+			st.MissedBlocks++
+			day.MissedBlocks++
+			day.Uptime = "0"
+			if s.alertThreshold > 0 && state.missStreak == s.alertThreshold {
+				alerts = append(alerts, models.ValidatorAlert{Type: models.ValidatorAlertMissStreak, ValidatorID: st.ValidatorID, PublicKey: v.PublicKey, MissStreak: state.missStreak, BlockID: b.Height})
+			}
+		}
+		streaks[v.PublicKey] = state.missStreak
+	}
+
+	if b.Proposer != "" {
+		id, err := s.validatorRepository.FindIdByPk(helpers.RemovePrefix(b.Proposer))
+		if err != nil {
+			return err
+		}
+		st, day := getStats(uint64(id))
+		st.ProposedBlocks++
+		day.ProposedBlocks++
+	}
+
+	for _, event := range b.Events {
+		if !event.MessageIs(&api_pb.JailEvent{}) {
+			continue
+		}
+		e := new(api_pb.JailEvent)
+		if err := event.UnmarshalTo(e); err != nil {
+			return err
+		}
+		id, err := s.validatorRepository.FindIdByPk(helpers.RemovePrefix(e.ValidatorPubKey))
+		if err != nil {
+			return err
+		}
+		st, _ := getStats(uint64(id))
+		height, jailedUntil := b.Height, e.JailedUntil
+		st.JailsCount++
+		st.LastJailedBlockID = &height
+		st.JailedUntilBlockID = &jailedUntil
+	}
+
+	var (
+		statsList []*models.ValidatorStats
+		dailyList []*models.ValidatorDailyUptime
+	)
+	for id, st := range stats {
+		state := getState(id)
+		state.trim(b.Height, s.window)
+		st.MissedInWindow = uint64(len(state.missed))
+		st.MissStreak = state.missStreak
+		st.MaxMissStreak = state.missStreak
+		statsList = append(statsList, st)
+		dailyList = append(dailyList, daily[id])
+	}
+	applied, err := s.repository.SaveStats(statsList, dailyList)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		s.logger.WithField("block", b.Height).Warning("validator statistics of the block are already saved")
+		return nil
+	}
+
+	for id, state := range states {
+		s.validators[id] = state
+	}
+	s.height = b.Height
+	for publicKey, streak := range streaks {
+		missStreakGauge.WithLabelValues(publicKey).Set(float64(streak))
+	}
+	for _, alert := range alerts {
+		s.alert(alert)
+	}
+	return nil
+}
+
+// load Restore miss streaks and misses of the window before the first handled block
+func (s *Service) load(height uint64) error {
+	s.validators = make(map[uint64]*validatorState)
+
+	list, err := s.repository.GetAllStats()
+	if err != nil {
+		return err
+	}
+	for _, st := range list {
+		s.getState(st.ValidatorID).missStreak = st.MissStreak
+	}
+
+	var from uint64 = 1
+	if height > s.window {
+		from = height - s.window
+	}
+	missed, err := s.repository.GetMissedBlocks(from, height-1)
+	if err != nil {
+		return err
+	}
+	for _, m := range missed {
+		state := s.getState(m.ValidatorID)
+		state.missed = append(state.missed, m.BlockID)
+	}
+	return nil
+}
+
+func (s *Service) getState(validatorId uint64) *validatorState {
+	state, ok := s.validators[validatorId]
+	if !ok {
+		state = new(validatorState)
+		s.validators[validatorId] = state
+	}
+	return state
+}
+
+// copy Return a state which can be changed without touching st
+func (st *validatorState) copy() *validatorState {
+	return &validatorState{
+		missed:     append([]uint64(nil), st.missed...),
+		missStreak: st.missStreak,
+	}
+}
+
+// trim Forget misses which are out of the window ending at height
+func (st *validatorState) trim(height, window uint64) {
+	i := 0
+	for i < len(st.missed) && st.missed[i]+window <= height {
+		i++
+	}
+	st.missed = st.missed[i:]
+}
+
+func (s *Service) alert(alert models.ValidatorAlert) {
+	fields := logrus.Fields{
+		"validator":   alert.PublicKey,
+		"miss_streak": alert.MissStreak,
+		"block":       alert.BlockID,
+	}
+	if alert.Type == models.ValidatorAlertMissStreak {
+		missStreakAlerts.WithLabelValues(alert.PublicKey).Inc()
+		s.logger.WithFields(fields).Warning(fmt.Sprintf("Validator missed %d blocks in a row", alert.MissStreak))
+	} else {
+		s.logger.WithFields(fields).Warning("Validator signs blocks again")
+	}
+	s.broadcastService.ValidatorAlertsChannel() <- alert
+}
+
+// Rebuild Recalculate statistics of blocks up to lastBlock from block_validator, blocks and validator_bans.
+// The extender must be stopped
+func (s *Service) Rebuild(lastBlock uint64) error {
+	err := s.repository.ClearStats()
+	if err != nil {
+		return err
+	}
+	for from := uint64(1); from <= lastBlock; from += rebuildChunk {
+		to := from + rebuildChunk - 1
+		if to > lastBlock {
+			to = lastBlock
+		}
+		err = s.repository.RebuildCounters(from, to)
+		if err != nil {
+			return err
+		}
+		s.logger.Warning(fmt.Sprintf("Validator stats rebuilt up to block %d of %d", to, lastBlock))
+	}
+	return s.repository.RebuildStreaks(lastBlock, s.window)
+}